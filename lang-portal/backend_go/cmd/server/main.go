@@ -1,70 +1,158 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/erans/lang-portal/internal/api"
+	"github.com/erans/lang-portal/internal/config"
 	"github.com/erans/lang-portal/internal/database"
-	"github.com/erans/lang-portal/internal/service"
+	"github.com/erans/lang-portal/internal/jobs"
+	"github.com/erans/lang-portal/internal/server"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// Load configuration from the config file, environment and flags
+	cfg, flags, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	if flags.PrintConfig {
+		out, err := cfg.YAML()
+		if err != nil {
+			log.Fatal("Failed to render configuration: ", err)
+		}
+		fmt.Print(string(out))
+		return
+	}
+
 	// Initialize database
-	if err := database.Initialize(); err != nil {
+	db, err := database.Initialize(cfg.Database)
+	if err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
-	defer database.Close()
 
 	// Run migrations
-	if err := database.RunMigrations(); err != nil {
+	if err := database.RunMigrations(db); err != nil {
+		database.Shutdown(db)
 		log.Fatal("Failed to run migrations:", err)
 	}
 
+	// Apply JSON seeds when requested
+	if cfg.Database.Seed {
+		if err := database.RunSeeds(db); err != nil {
+			database.Shutdown(db)
+			log.Fatal("Failed to run seeds:", err)
+		}
+	}
+
 	// Create services
-	wordService := service.NewWordService(database.GetDB())
-	groupService := service.NewGroupService(database.GetDB())
-	dashboardService := service.NewDashboardService(database.GetDB())
-	studySessionService := service.NewStudySessionService(database.GetDB())
-	studyActivityService := service.NewStudyActivityService(database.GetDB())
-	systemService := service.NewSystemService(database.GetDB())
-
-	// Create handlers
-	wordHandler := api.NewWordHandler(wordService)
-	groupHandler := api.NewGroupHandler(groupService)
-	dashboardHandler := api.NewDashboardHandler(dashboardService)
-	studySessionHandler := api.NewStudySessionHandler(studySessionService)
-	studyActivityHandler := api.NewStudyActivityHandler(studyActivityService)
-	systemHandler := api.NewSystemHandler(systemService)
-
-	// Create default gin engine with middleware
-	r := gin.Default()
+	services := api.NewServices(db, api.Options{
+		Limits: api.Limits{
+			PageSize:    cfg.Pagination.PageSize,
+			MaxPageSize: cfg.Pagination.MaxPageSize,
+		},
+		BackupDir:      cfg.Backup.Dir,
+		AllowAnonymous: cfg.Auth.Anonymous,
+	})
+
+	// Issue a token from the command line, e.g. the first admin token
+	if flags.IssueToken != "" {
+		err := issueToken(services, flags.IssueToken)
+		database.Shutdown(db)
+		if err != nil {
+			log.Fatal("Failed to issue token: ", err)
+		}
+		return
+	}
+
+	// Create gin engine with middleware for the configured log level
+	r := newEngine(cfg.Log)
 
 	// Add CORS middleware
-	r.Use(corsMiddleware())
-
-	// Register all routes
-	wordHandler.RegisterRoutes(r)
-	groupHandler.RegisterRoutes(r)
-	dashboardHandler.RegisterRoutes(r)
-	studySessionHandler.RegisterRoutes(r)
-	studyActivityHandler.RegisterRoutes(r)
-	systemHandler.RegisterRoutes(r)
-
-	// Add basic health check
-	r.GET("/health", healthCheck)
-
-	log.Println("Server starting on :8080...")
-	// Run the server
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	r.Use(corsMiddleware(cfg.CORS.AllowedOrigins))
+
+	// Register all routes, with the health check
+	api.RegisterRoutes(r, services)
+
+	// Serve until SIGINT or SIGTERM, then drain requests and close the
+	// database. The server owns the connection from here on.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := server.New(cfg.Server.Addr, r, db, time.Duration(cfg.Server.ShutdownTimeout))
+	// A second signal during shutdown kills the process immediately
+	srv.RegisterOnShutdown(stop)
+
+	// Run the background jobs while serving
+	if runner := newJobRunner(cfg.Sessions, services); runner != nil {
+		srv.Background(runner.Run)
+	}
+
+	if err := srv.Run(ctx); err != nil {
+		log.Fatal(err)
 	}
+	log.Println("Server stopped")
 }
 
-// corsMiddleware handles CORS headers
-func corsMiddleware() gin.HandlerFunc {
+// newJobRunner creates the runner for the background jobs that are turned
+// on, or returns nil when there are none
+func newJobRunner(sessions config.SessionsConfig, services *api.Services) *jobs.Runner {
+	if sessions.IdleTimeout == 0 {
+		return nil
+	}
+	return jobs.NewRunner(jobs.SessionReaper(
+		services.Sessions,
+		time.Duration(sessions.IdleTimeout),
+		time.Duration(sessions.ReapInterval),
+		jobs.SystemClock,
+	))
+}
+
+// newEngine creates the gin engine. Only debug logging puts gin in debug
+// mode, and the access log is dropped at warn and error.
+func newEngine(logCfg config.LogConfig) *gin.Engine {
+	if logCfg.Level == config.LogDebug {
+		gin.SetMode(gin.DebugMode)
+	} else {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	r := gin.New()
+	r.Use(gin.Recovery())
+	if logCfg.Level == config.LogDebug || logCfg.Level == config.LogInfo {
+		r.Use(gin.Logger())
+	}
+	return r
+}
+
+// corsMiddleware handles CORS headers for the allowed origins
+func corsMiddleware(allowedOrigins []string) gin.HandlerFunc {
+	allowAny := false
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAny = true
+		}
+		allowed[origin] = true
+	}
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		if allowAny {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Writer.Header().Add("Vary", "Origin")
+			if origin := c.GetHeader("Origin"); allowed[origin] {
+				c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+		}
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
@@ -77,39 +165,19 @@ func corsMiddleware() gin.HandlerFunc {
 	}
 }
 
-// healthCheck handles the health check endpoint
-func healthCheck(c *gin.Context) {
-	c.JSON(200, gin.H{
-		"status":  "healthy",
-		"version": "1.0.0",
-	})
-}
-
-func setupRoutes(r *gin.Engine) {
-	// Health check
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-		})
-	})
+// issueToken prints a new API token for the named user. The default user
+// is an admin, so its first token can create the other accounts.
+func issueToken(services *api.Services, name string) error {
+	user, err := services.Users.GetUserByName(name)
+	if err != nil {
+		return err
+	}
 
-	// API group
-	api := r.Group("/api")
-	{
-		// Dashboard endpoints
-		api.GET("/dashboard/last_session", func(c *gin.Context) {
-			// TODO: Implement last session endpoint
-			c.JSON(501, gin.H{"error": "Not implemented"})
-		})
-
-		api.GET("/dashboard/stats", func(c *gin.Context) {
-			// TODO: Implement stats endpoint
-			c.JSON(501, gin.H{"error": "Not implemented"})
-		})
-
-		api.GET("/dashboard/progress", func(c *gin.Context) {
-			// TODO: Implement progress endpoint
-			c.JSON(501, gin.H{"error": "Not implemented"})
-		})
+	token, err := services.Auth.IssueToken(user.ID, "issued from the command line", nil)
+	if err != nil {
+		return err
 	}
+
+	fmt.Printf("%s (%s) token %d: %s\n", user.Name, user.Role, token.ID, token.Token)
+	return nil
 }
@@ -0,0 +1,78 @@
+-- Create words table
+CREATE TABLE IF NOT EXISTS words (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    japanese TEXT NOT NULL,
+    romaji TEXT NOT NULL,
+    english TEXT NOT NULL,
+    parts JSONB NOT NULL DEFAULT '{}'
+);
+
+-- Create groups table
+CREATE TABLE IF NOT EXISTS groups (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL
+);
+
+-- Create word_groups table (many-to-many relationship)
+CREATE TABLE IF NOT EXISTS word_groups (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    word_id BIGINT NOT NULL,
+    group_id BIGINT NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    UNIQUE(word_id, group_id)
+);
+
+-- Create study_activities table
+CREATE TABLE IF NOT EXISTS study_activities (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    group_id BIGINT NOT NULL,
+    activity_type TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+-- Create study_sessions table
+CREATE TABLE IF NOT EXISTS study_sessions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    start_time TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    end_time TIMESTAMPTZ,
+    score DOUBLE PRECISION,
+    status TEXT NOT NULL CHECK(status IN ('active', 'completed', 'abandoned')),
+    study_activity_id BIGINT NOT NULL,
+    FOREIGN KEY (study_activity_id) REFERENCES study_activities(id) ON DELETE CASCADE
+);
+
+-- Create word_review_items table
+CREATE TABLE IF NOT EXISTS word_review_items (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    session_id BIGINT NOT NULL,
+    word_id BIGINT NOT NULL,
+    is_correct BOOLEAN NOT NULL,
+    reviewed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+
+-- Create backup_history table
+CREATE TABLE IF NOT EXISTS backup_history (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    backup_path TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    size_bytes BIGINT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'completed',
+    error_message TEXT
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_words_japanese ON words(japanese);
+CREATE INDEX IF NOT EXISTS idx_words_english ON words(english);
+CREATE INDEX IF NOT EXISTS idx_groups_name ON groups(name);
+CREATE INDEX IF NOT EXISTS idx_word_groups_word_id ON word_groups(word_id);
+CREATE INDEX IF NOT EXISTS idx_word_groups_group_id ON word_groups(group_id);
+CREATE INDEX IF NOT EXISTS idx_study_sessions_activity_id ON study_sessions(study_activity_id);
+CREATE INDEX IF NOT EXISTS idx_study_activities_group_id ON study_activities(group_id);
+CREATE INDEX IF NOT EXISTS idx_word_review_items_session_id ON word_review_items(session_id);
+CREATE INDEX IF NOT EXISTS idx_word_review_items_word_id ON word_review_items(word_id);
+CREATE INDEX IF NOT EXISTS idx_backup_history_created_at ON backup_history(created_at);
@@ -0,0 +1,12 @@
+-- Spaced-repetition state for each word, updated whenever a review is recorded
+CREATE TABLE IF NOT EXISTS word_review_schedules (
+    word_id BIGINT PRIMARY KEY,
+    ease_factor DOUBLE PRECISION NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    due_at TIMESTAMPTZ NOT NULL,
+    last_reviewed_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_review_schedules_due_at ON word_review_schedules(due_at);
@@ -0,0 +1,4 @@
+-- The pg_trgm extension is left installed; other schemas may use it
+DROP INDEX IF EXISTS idx_words_english_trgm;
+DROP INDEX IF EXISTS idx_words_romaji_trgm;
+DROP INDEX IF EXISTS idx_words_japanese_trgm;
@@ -0,0 +1,9 @@
+-- Trigram indexes over the vocabulary, the counterpart of the SQLite FTS5
+-- table. They let ILIKE '%term%' searches on any column use an index and
+-- provide similarity() for ranking. Creating the extension needs the
+-- CREATE privilege on the database.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_words_japanese_trgm ON words USING gin (japanese gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_words_romaji_trgm ON words USING gin (romaji gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_words_english_trgm ON words USING gin (english gin_trgm_ops);
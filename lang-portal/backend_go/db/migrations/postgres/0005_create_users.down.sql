@@ -0,0 +1,12 @@
+-- Only the default user's schedules fit the single-learner table
+DELETE FROM word_review_schedules WHERE user_id <> 1;
+ALTER TABLE word_review_schedules DROP CONSTRAINT word_review_schedules_pkey;
+ALTER TABLE word_review_schedules ADD PRIMARY KEY (word_id);
+DROP INDEX IF EXISTS idx_word_review_schedules_due_at;
+ALTER TABLE word_review_schedules DROP COLUMN user_id;
+CREATE INDEX IF NOT EXISTS idx_word_review_schedules_due_at ON word_review_schedules(due_at);
+
+DROP INDEX IF EXISTS idx_study_sessions_user_id;
+ALTER TABLE study_sessions DROP COLUMN user_id;
+
+DROP TABLE IF EXISTS users;
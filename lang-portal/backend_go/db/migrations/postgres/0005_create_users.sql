@@ -0,0 +1,25 @@
+-- Learners, each with their own study history. Data recorded before
+-- accounts existed belongs to the default user.
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO users (id, name) VALUES (1, 'default');
+SELECT setval(pg_get_serial_sequence('users', 'id'), 1);
+
+ALTER TABLE study_sessions
+    ADD COLUMN user_id BIGINT NOT NULL DEFAULT 1 REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_study_sessions_user_id ON study_sessions(user_id);
+
+-- Review schedules are kept per learner
+ALTER TABLE word_review_schedules
+    ADD COLUMN user_id BIGINT NOT NULL DEFAULT 1 REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE word_review_schedules ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE word_review_schedules DROP CONSTRAINT word_review_schedules_pkey;
+ALTER TABLE word_review_schedules ADD PRIMARY KEY (user_id, word_id);
+
+DROP INDEX IF EXISTS idx_word_review_schedules_due_at;
+CREATE INDEX IF NOT EXISTS idx_word_review_schedules_due_at ON word_review_schedules(user_id, due_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_activity_launches_user_id;
+DROP TABLE IF EXISTS activity_launches;
+
+DROP INDEX IF EXISTS idx_study_activities_app_id;
+ALTER TABLE study_activities DROP COLUMN app_id;
+
+DROP TABLE IF EXISTS activity_apps;
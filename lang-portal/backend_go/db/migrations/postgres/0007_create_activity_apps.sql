@@ -0,0 +1,30 @@
+-- The activity catalog: learning apps the portal launches against a group.
+-- launch_url is a template; see the README for its placeholders.
+CREATE TABLE IF NOT EXISTS activity_apps (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    activity_type TEXT NOT NULL,
+    thumbnail_url TEXT NOT NULL DEFAULT '',
+    launch_url TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Activities opened from the catalog remember their app
+ALTER TABLE study_activities
+    ADD COLUMN app_id BIGINT REFERENCES activity_apps(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_study_activities_app_id ON study_activities(app_id, group_id);
+
+-- Every launch of an app, with the session it opened and the URL the
+-- learner was sent to
+CREATE TABLE IF NOT EXISTS activity_launches (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    app_id BIGINT NOT NULL REFERENCES activity_apps(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    group_id BIGINT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    session_id BIGINT NOT NULL REFERENCES study_sessions(id) ON DELETE CASCADE,
+    launch_url TEXT NOT NULL,
+    launched_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_activity_launches_user_id ON activity_launches(user_id, launched_at);
@@ -0,0 +1,3 @@
+-- How a completed session's score was computed. Sessions scored before
+-- breakdowns were stored, and sessions without a score, have none.
+ALTER TABLE study_sessions ADD COLUMN score_breakdown JSONB;
@@ -0,0 +1,20 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_backup_history_created_at;
+DROP INDEX IF EXISTS idx_word_review_items_word_id;
+DROP INDEX IF EXISTS idx_word_review_items_session_id;
+DROP INDEX IF EXISTS idx_study_activities_group_id;
+DROP INDEX IF EXISTS idx_study_sessions_activity_id;
+DROP INDEX IF EXISTS idx_word_groups_group_id;
+DROP INDEX IF EXISTS idx_word_groups_word_id;
+DROP INDEX IF EXISTS idx_groups_name;
+DROP INDEX IF EXISTS idx_words_english;
+DROP INDEX IF EXISTS idx_words_japanese;
+
+-- Drop tables in reverse dependency order
+DROP TABLE IF EXISTS backup_history;
+DROP TABLE IF EXISTS word_review_items;
+DROP TABLE IF EXISTS study_sessions;
+DROP TABLE IF EXISTS study_activities;
+DROP TABLE IF EXISTS word_groups;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS words;
@@ -0,0 +1,2 @@
+-- Store the learner's free-text answer with each review
+ALTER TABLE word_review_items ADD COLUMN response TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,32 @@
+-- Full-text index over the vocabulary. The trigram tokenizer matches any
+-- substring of three or more characters, which covers kana and kanji as
+-- well as romaji and English glosses. Requires the sqlite_fts5 build tag.
+CREATE VIRTUAL TABLE IF NOT EXISTS words_fts USING fts5(
+    japanese,
+    romaji,
+    english,
+    content='words',
+    content_rowid='id',
+    tokenize='trigram'
+);
+
+-- Keep the index in sync with the words table
+CREATE TRIGGER IF NOT EXISTS words_fts_after_insert AFTER INSERT ON words BEGIN
+    INSERT INTO words_fts (rowid, japanese, romaji, english)
+    VALUES (new.id, new.japanese, new.romaji, new.english);
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_fts_after_delete AFTER DELETE ON words BEGIN
+    INSERT INTO words_fts (words_fts, rowid, japanese, romaji, english)
+    VALUES ('delete', old.id, old.japanese, old.romaji, old.english);
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_fts_after_update AFTER UPDATE ON words BEGIN
+    INSERT INTO words_fts (words_fts, rowid, japanese, romaji, english)
+    VALUES ('delete', old.id, old.japanese, old.romaji, old.english);
+    INSERT INTO words_fts (rowid, japanese, romaji, english)
+    VALUES (new.id, new.japanese, new.romaji, new.english);
+END;
+
+-- Index the existing vocabulary
+INSERT INTO words_fts (words_fts) VALUES ('rebuild');
@@ -0,0 +1,42 @@
+-- Only the default user's schedules fit the single-learner table
+CREATE TABLE word_review_schedules_old (
+    word_id INTEGER PRIMARY KEY,
+    ease_factor REAL NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    due_at DATETIME NOT NULL,
+    last_reviewed_at DATETIME NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+
+INSERT INTO word_review_schedules_old
+    (word_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at)
+SELECT word_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at
+FROM word_review_schedules
+WHERE user_id = 1;
+
+DROP TABLE word_review_schedules;
+ALTER TABLE word_review_schedules_old RENAME TO word_review_schedules;
+CREATE INDEX IF NOT EXISTS idx_word_review_schedules_due_at ON word_review_schedules(due_at);
+
+-- A REFERENCES column cannot be dropped, so the sessions are rebuilt
+-- without it
+CREATE TABLE study_sessions_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    start_time DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    end_time DATETIME,
+    score REAL,
+    status TEXT NOT NULL CHECK(status IN ('active', 'completed', 'abandoned')),
+    study_activity_id INTEGER NOT NULL,
+    FOREIGN KEY (study_activity_id) REFERENCES study_activities(id) ON DELETE CASCADE
+);
+
+INSERT INTO study_sessions_old (id, start_time, end_time, score, status, study_activity_id)
+SELECT id, start_time, end_time, score, status, study_activity_id
+FROM study_sessions;
+
+DROP TABLE study_sessions;
+ALTER TABLE study_sessions_old RENAME TO study_sessions;
+CREATE INDEX IF NOT EXISTS idx_study_sessions_activity_id ON study_sessions(study_activity_id);
+
+DROP TABLE IF EXISTS users;
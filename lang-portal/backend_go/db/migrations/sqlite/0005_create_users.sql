@@ -0,0 +1,59 @@
+-- Learners, each with their own study history. Data recorded before
+-- accounts existed belongs to the default user.
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO users (id, name) VALUES (1, 'default');
+
+-- SQLite cannot add a REFERENCES column with a default, so the sessions
+-- are rebuilt with it. Migrations run with foreign keys off, so dropping
+-- the old table leaves the review items and launches that point at it.
+CREATE TABLE study_sessions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    start_time DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    end_time DATETIME,
+    score REAL,
+    status TEXT NOT NULL CHECK(status IN ('active', 'completed', 'abandoned')),
+    study_activity_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (study_activity_id) REFERENCES study_activities(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO study_sessions_new (id, start_time, end_time, score, status, study_activity_id)
+SELECT id, start_time, end_time, score, status, study_activity_id
+FROM study_sessions;
+
+DROP TABLE study_sessions;
+ALTER TABLE study_sessions_new RENAME TO study_sessions;
+
+CREATE INDEX IF NOT EXISTS idx_study_sessions_activity_id ON study_sessions(study_activity_id);
+CREATE INDEX IF NOT EXISTS idx_study_sessions_user_id ON study_sessions(user_id);
+
+-- Review schedules are kept per learner. Nothing references the table, so
+-- it can be rebuilt with the wider key.
+CREATE TABLE word_review_schedules_new (
+    user_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    ease_factor REAL NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    due_at DATETIME NOT NULL,
+    last_reviewed_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, word_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+
+INSERT INTO word_review_schedules_new
+    (user_id, word_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at)
+SELECT 1, word_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at
+FROM word_review_schedules;
+
+DROP TABLE word_review_schedules;
+ALTER TABLE word_review_schedules_new RENAME TO word_review_schedules;
+
+CREATE INDEX IF NOT EXISTS idx_word_review_schedules_due_at ON word_review_schedules(user_id, due_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_api_tokens_user_id;
+DROP TABLE IF EXISTS api_tokens;
+
+ALTER TABLE users DROP COLUMN role;
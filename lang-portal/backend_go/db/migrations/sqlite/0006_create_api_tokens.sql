@@ -0,0 +1,20 @@
+-- Roles decide what a user may change. The default user owns the existing
+-- installation and becomes its admin; everyone else starts as a learner.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'learner'
+    CHECK (role IN ('learner', 'teacher', 'admin'));
+
+UPDATE users SET role = 'admin' WHERE id = 1;
+
+-- API tokens authenticate requests. Only a SHA-256 hash of each secret is
+-- stored.
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
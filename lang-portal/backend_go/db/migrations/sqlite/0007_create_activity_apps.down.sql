@@ -0,0 +1,22 @@
+DROP INDEX IF EXISTS idx_activity_launches_user_id;
+DROP TABLE IF EXISTS activity_launches;
+
+-- A REFERENCES column cannot be dropped, so the activities are rebuilt
+-- without it
+CREATE TABLE study_activities_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    activity_type TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+INSERT INTO study_activities_old (id, group_id, activity_type, created_at)
+SELECT id, group_id, activity_type, created_at
+FROM study_activities;
+
+DROP TABLE study_activities;
+ALTER TABLE study_activities_old RENAME TO study_activities;
+CREATE INDEX IF NOT EXISTS idx_study_activities_group_id ON study_activities(group_id);
+
+DROP TABLE IF EXISTS activity_apps;
@@ -0,0 +1,52 @@
+-- The activity catalog: learning apps the portal launches against a group.
+-- launch_url is a template; see the README for its placeholders.
+CREATE TABLE IF NOT EXISTS activity_apps (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    activity_type TEXT NOT NULL,
+    thumbnail_url TEXT NOT NULL DEFAULT '',
+    launch_url TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Activities opened from the catalog remember their app. SQLite cannot add
+-- a REFERENCES column with an action, so the activities are rebuilt with
+-- it. Migrations run with foreign keys off, so dropping the old table
+-- leaves the sessions and review items that point at it.
+CREATE TABLE study_activities_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    activity_type TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    app_id INTEGER,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (app_id) REFERENCES activity_apps(id) ON DELETE SET NULL
+);
+
+INSERT INTO study_activities_new (id, group_id, activity_type, created_at)
+SELECT id, group_id, activity_type, created_at
+FROM study_activities;
+
+DROP TABLE study_activities;
+ALTER TABLE study_activities_new RENAME TO study_activities;
+
+CREATE INDEX IF NOT EXISTS idx_study_activities_group_id ON study_activities(group_id);
+CREATE INDEX IF NOT EXISTS idx_study_activities_app_id ON study_activities(app_id, group_id);
+
+-- Every launch of an app, with the session it opened and the URL the
+-- learner was sent to
+CREATE TABLE IF NOT EXISTS activity_launches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    group_id INTEGER NOT NULL,
+    session_id INTEGER NOT NULL,
+    launch_url TEXT NOT NULL,
+    launched_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (app_id) REFERENCES activity_apps(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (session_id) REFERENCES study_sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_activity_launches_user_id ON activity_launches(user_id, launched_at);
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ActivityAppHandler handles the activity catalog and app launches
+type ActivityAppHandler struct {
+	appService *service.ActivityAppService
+	limits     Limits
+}
+
+// NewActivityAppHandler creates a new ActivityAppHandler
+func NewActivityAppHandler(appService *service.ActivityAppService, limits Limits) *ActivityAppHandler {
+	return &ActivityAppHandler{appService: appService, limits: limits}
+}
+
+// RegisterRoutes registers the catalog and launch routes. Only teachers and
+// admins may change the catalog; anyone may launch an app.
+func (h *ActivityAppHandler) RegisterRoutes(router *gin.RouterGroup) {
+	apps := router.Group("/apps")
+	{
+		apps.GET("", h.ListApps)
+		apps.GET("/:id", h.GetApp)
+		apps.POST("", staffOnly, h.CreateApp)
+		apps.PUT("/:id", staffOnly, h.UpdateApp)
+		apps.DELETE("/:id", staffOnly, h.DeleteApp)
+		apps.POST("/:id/launch", h.LaunchApp)
+	}
+	router.GET("/launches", h.ListLaunches)
+}
+
+// ListApps handles GET /api/apps
+func (h *ActivityAppHandler) ListApps(c *gin.Context) {
+	req, page, err := h.limits.listParams(c, repository.AppListing)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.appService.ListApps(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, listResponse(req, page, result))
+}
+
+// GetApp handles GET /api/apps/:id
+func (h *ActivityAppHandler) GetApp(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid app ID"))
+		return
+	}
+
+	app, err := h.appService.GetApp(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, app)
+}
+
+// CreateApp handles POST /api/apps
+func (h *ActivityAppHandler) CreateApp(c *gin.Context) {
+	var app models.ActivityApp
+	if err := bindJSON(c, &app); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.appService.CreateApp(&app); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, app)
+}
+
+// UpdateApp handles PUT /api/apps/:id
+func (h *ActivityAppHandler) UpdateApp(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid app ID"))
+		return
+	}
+
+	var app models.ActivityApp
+	if err := bindJSON(c, &app); err != nil {
+		c.Error(err)
+		return
+	}
+
+	app.ID = id
+	if err := h.appService.UpdateApp(&app); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, app)
+}
+
+// DeleteApp handles DELETE /api/apps/:id
+func (h *ActivityAppHandler) DeleteApp(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid app ID"))
+		return
+	}
+
+	if err := h.appService.DeleteApp(id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LaunchApp handles POST /api/apps/:id/launch. It starts a session on the
+// group and returns the URL to send the learner to.
+func (h *ActivityAppHandler) LaunchApp(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid app ID"))
+		return
+	}
+
+	var request struct {
+		GroupID int64 `json:"group_id" binding:"required,exists=groups"`
+	}
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	launch, err := h.appService.Launch(currentUser(c).ID, id, request.GroupID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, launch)
+}
+
+// ListLaunches handles GET /api/launches, the current user's launches
+func (h *ActivityAppHandler) ListLaunches(c *gin.Context) {
+	req, page, err := h.limits.listParams(c, repository.LaunchListing)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.appService.ListLaunches(currentUser(c).ID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, listResponse(req, page, result))
+}
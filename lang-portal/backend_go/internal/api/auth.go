@@ -0,0 +1,117 @@
+package api
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys Authenticate stores the acting user under
+const (
+	userKey      = "lang-portal.user"
+	anonymousKey = "lang-portal.anonymous"
+)
+
+// Authenticate resolves the user a request acts for before the handlers
+// run. A bearer token in the Authorization header names the user. Requests
+// without one act for the default user with learner rights when anonymous
+// access is allowed, and are rejected with 401 otherwise.
+func Authenticate(auth *service.AuthService, allowAnonymous bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			if !allowAnonymous {
+				unauthenticated(c, service.ErrAuthenticationRequired)
+				return
+			}
+			user, err := auth.Anonymous()
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			c.Set(userKey, user)
+			c.Set(anonymousKey, true)
+			c.Next()
+			return
+		}
+
+		scheme, secret, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(secret) == "" {
+			unauthenticated(c, service.ErrInvalidToken)
+			return
+		}
+
+		user, err := auth.Authenticate(strings.TrimSpace(secret))
+		if err != nil {
+			unauthenticated(c, err)
+			return
+		}
+
+		c.Set(userKey, user)
+		c.Next()
+	}
+}
+
+// RequireRole lets a request through only if the acting user has one of
+// the roles. Anonymous requests are asked to authenticate instead.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if slices.Contains(roles, currentUser(c).Role) {
+			c.Next()
+			return
+		}
+		if isAnonymous(c) {
+			unauthenticated(c, service.ErrAuthenticationRequired)
+			return
+		}
+		c.Error(service.ErrForbidden)
+		c.Abort()
+	}
+}
+
+var (
+	// staffOnly guards vocabulary changes and maintenance
+	staffOnly = RequireRole(models.RoleTeacher, models.RoleAdmin)
+	// adminOnly guards user management
+	adminOnly = RequireRole(models.RoleAdmin)
+)
+
+// selfOrAdmin lets users act on their own account, named by the :id
+// parameter, and admins on anyone's. Anonymous requests act for nobody.
+func selfOrAdmin(c *gin.Context) {
+	if isAnonymous(c) {
+		unauthenticated(c, service.ErrAuthenticationRequired)
+		return
+	}
+	user := currentUser(c)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if user.Role != models.RoleAdmin && (err != nil || id != user.ID) {
+		c.Error(service.ErrForbidden)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// unauthenticated aborts a request with a 401 error and the challenge
+// telling clients to send a bearer token
+func unauthenticated(c *gin.Context, err error) {
+	c.Header("WWW-Authenticate", `Bearer realm="lang-portal"`)
+	c.Error(err)
+	c.Abort()
+}
+
+// currentUser returns the user Authenticate resolved for the request
+func currentUser(c *gin.Context) *models.User {
+	return c.MustGet(userKey).(*models.User)
+}
+
+// isAnonymous reports whether the request came without a token
+func isAnonymous(c *gin.Context) bool {
+	return c.GetBool(anonymousKey)
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/erans/lang-portal/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// validatorKey holds the router's validator in the request context
+const validatorKey = "lang-portal.validator"
+
+// withValidator makes v the validator of bindJSON, so every router
+// validates with its own and Gin's global validator is never set
+func withValidator(v *validation.Validator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(validatorKey, v)
+		c.Next()
+	}
+}
+
+// bindJSON decodes the JSON request body into obj and validates it with the
+// router's validator. A body that cannot be decoded or fails validation is
+// an invalid_body error; a reference that could not be checked is returned
+// as is.
+func bindJSON(c *gin.Context, obj any) error {
+	if c.Request.Body == nil {
+		return invalidBody(errors.New("invalid request"))
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(obj); err != nil {
+		return invalidBody(err)
+	}
+
+	err := c.MustGet(validatorKey).(*validation.Validator).ValidateStruct(obj)
+	var fieldErrs validation.Errors
+	if errors.As(err, &fieldErrs) {
+		return invalidBody(err)
+	}
+	return err
+}
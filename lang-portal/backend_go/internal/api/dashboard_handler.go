@@ -18,8 +18,8 @@ func NewDashboardHandler(dashboardService *service.DashboardService) *DashboardH
 }
 
 // RegisterRoutes registers the dashboard routes
-func (h *DashboardHandler) RegisterRoutes(r *gin.Engine) {
-	dashboard := r.Group("/api/dashboard")
+func (h *DashboardHandler) RegisterRoutes(r *gin.RouterGroup) {
+	dashboard := r.Group("/dashboard")
 	{
 		dashboard.GET("/last_session", h.GetLastSession)
 		dashboard.GET("/stats", h.GetStats)
@@ -29,9 +29,9 @@ func (h *DashboardHandler) RegisterRoutes(r *gin.Engine) {
 
 // GetLastSession handles GET /api/dashboard/last_session
 func (h *DashboardHandler) GetLastSession(c *gin.Context) {
-	session, err := h.dashboardService.GetLastSession()
+	session, err := h.dashboardService.GetLastSession(currentUser(c).ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -47,9 +47,9 @@ func (h *DashboardHandler) GetLastSession(c *gin.Context) {
 
 // GetStats handles GET /api/dashboard/stats
 func (h *DashboardHandler) GetStats(c *gin.Context) {
-	stats, err := h.dashboardService.GetStats()
+	stats, err := h.dashboardService.GetStats(currentUser(c).ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -58,9 +58,9 @@ func (h *DashboardHandler) GetStats(c *gin.Context) {
 
 // GetProgress handles GET /api/dashboard/progress
 func (h *DashboardHandler) GetProgress(c *gin.Context) {
-	progress, err := h.dashboardService.GetProgress()
+	progress, err := h.dashboardService.GetProgress(currentUser(c).ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
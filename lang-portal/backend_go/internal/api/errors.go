@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/erans/lang-portal/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// errorDetails is implemented by errors that carry structured context for
+// the client, such as the IDs that caused a batch operation to fail
+type errorDetails interface {
+	Details() map[string]any
+}
+
+// ErrorResponse is the envelope of every error response
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes an error to API clients. Code is stable and meant
+// for programs; Message is meant for humans.
+type ErrorBody struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// ErrorHandler renders the last error a handler attached with c.Error.
+// Service errors map to a status by kind; anything else is logged and
+// reported as an internal error without leaking its message.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		status, body := errorResponse(err)
+		if status == http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+
+		c.JSON(status, ErrorResponse{Error: body})
+	}
+}
+
+// errorResponse maps an error to its HTTP status and response body
+func errorResponse(err error) (int, ErrorBody) {
+	var serviceErr *service.Error
+	if !errors.As(err, &serviceErr) {
+		return http.StatusInternalServerError, ErrorBody{
+			Code:    "internal_error",
+			Message: "internal server error",
+		}
+	}
+
+	body := ErrorBody{Code: serviceErr.Code, Message: err.Error()}
+
+	var details errorDetails
+	if errors.As(err, &details) {
+		body.Details = details.Details()
+	}
+
+	return statusOf(serviceErr.Kind), body
+}
+
+// statusOf returns the HTTP status for a kind of service error
+func statusOf(kind service.Kind) int {
+	switch kind {
+	case service.KindNotFound:
+		return http.StatusNotFound
+	case service.KindConflict:
+		return http.StatusConflict
+	case service.KindValidation:
+		return http.StatusBadRequest
+	case service.KindPrecondition:
+		return http.StatusPreconditionFailed
+	case service.KindUnauthenticated:
+		return http.StatusUnauthorized
+	case service.KindForbidden:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// invalidParam reports a malformed path or query parameter
+func invalidParam(message string) error {
+	return service.NewValidationError("invalid_parameter", message)
+}
+
+// invalidBody reports a request body that could not be decoded or that
+// failed validation, keeping the per-field errors of the latter
+func invalidBody(err error) error {
+	var fieldErrs validation.Errors
+	if errors.As(err, &fieldErrs) {
+		return fieldErrs
+	}
+	return service.NewValidationError("invalid_body", err.Error())
+}
+
+// routeNotFound renders unknown routes with the error envelope
+func routeNotFound(c *gin.Context) {
+	c.Error(service.NewNotFoundError("route_not_found", "route not found"))
+}
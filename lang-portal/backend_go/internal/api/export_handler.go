@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/export"
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler streams vocabulary exports
+type ExportHandler struct {
+	exportService *service.ExportService
+}
+
+// NewExportHandler creates a new ExportHandler
+func NewExportHandler(exportService *service.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// RegisterRoutes registers the export route
+func (h *ExportHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/export", h.Export)
+}
+
+// Export handles GET /api/export?format=csv|json|apkg&group_id=. The file
+// is sent as an attachment named after the group.
+func (h *ExportHandler) Export(c *gin.Context) {
+	format, err := export.ParseFormat(c.DefaultQuery("format", string(export.CSV)))
+	if err != nil {
+		names := make([]string, len(export.Formats))
+		for i, f := range export.Formats {
+			names[i] = string(f)
+		}
+		c.Error(invalidParam("format must be one of " + strings.Join(names, ", ")))
+		return
+	}
+
+	groupID, err := idParam(c, "group_id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	deck, err := h.exportService.Deck(groupID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, deck.FileName(format)))
+	c.Status(http.StatusOK)
+	if err := export.Write(c.Writer, format, deck); err != nil {
+		if c.Writer.Written() {
+			// The response is under way and can only be cut short
+			log.Printf("export: %s export of %q failed: %v", format, deck.Name(), err)
+			c.Abort()
+			return
+		}
+		// Nothing was sent yet, so the error goes out as JSON instead
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		c.Error(err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
 	"github.com/erans/lang-portal/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -12,68 +13,62 @@ import (
 // GroupHandler handles group-related HTTP requests
 type GroupHandler struct {
 	groupService *service.GroupService
+	limits       Limits
 }
 
 // NewGroupHandler creates a new GroupHandler
-func NewGroupHandler(groupService *service.GroupService) *GroupHandler {
-	return &GroupHandler{groupService: groupService}
+func NewGroupHandler(groupService *service.GroupService, limits Limits) *GroupHandler {
+	return &GroupHandler{groupService: groupService, limits: limits}
 }
 
-// RegisterRoutes registers the group routes
-func (h *GroupHandler) RegisterRoutes(router *gin.Engine) {
-	groups := router.Group("/api/groups")
+// RegisterRoutes registers the group routes. Only teachers and admins may
+// change groups or their words.
+func (h *GroupHandler) RegisterRoutes(router *gin.RouterGroup) {
+	groups := router.Group("/groups")
 	{
 		groups.GET("", h.ListGroups)
 		groups.GET("/:id", h.GetGroup)
-		groups.POST("", h.CreateGroup)
-		groups.PUT("/:id", h.UpdateGroup)
-		groups.DELETE("/:id", h.DeleteGroup)
+		groups.POST("", staffOnly, h.CreateGroup)
+		groups.PUT("/:id", staffOnly, h.UpdateGroup)
+		groups.DELETE("/:id", staffOnly, h.DeleteGroup)
 		groups.GET("/:id/words", h.GetGroupWords)
+		groups.POST("/:id/words", staffOnly, h.AddGroupWords)
+		groups.DELETE("/:id/words", staffOnly, h.RemoveGroupWords)
+		groups.POST("/:id/words/move", staffOnly, h.MoveGroupWords)
+		groups.POST("/:id/words/:word_id", staffOnly, h.AddGroupWord)
+		groups.DELETE("/:id/words/:word_id", staffOnly, h.RemoveGroupWord)
 		groups.GET("/:id/study-sessions", h.GetGroupStudySessions)
 	}
 }
 
 // ListGroups handles GET /api/groups
 func (h *GroupHandler) ListGroups(c *gin.Context) {
-	// Get pagination parameters with default 100 items per page as per spec
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit := 100 // Fixed as per spec
-	offset := (page - 1) * limit
-
-	// Get groups from service
-	result, err := h.groupService.ListGroups(offset, limit)
+	req, page, err := h.limits.listParams(c, repository.GroupListing)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	// Calculate pagination metadata
-	totalPages := int((result.TotalItems + int64(limit) - 1) / int64(limit))
-
-	response := models.PaginatedResponse{
-		Items: result.Items,
-		Pagination: models.Pagination{
-			CurrentPage:  page,
-			TotalPages:   totalPages,
-			TotalItems:   result.TotalItems,
-			ItemsPerPage: limit,
-		},
+	result, err := h.groupService.ListGroups(req)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, listResponse(req, page, result))
 }
 
 // GetGroup handles GET /api/groups/:id
 func (h *GroupHandler) GetGroup(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group ID"})
+		c.Error(invalidParam("invalid group ID"))
 		return
 	}
 
 	group, err := h.groupService.GetGroup(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -84,104 +79,58 @@ func (h *GroupHandler) GetGroup(c *gin.Context) {
 func (h *GroupHandler) GetGroupWords(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group ID"})
+		c.Error(invalidParam("invalid group ID"))
 		return
 	}
 
-	// Get pagination parameters with default 100 items per page as per spec
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit := 100 // Fixed as per spec
-	offset := (page - 1) * limit
-
-	words, err := h.groupService.GetGroupWords(id)
+	req, page, err := h.limits.listParams(c, repository.WordListing)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	// Calculate total pages based on total words
-	totalItems := int64(len(words))
-	totalPages := int((totalItems + int64(limit) - 1) / int64(limit))
-
-	// Paginate the results
-	start := offset
-	end := offset + limit
-	if start > len(words) {
-		start = len(words)
-	}
-	if end > len(words) {
-		end = len(words)
-	}
-
-	response := models.PaginatedResponse{
-		Items: words[start:end],
-		Pagination: models.Pagination{
-			CurrentPage:  page,
-			TotalPages:   totalPages,
-			TotalItems:   totalItems,
-			ItemsPerPage: limit,
-		},
+	result, err := h.groupService.GetGroupWords(id, req)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, listResponse(req, page, result))
 }
 
 // GetGroupStudySessions handles GET /api/groups/:id/study-sessions
 func (h *GroupHandler) GetGroupStudySessions(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group ID"})
+		c.Error(invalidParam("invalid group ID"))
 		return
 	}
 
-	// Get pagination parameters with default 100 items per page as per spec
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit := 100 // Fixed as per spec
-	offset := (page - 1) * limit
-
-	sessions, err := h.groupService.GetGroupStudySessions(id)
+	req, page, err := h.limits.listParams(c, repository.SessionListing)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	// Calculate total pages based on total sessions
-	totalItems := int64(len(sessions))
-	totalPages := int((totalItems + int64(limit) - 1) / int64(limit))
-
-	// Paginate the results
-	start := offset
-	end := offset + limit
-	if start > len(sessions) {
-		start = len(sessions)
-	}
-	if end > len(sessions) {
-		end = len(sessions)
-	}
-
-	response := models.PaginatedResponse{
-		Items: sessions[start:end],
-		Pagination: models.Pagination{
-			CurrentPage:  page,
-			TotalPages:   totalPages,
-			TotalItems:   totalItems,
-			ItemsPerPage: limit,
-		},
+	result, err := h.groupService.GetGroupStudySessions(currentUser(c).ID, id, req)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, listResponse(req, page, result))
 }
 
 // CreateGroup handles POST /api/groups
 func (h *GroupHandler) CreateGroup(c *gin.Context) {
 	var group models.Group
-	if err := c.ShouldBindJSON(&group); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &group); err != nil {
+		c.Error(err)
 		return
 	}
 
 	if err := h.groupService.CreateGroup(&group); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -192,19 +141,19 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group ID"})
+		c.Error(invalidParam("invalid group ID"))
 		return
 	}
 
 	var group models.Group
-	if err := c.ShouldBindJSON(&group); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &group); err != nil {
+		c.Error(err)
 		return
 	}
 
 	group.ID = id
 	if err := h.groupService.UpdateGroup(&group); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -215,14 +164,152 @@ func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 func (h *GroupHandler) DeleteGroup(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group ID"})
+		c.Error(invalidParam("invalid group ID"))
 		return
 	}
 
 	if err := h.groupService.DeleteGroup(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// wordIDsRequest is the payload for bulk membership changes
+type wordIDsRequest struct {
+	WordIDs []int64 `json:"word_ids" binding:"required,min=1"`
+}
+
+// AddGroupWords handles POST /api/groups/:id/words
+func (h *GroupHandler) AddGroupWords(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid group ID"))
+		return
+	}
+
+	var request wordIDsRequest
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	membership, err := h.groupService.AddWordsToGroup(id, uniqueIDs(request.WordIDs))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, membership)
+}
+
+// AddGroupWord handles POST /api/groups/:id/words/:word_id
+func (h *GroupHandler) AddGroupWord(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid group ID"))
+		return
+	}
+
+	wordID, err := strconv.ParseInt(c.Param("word_id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid word ID"))
+		return
+	}
+
+	membership, err := h.groupService.AddWordsToGroup(id, []int64{wordID})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, membership)
+}
+
+// RemoveGroupWords handles DELETE /api/groups/:id/words
+func (h *GroupHandler) RemoveGroupWords(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid group ID"))
+		return
+	}
+
+	var request wordIDsRequest
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	membership, err := h.groupService.RemoveWordsFromGroup(id, uniqueIDs(request.WordIDs))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, membership)
+}
+
+// RemoveGroupWord handles DELETE /api/groups/:id/words/:word_id
+func (h *GroupHandler) RemoveGroupWord(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid group ID"))
+		return
+	}
+
+	wordID, err := strconv.ParseInt(c.Param("word_id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid word ID"))
+		return
+	}
+
+	if _, err := h.groupService.RemoveWordsFromGroup(id, []int64{wordID}); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// MoveGroupWords handles POST /api/groups/:id/words/move
+func (h *GroupHandler) MoveGroupWords(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid group ID"))
+		return
+	}
+
+	var request struct {
+		WordIDs       []int64 `json:"word_ids" binding:"required,min=1"`
+		TargetGroupID int64   `json:"target_group_id" binding:"required"`
+	}
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	source, target, err := h.groupService.MoveWords(id, request.TargetGroupID, uniqueIDs(request.WordIDs))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"source": source,
+		"target": target,
+	})
+}
+
+// uniqueIDs removes duplicate IDs while preserving order
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
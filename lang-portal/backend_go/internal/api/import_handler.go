@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/importer"
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize bounds the body of an import request
+const maxImportSize = 64 << 20
+
+// ImportHandler handles vocabulary imports
+type ImportHandler struct {
+	importService *service.ImportService
+}
+
+// NewImportHandler creates a new ImportHandler
+func NewImportHandler(importService *service.ImportService) *ImportHandler {
+	return &ImportHandler{importService: importService}
+}
+
+// RegisterRoutes registers the import route. Only teachers and admins may
+// import.
+func (h *ImportHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/import", staffOnly, h.Import)
+}
+
+// Import handles POST /api/import. The request body is the file; the query
+// names its format and the target group, and may map columns with
+// columns[<field>]=<column>, read CSV or TSV without a header with
+// header=false, and ask for a dry run with dry_run=true.
+func (h *ImportHandler) Import(c *gin.Context) {
+	format, err := importer.ParseFormat(c.Query("format"))
+	if err != nil {
+		names := make([]string, len(importer.Formats))
+		for i, f := range importer.Formats {
+			names[i] = string(f)
+		}
+		c.Error(invalidParam("format must be one of " + strings.Join(names, ", ")))
+		return
+	}
+
+	opts := service.ImportOptions{
+		Options:   importer.Options{Columns: c.QueryMap("columns")},
+		GroupName: strings.TrimSpace(c.Query("group")),
+	}
+	if opts.GroupID, err = idParam(c, "group_id"); err != nil {
+		c.Error(err)
+		return
+	}
+	header, err := boolParam(c, "header", true)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	opts.NoHeader = !header
+	if opts.DryRun, err = boolParam(c, "dry_run", false); err != nil {
+		c.Error(err)
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	report, err := h.importService.Import(body, format, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// boolParam reads a true or false query parameter, or def when it is
+// missing
+func boolParam(c *gin.Context, name string, def bool) (bool, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return def, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, invalidParam(name + " must be true or false")
+	}
+	return value, nil
+}
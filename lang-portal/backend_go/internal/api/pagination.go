@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// Limits bounds the page sizes of list endpoints
+type Limits struct {
+	// PageSize is used when a request does not set per_page
+	PageSize int
+	// MaxPageSize is the largest per_page a request may ask for
+	MaxPageSize int
+}
+
+// DefaultLimits returns the page sizes of the API spec: 100 items per page
+func DefaultLimits() Limits {
+	return Limits{PageSize: 100, MaxPageSize: 100}
+}
+
+// pageParams reads the page and per_page query parameters and returns the
+// page, its size and the offset of its first item. Pages whose offset
+// would overflow are rejected.
+func (l Limits) pageParams(c *gin.Context) (page, limit, offset int, err error) {
+	page, err = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, 0, invalidParam("page must be a positive integer")
+	}
+
+	limit, err = l.perPage(c)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if page-1 > math.MaxInt/limit {
+		return 0, 0, 0, invalidParam("page is out of range")
+	}
+	return page, limit, (page - 1) * limit, nil
+}
+
+// perPage reads the per_page query parameter
+func (l Limits) perPage(c *gin.Context) (int, error) {
+	raw := c.Query("per_page")
+	if raw == "" {
+		return l.PageSize, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 || limit > l.MaxPageSize {
+		return 0, invalidParam("per_page must be between 1 and " + strconv.Itoa(l.MaxPageSize))
+	}
+	return limit, nil
+}
+
+// listParams reads the query parameters of a listing that supports the
+// sorts of spec: per_page, sort, and either page or the cursor of a
+// previous page. A cursor continues the order it was made in, so it cannot
+// be combined with page or another sort. The page number returned is 0
+// for cursor requests.
+func (l Limits) listParams(c *gin.Context, spec pagination.Spec) (pagination.Request, int, error) {
+	limit, err := l.perPage(c)
+	if err != nil {
+		return pagination.Request{}, 0, err
+	}
+	sort, err := spec.ParseSort(c.Query("sort"))
+	if errors.Is(err, pagination.ErrInvalidSort) {
+		return pagination.Request{}, 0, invalidParam(err.Error())
+	}
+	req := pagination.Request{Sort: sort, Limit: limit}
+
+	token := c.Query("cursor")
+	if token == "" {
+		page, _, offset, err := l.pageParams(c)
+		req.Offset = offset
+		return req, page, err
+	}
+	if c.Query("page") != "" {
+		return pagination.Request{}, 0, invalidParam("page and cursor cannot be combined")
+	}
+	req.After, err = spec.ParseCursor(token)
+	if err != nil {
+		return pagination.Request{}, 0, invalidParam("invalid cursor")
+	}
+	if c.Query("sort") != "" && req.After.Sort != sort {
+		return pagination.Request{}, 0, invalidParam("cursor was made for sort " + req.After.Sort.String())
+	}
+	req.Sort = req.After.Sort
+	return req, 0, nil
+}
+
+// listResponse renders a page of a listing with its pagination metadata
+func listResponse[T any](req pagination.Request, page int, result *pagination.Page[T]) models.PaginatedResponse {
+	response := models.PaginatedResponse{
+		Items: result.Items,
+		Pagination: models.Pagination{
+			CurrentPage:  page,
+			TotalPages:   int((result.Total + int64(req.Limit) - 1) / int64(req.Limit)),
+			TotalItems:   result.Total,
+			ItemsPerPage: req.Limit,
+		},
+	}
+	if result.Next != nil {
+		response.Pagination.NextCursor = result.Next.Encode()
+	}
+	return response
+}
+
+// idParam reads an optional ID query parameter
+func idParam(c *gin.Context, name string) (*int64, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, invalidParam(name + " must be an integer")
+	}
+	return &id, nil
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Bounds for the number of due words returned in one batch
+const (
+	defaultDueLimit = 20
+	maxDueLimit     = 100
+)
+
+// ReviewHandler handles spaced-repetition review requests
+type ReviewHandler struct {
+	reviewService *service.ReviewService
+}
+
+// NewReviewHandler creates a new ReviewHandler
+func NewReviewHandler(reviewService *service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService}
+}
+
+// RegisterRoutes registers the review routes
+func (h *ReviewHandler) RegisterRoutes(router *gin.RouterGroup) {
+	review := router.Group("/review")
+	{
+		review.GET("/due", h.GetDueWords)
+	}
+}
+
+// GetDueWords handles GET /api/review/due
+func (h *ReviewHandler) GetDueWords(c *gin.Context) {
+	groupID, err := idParam(c, "group_id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultDueLimit)))
+	if err != nil || limit < 1 || limit > maxDueLimit {
+		c.Error(invalidParam("limit must be between 1 and " + strconv.Itoa(maxDueLimit)))
+		return
+	}
+
+	words, err := h.reviewService.GetDueWords(currentUser(c).ID, groupID, limit, time.Now())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": words})
+}
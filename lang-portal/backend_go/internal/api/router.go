@@ -0,0 +1,103 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/erans/lang-portal/internal/repository/sqlstore"
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/erans/lang-portal/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// Options tunes the services and handlers to the server configuration
+type Options struct {
+	Limits Limits
+	// BackupDir confines backups to a directory; empty allows any path
+	BackupDir string
+	// AllowAnonymous lets requests without a token act for the default
+	// user with learner rights. It is off unless configured, so every
+	// request needs a token.
+	AllowAnonymous bool
+}
+
+// DefaultOptions returns the options used when nothing is configured
+func DefaultOptions() Options {
+	return Options{Limits: DefaultLimits()}
+}
+
+// Services holds the services the API handlers depend on
+type Services struct {
+	Users      *service.UserService
+	Auth       *service.AuthService
+	Words      *service.WordService
+	Groups     *service.GroupService
+	Dashboard  *service.DashboardService
+	Sessions   *service.StudySessionService
+	Activities *service.StudyActivityService
+	Apps       *service.ActivityAppService
+	Export     *service.ExportService
+	Import     *service.ImportService
+	System     *service.SystemService
+	Reviews    *service.ReviewService
+	Validator  *validation.Validator
+	Limits     Limits
+	// AllowAnonymous is passed on to Authenticate
+	AllowAnonymous bool
+}
+
+// NewServices creates every service on a sqlstore over db. The validator
+// also checks references against the store.
+func NewServices(db *sql.DB, opts Options) *Services {
+	store := sqlstore.New(db)
+	validator := validation.New(store)
+	return &Services{
+		Users:      service.NewUserService(store),
+		Auth:       service.NewAuthService(store),
+		Words:      service.NewWordService(store),
+		Groups:     service.NewGroupService(store),
+		Dashboard:  service.NewDashboardService(store),
+		Sessions:   service.NewStudySessionService(store),
+		Activities: service.NewStudyActivityService(store),
+		Apps:       service.NewActivityAppService(store),
+		Export:     service.NewExportService(store),
+		Import:     service.NewImportService(store, validator),
+		System:     service.NewSystemService(store, opts.BackupDir),
+		Reviews:    service.NewReviewService(store),
+		Validator:  validator,
+		Limits:     opts.Limits,
+
+		AllowAnonymous: opts.AllowAnonymous,
+	}
+}
+
+// RegisterRoutes installs the error handler and validator, registers the
+// health check, then creates every handler and registers its routes under
+// /api, behind authentication. The health check and unknown routes answer
+// without a token.
+func RegisterRoutes(r *gin.Engine, s *Services) {
+	r.Use(ErrorHandler(), withValidator(s.Validator))
+	r.NoRoute(routeNotFound)
+	r.GET("/health", healthCheck)
+
+	api := r.Group("/api", Authenticate(s.Auth, s.AllowAnonymous))
+	NewUserHandler(s.Users, s.Auth, s.Limits).RegisterRoutes(api)
+	NewWordHandler(s.Words, s.Limits).RegisterRoutes(api)
+	NewGroupHandler(s.Groups, s.Limits).RegisterRoutes(api)
+	NewDashboardHandler(s.Dashboard).RegisterRoutes(api)
+	NewStudySessionHandler(s.Sessions, s.Limits).RegisterRoutes(api)
+	NewStudyActivityHandler(s.Activities, s.Limits).RegisterRoutes(api)
+	NewActivityAppHandler(s.Apps, s.Limits).RegisterRoutes(api)
+	NewExportHandler(s.Export).RegisterRoutes(api)
+	NewImportHandler(s.Import).RegisterRoutes(api)
+	NewSystemHandler(s.System).RegisterRoutes(api)
+	NewReviewHandler(s.Reviews).RegisterRoutes(api)
+}
+
+// healthCheck handles GET /health, the liveness probe
+func healthCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "healthy",
+		"version": "1.0.0",
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
 	"github.com/erans/lang-portal/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -12,51 +13,62 @@ import (
 // StudyActivityHandler handles study activity-related HTTP requests
 type StudyActivityHandler struct {
 	activityService *service.StudyActivityService
+	limits          Limits
 }
 
 // NewStudyActivityHandler creates a new StudyActivityHandler
-func NewStudyActivityHandler(activityService *service.StudyActivityService) *StudyActivityHandler {
-	return &StudyActivityHandler{activityService: activityService}
+func NewStudyActivityHandler(activityService *service.StudyActivityService, limits Limits) *StudyActivityHandler {
+	return &StudyActivityHandler{activityService: activityService, limits: limits}
 }
 
-// RegisterRoutes registers the study activity routes
-func (h *StudyActivityHandler) RegisterRoutes(router *gin.Engine) {
-	activities := router.Group("/api/activities")
+// RegisterRoutes registers the study activity routes. Only teachers and
+// admins may change activities.
+func (h *StudyActivityHandler) RegisterRoutes(router *gin.RouterGroup) {
+	activities := router.Group("/activities")
 	{
 		activities.GET("", h.ListActivities)
 		activities.GET("/:id", h.GetActivity)
-		activities.POST("", h.CreateActivity)
-		activities.PUT("/:id", h.UpdateActivity)
-		activities.DELETE("/:id", h.DeleteActivity)
+		activities.POST("", staffOnly, h.CreateActivity)
+		activities.PUT("/:id", staffOnly, h.UpdateActivity)
+		activities.DELETE("/:id", staffOnly, h.DeleteActivity)
 		activities.GET("/:id/sessions", h.GetActivitySessions)
 	}
 }
 
-// ListActivities handles GET /api/activities
+// ListActivities handles GET /api/activities?group_id=&type=
 func (h *StudyActivityHandler) ListActivities(c *gin.Context) {
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	req, page, err := h.limits.listParams(c, repository.ActivityListing)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filter := repository.ActivityFilter{ActivityType: c.Query("type")}
+	if filter.GroupID, err = idParam(c, "group_id"); err != nil {
+		c.Error(err)
+		return
+	}
 
-	activities, err := h.activityService.ListActivities(offset, limit)
+	result, err := h.activityService.ListActivities(filter, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, activities)
+	c.JSON(http.StatusOK, listResponse(req, page, result))
 }
 
 // GetActivity handles GET /api/activities/:id
 func (h *StudyActivityHandler) GetActivity(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid activity ID"})
+		c.Error(invalidParam("invalid activity ID"))
 		return
 	}
 
 	activity, err := h.activityService.GetActivity(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -66,13 +78,13 @@ func (h *StudyActivityHandler) GetActivity(c *gin.Context) {
 // CreateActivity handles POST /api/activities
 func (h *StudyActivityHandler) CreateActivity(c *gin.Context) {
 	var activity models.StudyActivity
-	if err := c.ShouldBindJSON(&activity); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &activity); err != nil {
+		c.Error(err)
 		return
 	}
 
 	if err := h.activityService.CreateActivity(&activity); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -83,19 +95,19 @@ func (h *StudyActivityHandler) CreateActivity(c *gin.Context) {
 func (h *StudyActivityHandler) UpdateActivity(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid activity ID"})
+		c.Error(invalidParam("invalid activity ID"))
 		return
 	}
 
 	var activity models.StudyActivity
-	if err := c.ShouldBindJSON(&activity); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &activity); err != nil {
+		c.Error(err)
 		return
 	}
 
 	activity.ID = id
 	if err := h.activityService.UpdateActivity(&activity); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -106,12 +118,12 @@ func (h *StudyActivityHandler) UpdateActivity(c *gin.Context) {
 func (h *StudyActivityHandler) DeleteActivity(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid activity ID"})
+		c.Error(invalidParam("invalid activity ID"))
 		return
 	}
 
 	if err := h.activityService.DeleteActivity(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -122,15 +134,21 @@ func (h *StudyActivityHandler) DeleteActivity(c *gin.Context) {
 func (h *StudyActivityHandler) GetActivitySessions(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid activity ID"})
+		c.Error(invalidParam("invalid activity ID"))
+		return
+	}
+
+	req, page, err := h.limits.listParams(c, repository.SessionListing)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	sessions, err := h.activityService.GetActivitySessions(id)
+	result, err := h.activityService.GetActivitySessions(currentUser(c).ID, id, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, sessions)
+	c.JSON(http.StatusOK, listResponse(req, page, result))
 }
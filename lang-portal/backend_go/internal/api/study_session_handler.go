@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
 	"github.com/erans/lang-portal/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -12,16 +13,17 @@ import (
 // StudySessionHandler handles study session-related HTTP requests
 type StudySessionHandler struct {
 	sessionService *service.StudySessionService
+	limits         Limits
 }
 
 // NewStudySessionHandler creates a new StudySessionHandler
-func NewStudySessionHandler(sessionService *service.StudySessionService) *StudySessionHandler {
-	return &StudySessionHandler{sessionService: sessionService}
+func NewStudySessionHandler(sessionService *service.StudySessionService, limits Limits) *StudySessionHandler {
+	return &StudySessionHandler{sessionService: sessionService, limits: limits}
 }
 
 // RegisterRoutes registers the study session routes
-func (h *StudySessionHandler) RegisterRoutes(router *gin.Engine) {
-	sessions := router.Group("/api/study-sessions")
+func (h *StudySessionHandler) RegisterRoutes(router *gin.RouterGroup) {
+	sessions := router.Group("/study-sessions")
 	{
 		sessions.GET("", h.ListSessions)
 		sessions.GET("/:id", h.GetSession)
@@ -29,179 +31,184 @@ func (h *StudySessionHandler) RegisterRoutes(router *gin.Engine) {
 		sessions.PUT("/:id", h.UpdateSession)
 		sessions.PUT("/:id/end", h.EndSession)
 		sessions.GET("/:id/words", h.GetSessionWords)
-		sessions.GET("/:id/review-items", h.GetSessionReviewItems)
+		sessions.GET("/:id/review-items", h.GetSessionWords)
+		sessions.POST("/:id/words/:word_id/review", h.RecordReview)
 	}
 }
 
-// ListSessions handles GET /api/study-sessions
+// ListSessions handles GET /api/study-sessions?status=&activity_id=&group_id=
 func (h *StudySessionHandler) ListSessions(c *gin.Context) {
-	// Get pagination parameters with default 100 items per page as per spec
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit := 100 // Fixed as per spec
-	offset := (page - 1) * limit
-
-	// Get sessions from service
-	result, err := h.sessionService.ListSessions(offset, limit)
+	req, page, err := h.limits.listParams(c, repository.SessionListing)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	// Calculate pagination metadata
-	totalPages := int((result.TotalItems + int64(limit) - 1) / int64(limit))
+	filter := repository.SessionFilter{Status: c.Query("status")}
+	switch filter.Status {
+	case "", models.SessionActive, models.SessionCompleted, models.SessionAbandoned:
+	default:
+		c.Error(invalidParam("status must be active, completed or abandoned"))
+		return
+	}
+	if filter.ActivityID, err = idParam(c, "activity_id"); err != nil {
+		c.Error(err)
+		return
+	}
+	if filter.GroupID, err = idParam(c, "group_id"); err != nil {
+		c.Error(err)
+		return
+	}
 
-	response := models.PaginatedResponse{
-		Items: result.Items,
-		Pagination: models.Pagination{
-			CurrentPage:  page,
-			TotalPages:   totalPages,
-			TotalItems:   result.TotalItems,
-			ItemsPerPage: limit,
-		},
+	result, err := h.sessionService.ListSessions(currentUser(c).ID, filter, req)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, listResponse(req, page, result))
 }
 
 // GetSession handles GET /api/study-sessions/:id
 func (h *StudySessionHandler) GetSession(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		c.Error(invalidParam("invalid session ID"))
 		return
 	}
 
-	session, err := h.sessionService.GetSession(id)
+	session, err := h.sessionService.GetSession(currentUser(c).ID, id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, session)
 }
 
-// GetSessionWords handles GET /api/study-sessions/:id/words
+// GetSessionWords handles GET /api/study-sessions/:id/words?correct=, a
+// paginated listing of the session's review items. It also serves
+// /api/study-sessions/:id/review-items.
 func (h *StudySessionHandler) GetSessionWords(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		c.Error(invalidParam("invalid session ID"))
 		return
 	}
 
-	// Get pagination parameters with default 100 items per page as per spec
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit := 100 // Fixed as per spec
-	offset := (page - 1) * limit
-
-	items, err := h.sessionService.GetSessionReviewItems(id)
+	req, page, err := h.limits.listParams(c, repository.ReviewListing)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	// Calculate total pages based on total items
-	totalItems := int64(len(items))
-	totalPages := int((totalItems + int64(limit) - 1) / int64(limit))
-
-	// Paginate the results
-	start := offset
-	end := offset + limit
-	if start > len(items) {
-		start = len(items)
-	}
-	if end > len(items) {
-		end = len(items)
-	}
-
-	response := models.PaginatedResponse{
-		Items: items[start:end],
-		Pagination: models.Pagination{
-			CurrentPage:  page,
-			TotalPages:   totalPages,
-			TotalItems:   totalItems,
-			ItemsPerPage: limit,
-		},
-	}
-
-	c.JSON(http.StatusOK, response)
-}
-
-// GetSessionReviewItems handles GET /api/study-sessions/:id/review-items
-func (h *StudySessionHandler) GetSessionReviewItems(c *gin.Context) {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
-		return
+	var filter repository.ReviewFilter
+	if c.Query("correct") != "" {
+		correct, err := boolParam(c, "correct", false)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		filter.Correct = &correct
 	}
 
-	items, err := h.sessionService.GetSessionReviewItems(id)
+	result, err := h.sessionService.ListSessionReviewItems(currentUser(c).ID, id, filter, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, items)
+	c.JSON(http.StatusOK, listResponse(req, page, result))
 }
 
 // CreateSession handles POST /api/study-sessions
 func (h *StudySessionHandler) CreateSession(c *gin.Context) {
 	var session models.StudySession
-	if err := c.ShouldBindJSON(&session); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &session); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if err := h.sessionService.CreateSession(&session); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.sessionService.CreateSession(currentUser(c).ID, &session); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, session)
 }
 
-// UpdateSession handles PUT /api/study-sessions/:id
+// UpdateSession handles PUT /api/study-sessions/:id. Only the status can
+// change, from active to completed or abandoned.
 func (h *StudySessionHandler) UpdateSession(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		c.Error(invalidParam("invalid session ID"))
 		return
 	}
 
-	var session models.StudySession
-	if err := c.ShouldBindJSON(&session); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var payload struct {
+		Status string `json:"status" binding:"required,oneof=active completed abandoned"`
+	}
+	if err := bindJSON(c, &payload); err != nil {
+		c.Error(err)
 		return
 	}
 
-	session.ID = id
-	if err := h.sessionService.UpdateSession(&session); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	session, err := h.sessionService.UpdateSession(currentUser(c).ID, id, payload.Status)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, session)
 }
 
-// EndSession handles PUT /api/study-sessions/:id/end
+// EndSession handles PUT /api/study-sessions/:id/end. The score is
+// computed from the session's answers; any body is ignored.
 func (h *StudySessionHandler) EndSession(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		c.Error(invalidParam("invalid session ID"))
+		return
+	}
+
+	session, err := h.sessionService.EndSession(currentUser(c).ID, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// RecordReview handles POST /api/study-sessions/:id/words/:word_id/review
+func (h *StudySessionHandler) RecordReview(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid session ID"))
+		return
+	}
+
+	wordID, err := strconv.ParseInt(c.Param("word_id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid word ID"))
 		return
 	}
 
 	var payload struct {
-		Score float64 `json:"score" binding:"required"`
+		Correct  *bool  `json:"correct" binding:"required"`
+		Response string `json:"response"`
 	}
-	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &payload); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if err := h.sessionService.EndSession(id, payload.Score); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	item, err := h.sessionService.RecordReview(currentUser(c).ID, sessionID, wordID, *payload.Correct, payload.Response)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusCreated, item)
 }
@@ -17,9 +17,10 @@ func NewSystemHandler(systemService *service.SystemService) *SystemHandler {
 	return &SystemHandler{systemService: systemService}
 }
 
-// RegisterRoutes registers the system routes
-func (h *SystemHandler) RegisterRoutes(router *gin.Engine) {
-	system := router.Group("/api/system")
+// RegisterRoutes registers the system routes, which only teachers and
+// admins may call
+func (h *SystemHandler) RegisterRoutes(router *gin.RouterGroup) {
+	system := router.Group("/system", staffOnly)
 	{
 		system.GET("/stats", h.GetSystemStats)
 		system.GET("/health", h.GetSystemHealth)
@@ -34,7 +35,7 @@ func (h *SystemHandler) RegisterRoutes(router *gin.Engine) {
 func (h *SystemHandler) GetSystemStats(c *gin.Context) {
 	stats, err := h.systemService.GetSystemStats()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -45,7 +46,7 @@ func (h *SystemHandler) GetSystemStats(c *gin.Context) {
 func (h *SystemHandler) GetSystemHealth(c *gin.Context) {
 	health, err := h.systemService.GetSystemHealth()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -62,13 +63,13 @@ func (h *SystemHandler) BackupDatabase(c *gin.Context) {
 	var request struct {
 		BackupPath string `json:"backup_path" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
 		return
 	}
 
 	if err := h.systemService.BackupDatabase(request.BackupPath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -79,7 +80,7 @@ func (h *SystemHandler) BackupDatabase(c *gin.Context) {
 func (h *SystemHandler) GetDatabaseSize(c *gin.Context) {
 	size, err := h.systemService.GetDatabaseSize()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -90,7 +91,7 @@ func (h *SystemHandler) GetDatabaseSize(c *gin.Context) {
 func (h *SystemHandler) GetLastBackupInfo(c *gin.Context) {
 	info, err := h.systemService.GetLastBackupInfo()
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -102,13 +103,13 @@ func (h *SystemHandler) PruneOldData(c *gin.Context) {
 	var request struct {
 		RetentionDays int `json:"retention_days" binding:"required,min=1"`
 	}
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
 		return
 	}
 
 	if err := h.systemService.PruneOldData(request.RetentionDays); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
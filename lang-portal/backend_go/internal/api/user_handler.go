@@ -0,0 +1,192 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// UserHandler handles user account, role and API token requests
+type UserHandler struct {
+	userService *service.UserService
+	authService *service.AuthService
+	limits      Limits
+}
+
+// NewUserHandler creates a new UserHandler
+func NewUserHandler(userService *service.UserService, authService *service.AuthService, limits Limits) *UserHandler {
+	return &UserHandler{userService: userService, authService: authService, limits: limits}
+}
+
+// RegisterRoutes registers the user routes. Staff may look users up, only
+// admins create them or change roles, and users manage their own tokens.
+func (h *UserHandler) RegisterRoutes(router *gin.RouterGroup) {
+	users := router.Group("/users")
+	{
+		users.GET("", staffOnly, h.ListUsers)
+		users.GET("/me", h.GetCurrentUser)
+		users.GET("/:id", staffOnly, h.GetUser)
+		users.POST("", adminOnly, h.CreateUser)
+		users.PUT("/:id/role", adminOnly, h.SetRole)
+		users.GET("/:id/tokens", selfOrAdmin, h.ListTokens)
+		users.POST("/:id/tokens", selfOrAdmin, h.IssueToken)
+		users.DELETE("/:id/tokens/:token_id", selfOrAdmin, h.RevokeToken)
+	}
+}
+
+// ListUsers handles GET /api/users
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	req, page, err := h.limits.listParams(c, repository.UserListing)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.userService.ListUsers(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, listResponse(req, page, result))
+}
+
+// GetCurrentUser handles GET /api/users/me. The role is the one the
+// request acts with, so anonymous requests report learner.
+func (h *UserHandler) GetCurrentUser(c *gin.Context) {
+	c.JSON(http.StatusOK, currentUser(c))
+}
+
+// GetUser handles GET /api/users/:id
+func (h *UserHandler) GetUser(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid user ID"))
+		return
+	}
+
+	user, err := h.userService.GetUser(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// CreateUser handles POST /api/users
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var user models.User
+	if err := bindJSON(c, &user); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.userService.CreateUser(&user); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// SetRole handles PUT /api/users/:id/role
+func (h *UserHandler) SetRole(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid user ID"))
+		return
+	}
+
+	var request struct {
+		Role string `json:"role" binding:"required,oneof=learner teacher admin"`
+	}
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := h.userService.SetRole(id, request.Role)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ListTokens handles GET /api/users/:id/tokens
+func (h *UserHandler) ListTokens(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid user ID"))
+		return
+	}
+
+	tokens, err := h.authService.ListTokens(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": tokens})
+}
+
+// IssueToken handles POST /api/users/:id/tokens. The response is the only
+// time the secret is shown.
+func (h *UserHandler) IssueToken(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid user ID"))
+		return
+	}
+
+	var request struct {
+		Name          string `json:"name" binding:"notblank,max=100"`
+		ExpiresInDays int    `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+	}
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var expiresAt *time.Time
+	if request.ExpiresInDays > 0 {
+		at := time.Now().AddDate(0, 0, request.ExpiresInDays)
+		expiresAt = &at
+	}
+
+	token, err := h.authService.IssueToken(id, request.Name, expiresAt)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// RevokeToken handles DELETE /api/users/:id/tokens/:token_id
+func (h *UserHandler) RevokeToken(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid user ID"))
+		return
+	}
+	tokenID, err := strconv.ParseInt(c.Param("token_id"), 10, 64)
+	if err != nil {
+		c.Error(invalidParam("invalid token ID"))
+		return
+	}
+
+	if err := h.authService.RevokeToken(id, tokenID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
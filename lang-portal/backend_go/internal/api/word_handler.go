@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
 	"github.com/erans/lang-portal/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -12,40 +13,83 @@ import (
 // WordHandler handles HTTP requests for words
 type WordHandler struct {
 	wordService *service.WordService
+	limits      Limits
 }
 
 // NewWordHandler creates a new WordHandler
-func NewWordHandler(wordService *service.WordService) *WordHandler {
-	return &WordHandler{wordService: wordService}
+func NewWordHandler(wordService *service.WordService, limits Limits) *WordHandler {
+	return &WordHandler{wordService: wordService, limits: limits}
 }
 
-// RegisterRoutes registers the word routes
-func (h *WordHandler) RegisterRoutes(r *gin.Engine) {
-	words := r.Group("/api/words")
+// RegisterRoutes registers the word routes. Only teachers and admins may
+// change the vocabulary.
+func (h *WordHandler) RegisterRoutes(r *gin.RouterGroup) {
+	words := r.Group("/words")
 	{
 		words.GET("", h.ListWords)
+		words.GET("/search", h.SearchWords)
 		words.GET("/:id", h.GetWord)
-		words.POST("", h.CreateWord)
-		words.PUT("/:id", h.UpdateWord)
-		words.DELETE("/:id", h.DeleteWord)
+		words.POST("", staffOnly, h.CreateWord)
+		words.PUT("/:id", staffOnly, h.UpdateWord)
+		words.DELETE("/:id", staffOnly, h.DeleteWord)
 	}
 }
 
-// ListWords handles GET /api/words
+// ListWords handles GET /api/words?group_id=
 func (h *WordHandler) ListWords(c *gin.Context) {
-	// Get pagination parameters with default 100 items per page as per spec
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit := 100 // Fixed as per spec
-	offset := (page - 1) * limit
+	req, page, err := h.limits.listParams(c, repository.WordListing)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var filter repository.WordFilter
+	if filter.GroupID, err = idParam(c, "group_id"); err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.wordService.ListWords(currentUser(c).ID, filter, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, listResponse(req, page, result))
+}
+
+// searchParams are the query parameters of a search that are not parts
+// filters. Results are ranked by relevance and paged by number, so the
+// sort and cursor of listings are rejected rather than read as filters.
+var searchParams = map[string]bool{"q": true, "page": true, "per_page": true, "sort": false, "cursor": false}
+
+// SearchWords handles GET /api/words/search?q=...
+// Any other query parameter filters on a key of the word's parts, e.g. type=verb.
+func (h *WordHandler) SearchWords(c *gin.Context) {
+	page, limit, offset, err := h.limits.pageParams(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filters := make(map[string]string)
+	for key, values := range c.Request.URL.Query() {
+		supported, reserved := searchParams[key]
+		if reserved && !supported {
+			c.Error(invalidParam(key + " is not supported by search, whose results are ranked by relevance"))
+			return
+		}
+		if !reserved && len(values) > 0 {
+			filters[key] = values[0]
+		}
+	}
 
-	// Get words from service
-	result, err := h.wordService.ListWords(offset, limit)
+	result, err := h.wordService.SearchWords(c.Query("q"), filters, offset, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	// Calculate pagination metadata
 	totalPages := int((result.TotalItems + int64(limit) - 1) / int64(limit))
 
 	response := models.PaginatedResponse{
@@ -65,13 +109,13 @@ func (h *WordHandler) ListWords(c *gin.Context) {
 func (h *WordHandler) GetWord(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		c.Error(invalidParam("invalid id"))
 		return
 	}
 
-	word, err := h.wordService.GetWord(id)
+	word, err := h.wordService.GetWordWithStats(currentUser(c).ID, id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -81,13 +125,13 @@ func (h *WordHandler) GetWord(c *gin.Context) {
 // CreateWord handles POST /api/words
 func (h *WordHandler) CreateWord(c *gin.Context) {
 	var word models.Word
-	if err := c.ShouldBindJSON(&word); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &word); err != nil {
+		c.Error(err)
 		return
 	}
 
 	if err := h.wordService.CreateWord(&word); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -98,19 +142,19 @@ func (h *WordHandler) CreateWord(c *gin.Context) {
 func (h *WordHandler) UpdateWord(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		c.Error(invalidParam("invalid id"))
 		return
 	}
 
 	var word models.Word
-	if err := c.ShouldBindJSON(&word); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &word); err != nil {
+		c.Error(err)
 		return
 	}
 
 	word.ID = id
 	if err := h.wordService.UpdateWord(&word); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -121,12 +165,12 @@ func (h *WordHandler) UpdateWord(c *gin.Context) {
 func (h *WordHandler) DeleteWord(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		c.Error(invalidParam("invalid id"))
 		return
 	}
 
 	if err := h.wordService.DeleteWord(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
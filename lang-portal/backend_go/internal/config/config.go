@@ -0,0 +1,238 @@
+// Package config assembles the server configuration from defaults, an
+// optional YAML or TOML file, environment variables and command line
+// flags, each overriding the one before.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/erans/lang-portal/internal/database"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvConfigFile names the config file when --config is not given
+const EnvConfigFile = "LANG_PORTAL_CONFIG"
+
+// Log levels, from most to least verbose
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogWarn  = "warn"
+	LogError = "error"
+)
+
+// Config is the complete server configuration
+type Config struct {
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	Database   database.Config  `yaml:"database" toml:"database"`
+	CORS       CORSConfig       `yaml:"cors" toml:"cors"`
+	Log        LogConfig        `yaml:"log" toml:"log"`
+	Pagination PaginationConfig `yaml:"pagination" toml:"pagination"`
+	Backup     BackupConfig     `yaml:"backup" toml:"backup"`
+	Auth       AuthConfig       `yaml:"auth" toml:"auth"`
+	Sessions   SessionsConfig   `yaml:"sessions" toml:"sessions"`
+}
+
+// ServerConfig controls the HTTP listener
+type ServerConfig struct {
+	Addr string `yaml:"addr" toml:"addr"`
+	// ShutdownTimeout bounds how long in-flight requests may take to finish
+	// once the server is asked to stop
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+// Duration is a time.Duration written as a string such as "15s" in config
+// files
+type Duration time.Duration
+
+// UnmarshalText parses a duration such as "15s" or "1m30s"
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText formats the duration the way UnmarshalText reads it
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// CORSConfig lists the origins browsers may call the API from. "*" allows
+// any origin.
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"`
+}
+
+// LogConfig controls how much the server logs. Debug also puts Gin in
+// debug mode; warn and error drop the per-request access log.
+type LogConfig struct {
+	Level string `yaml:"level" toml:"level"`
+}
+
+// PaginationConfig bounds the page sizes of list endpoints
+type PaginationConfig struct {
+	PageSize    int `yaml:"page_size" toml:"page_size"`
+	MaxPageSize int `yaml:"max_page_size" toml:"max_page_size"`
+}
+
+// BackupConfig controls where database backups are written. An empty Dir
+// lets clients choose any path.
+type BackupConfig struct {
+	Dir string `yaml:"dir" toml:"dir"`
+}
+
+// AuthConfig controls authentication. Every request needs a token unless
+// Anonymous is set, which lets requests without one act for the default
+// user with learner rights; it is meant for local development.
+type AuthConfig struct {
+	Anonymous bool `yaml:"anonymous" toml:"anonymous"`
+}
+
+// SessionsConfig controls the background job that abandons study sessions
+// a study app left open. Sessions without an answer for IdleTimeout are
+// abandoned; zero turns the job off.
+type SessionsConfig struct {
+	IdleTimeout Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// ReapInterval is how often idle sessions are looked for
+	ReapInterval Duration `yaml:"reap_interval" toml:"reap_interval"`
+}
+
+// Default returns the configuration used when nothing is configured
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ShutdownTimeout: Duration(15 * time.Second),
+		},
+		Database: database.DefaultConfig(),
+		CORS:     CORSConfig{AllowedOrigins: []string{"*"}},
+		Log:      LogConfig{Level: LogInfo},
+		Pagination: PaginationConfig{
+			PageSize:    100,
+			MaxPageSize: 100,
+		},
+		Sessions: SessionsConfig{
+			IdleTimeout:  Duration(2 * time.Hour),
+			ReapInterval: Duration(5 * time.Minute),
+		},
+	}
+}
+
+// LoadFile merges a YAML (.yaml, .yml) or TOML (.toml) file into c. Keys
+// the file leaves out keep their current value; unknown keys are an error.
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			var strictErr *toml.StrictMissingError
+			if errors.As(err, &strictErr) {
+				return fmt.Errorf("%s: %s", path, strictErr.String())
+			}
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s must end in .yaml, .yml or .toml", path)
+	}
+
+	return nil
+}
+
+// Validate checks every setting and reports all problems at once
+func (c Config) Validate() error {
+	var errs []error
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("server.addr: %w", err))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout: must be positive"))
+	}
+
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("database: %w", err))
+	}
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		errs = append(errs, errors.New("cors.allowed_origins: at least one origin is required"))
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if err := validateOrigin(origin); err != nil {
+			errs = append(errs, fmt.Errorf("cors.allowed_origins: %w", err))
+		}
+	}
+
+	switch c.Log.Level {
+	case LogDebug, LogInfo, LogWarn, LogError:
+	default:
+		errs = append(errs, fmt.Errorf("log.level: unknown level %q (expected debug, info, warn or error)", c.Log.Level))
+	}
+
+	if c.Pagination.MaxPageSize < 1 {
+		errs = append(errs, errors.New("pagination.max_page_size: must be positive"))
+	}
+	if c.Pagination.PageSize < 1 || c.Pagination.PageSize > c.Pagination.MaxPageSize {
+		errs = append(errs, errors.New("pagination.page_size: must be between 1 and pagination.max_page_size"))
+	}
+
+	if c.Backup.Dir != "" {
+		if info, err := os.Stat(c.Backup.Dir); err == nil && !info.IsDir() {
+			errs = append(errs, fmt.Errorf("backup.dir: %s is not a directory", c.Backup.Dir))
+		}
+	}
+
+	if c.Sessions.IdleTimeout < 0 {
+		errs = append(errs, errors.New("sessions.idle_timeout: must not be negative"))
+	}
+	if c.Sessions.ReapInterval <= 0 {
+		errs = append(errs, errors.New("sessions.reap_interval: must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateOrigin accepts "*" or a scheme and host such as
+// http://localhost:5173
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		(u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		return fmt.Errorf("invalid origin %q (expected \"*\" or scheme://host[:port])", origin)
+	}
+	return nil
+}
+
+// YAML renders the configuration as YAML, e.g. for --print-config. The
+// database password is masked.
+func (c Config) YAML() ([]byte, error) {
+	c.Database = c.Database.Redacted()
+	return yaml.Marshal(c)
+}
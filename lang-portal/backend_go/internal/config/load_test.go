@@ -0,0 +1,275 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erans/lang-portal/internal/database"
+	"github.com/erans/lang-portal/internal/dialect"
+)
+
+// writeFile writes a config file into a temporary directory and returns
+// its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// env returns a getenv over vars
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestLoadLayers(t *testing.T) {
+	file := writeFile(t, "server.yaml", "server:\n  addr: \":9000\"\nlog:\n  level: warn\npagination:\n  page_size: 20\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		wantAddr string
+		wantLog  string
+		wantPage int
+	}{
+		{
+			name:     "defaults",
+			wantAddr: ":8080",
+			wantLog:  LogInfo,
+			wantPage: 100,
+		},
+		{
+			name:     "file over defaults",
+			args:     []string{"--config", file},
+			wantAddr: ":9000",
+			wantLog:  LogWarn,
+			wantPage: 20,
+		},
+		{
+			name:     "file named by the environment",
+			env:      map[string]string{EnvConfigFile: file},
+			wantAddr: ":9000",
+			wantLog:  LogWarn,
+			wantPage: 20,
+		},
+		{
+			name:     "environment over file",
+			args:     []string{"--config", file},
+			env:      map[string]string{"LANG_PORTAL_ADDR": ":9100", "LANG_PORTAL_LOG_LEVEL": "DEBUG"},
+			wantAddr: ":9100",
+			wantLog:  LogDebug,
+			wantPage: 20,
+		},
+		{
+			name:     "flags over environment",
+			args:     []string{"--config", file, "--addr", ":9200", "--page-size=30"},
+			env:      map[string]string{"LANG_PORTAL_ADDR": ":9100", "LANG_PORTAL_PAGE_SIZE": "10"},
+			wantAddr: ":9200",
+			wantLog:  LogWarn,
+			wantPage: 30,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _, err := load(tt.args, env(tt.env), io.Discard)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if cfg.Server.Addr != tt.wantAddr || cfg.Log.Level != tt.wantLog || cfg.Pagination.PageSize != tt.wantPage {
+				t.Errorf("addr, log level, page size = %q, %q, %d, want %q, %q, %d",
+					cfg.Server.Addr, cfg.Log.Level, cfg.Pagination.PageSize, tt.wantAddr, tt.wantLog, tt.wantPage)
+			}
+		})
+	}
+}
+
+// yamlConfig sets a value in every section TestLoadFileFormats checks
+const yamlConfig = `server:
+  addr: "127.0.0.1:9000"
+  shutdown_timeout: 30s
+database:
+  driver: postgres
+  url: postgres://lang:secret@db/portal
+cors:
+  allowed_origins: ["http://localhost:5173"]
+auth:
+  anonymous: true
+`
+
+func TestLoadFileFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "yaml", file: "server.yaml", content: yamlConfig},
+		{name: "yml", file: "server.yml", content: yamlConfig},
+		{
+			name: "toml",
+			file: "server.toml",
+			content: `[server]
+addr = "127.0.0.1:9000"
+shutdown_timeout = "30s"
+
+[database]
+driver = "postgres"
+url = "postgres://lang:secret@db/portal"
+
+[cors]
+allowed_origins = ["http://localhost:5173"]
+
+[auth]
+anonymous = true
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, flags, err := load([]string{"--config", writeFile(t, tt.file, tt.content)}, env(nil), io.Discard)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if flags.ConfigFile == "" {
+				t.Error("config file was not recorded")
+			}
+			if cfg.Server.Addr != "127.0.0.1:9000" || time.Duration(cfg.Server.ShutdownTimeout) != 30*time.Second {
+				t.Errorf("server = %+v, want 127.0.0.1:9000 and 30s", cfg.Server)
+			}
+			if cfg.Database.Dialect() != dialect.Postgres || cfg.Database.URL != "postgres://lang:secret@db/portal" {
+				t.Errorf("database = %+v, want the postgres URL", cfg.Database)
+			}
+			if len(cfg.CORS.AllowedOrigins) != 1 || cfg.CORS.AllowedOrigins[0] != "http://localhost:5173" {
+				t.Errorf("cors origins = %v, want http://localhost:5173", cfg.CORS.AllowedOrigins)
+			}
+			if !cfg.Auth.Anonymous {
+				t.Error("auth.anonymous was not read")
+			}
+			// Keys the file leaves out keep their defaults
+			if cfg.Log.Level != LogInfo || cfg.Pagination.MaxPageSize != 100 {
+				t.Errorf("log level, max page size = %q, %d, want the defaults", cfg.Log.Level, cfg.Pagination.MaxPageSize)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown flag", args: []string{"--port", "80"}, wantErr: "flag provided but not defined"},
+		{name: "extra argument", args: []string{"serve"}, wantErr: "unexpected arguments: serve"},
+		{name: "listen address", args: []string{"--addr", "8080"}, wantErr: "server.addr"},
+		{name: "duration flag", args: []string{"--shutdown-timeout", "soon"}, wantErr: "--shutdown-timeout"},
+		{name: "negative shutdown timeout", args: []string{"--shutdown-timeout", "-1s"}, wantErr: "server.shutdown_timeout: must be positive"},
+		{name: "integer environment", env: map[string]string{"LANG_PORTAL_PAGE_SIZE": "ten"}, wantErr: `LANG_PORTAL_PAGE_SIZE: invalid integer "ten"`},
+		{name: "boolean environment", env: map[string]string{"LANG_PORTAL_SEED": "maybe"}, wantErr: `LANG_PORTAL_SEED: invalid boolean "maybe"`},
+		{name: "page size above the maximum", args: []string{"--page-size", "200"}, wantErr: "pagination.page_size"},
+		{name: "log level", args: []string{"--log-level", "loud"}, wantErr: `unknown level "loud"`},
+		{name: "cors origin", args: []string{"--cors-origins", "localhost"}, wantErr: "cors.allowed_origins"},
+		{name: "database driver", args: []string{"--db-driver", "mysql"}, wantErr: "--db-driver"},
+		{name: "database mode", args: []string{"--db-mode", "cloud"}, wantErr: "--db-mode"},
+		{name: "postgres without a url", args: []string{"--db-driver", "postgres"}, wantErr: "database url is required"},
+		{name: "session reap interval", args: []string{"--session-reap-interval", "0s"}, wantErr: "sessions.reap_interval"},
+		{name: "unknown yaml key", file: "server.yaml", content: "server:\n  port: 80\n", wantErr: "field port not found"},
+		{name: "unknown toml key", file: "server.toml", content: "[server]\nport = 80\n", wantErr: "port"},
+		{name: "config file extension", file: "server.json", content: "{}", wantErr: "must end in .yaml, .yml or .toml"},
+		{name: "missing config file", args: []string{"--config", "does-not-exist.yaml"}, wantErr: "failed to read config file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"--config", writeFile(t, tt.file, tt.content)}, args...)
+			}
+			_, _, err := load(args, env(tt.env), io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("load error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		want     []string
+		wantNone string
+	}{
+		{
+			name: "sqlite",
+			want: []string{"addr: :8080", "path: " + database.DefaultPath, "mode: persistent", "level: info", "shutdown_timeout: 15s"},
+		},
+		{
+			name:     "postgres url",
+			url:      "postgres://lang:secret@db:5432/portal?sslmode=disable",
+			want:     []string{"url: postgres://lang:xxxxx@db:5432/portal?sslmode=disable"},
+			wantNone: "secret",
+		},
+		{
+			name:     "postgres url with a password parameter",
+			url:      "postgres://lang@db/portal?password=secret",
+			want:     []string{"url: postgres://lang@db/portal?password=xxxxx"},
+			wantNone: "secret",
+		},
+		{
+			name:     "postgres key=value",
+			url:      "host=db user=lang password='s3 cret' dbname=portal",
+			want:     []string{"host=db user=lang password=xxxxx dbname=portal"},
+			wantNone: "s3 cret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"--print-config"}
+			if tt.url != "" {
+				args = append(args, "--db-driver", "postgres", "--db-url", tt.url)
+			}
+			cfg, flags, err := load(args, env(nil), io.Discard)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if !flags.PrintConfig {
+				t.Fatal("--print-config was not recorded")
+			}
+
+			out, err := cfg.YAML()
+			if err != nil {
+				t.Fatalf("YAML: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("printed config lacks %q:\n%s", want, out)
+				}
+			}
+			if tt.wantNone != "" && strings.Contains(string(out), tt.wantNone) {
+				t.Errorf("printed config shows %q:\n%s", tt.wantNone, out)
+			}
+			if tt.url != "" && cfg.Database.URL != tt.url {
+				t.Errorf("printing changed the database url to %q", cfg.Database.URL)
+			}
+
+			// The printed configuration loads back as it was, but for the
+			// masked password
+			var printed Config
+			if err := printed.LoadFile(writeFile(t, "printed.yaml", string(out))); err != nil {
+				t.Fatalf("loading the printed config: %v", err)
+			}
+			if printed.Server != cfg.Server || printed.Log != cfg.Log || printed.Database.Mode != cfg.Database.Mode {
+				t.Errorf("printed config loads as %+v, want %+v", printed, cfg)
+			}
+		})
+	}
+}
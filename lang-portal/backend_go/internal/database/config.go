@@ -0,0 +1,153 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Mode controls how the database is stored and whether it survives restarts
+type Mode string
+
+const (
+	// ModePersistent keeps the database file between runs
+	ModePersistent Mode = "persistent"
+	// ModeEphemeral deletes the database file on startup, for development
+	ModeEphemeral Mode = "ephemeral"
+	// ModeMemory keeps the database in memory only, for tests
+	ModeMemory Mode = "memory"
+)
+
+// DefaultPath is the database file used when no path is configured
+const DefaultPath = "words.db"
+
+// Config describes where the database lives and how it is managed
+type Config struct {
+	// Driver selects SQLite or Postgres; empty means SQLite
+	Driver dialect.Dialect `yaml:"driver" toml:"driver"`
+	// Path is the SQLite database file
+	Path string `yaml:"path" toml:"path"`
+	// URL is the Postgres connection string, as a URL or key=value pairs
+	URL  string `yaml:"url" toml:"url"`
+	Mode Mode   `yaml:"mode" toml:"mode"`
+	// Seed applies the JSON seed manifest after migrations on startup
+	Seed bool `yaml:"seed" toml:"seed"`
+}
+
+// DefaultConfig returns a persistent SQLite database stored in words.db
+func DefaultConfig() Config {
+	return Config{
+		Driver: dialect.SQLite,
+		Path:   DefaultPath,
+		Mode:   ModePersistent,
+	}
+}
+
+// Dialect returns the configured driver, defaulting to SQLite
+func (c Config) Dialect() dialect.Dialect {
+	if c.Driver == "" {
+		return dialect.SQLite
+	}
+	return c.Driver
+}
+
+// ParseMode converts a string into a Mode
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case ModePersistent, ModeEphemeral, ModeMemory:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown database mode %q (expected persistent, ephemeral or memory)", s)
+	}
+}
+
+// Validate checks that the configuration is usable
+func (c Config) Validate() error {
+	if _, err := ParseMode(string(c.Mode)); err != nil {
+		return err
+	}
+	if _, err := dialect.Parse(string(c.Dialect())); err != nil {
+		return err
+	}
+	if c.Dialect() == dialect.Postgres {
+		// The server owns a Postgres database's lifecycle, not the portal
+		if c.Mode != ModePersistent {
+			return fmt.Errorf("database mode %s is only supported by sqlite", c.Mode)
+		}
+		if c.URL == "" {
+			return fmt.Errorf("database url is required for postgres")
+		}
+		if _, err := pgconn.ParseConfig(c.URL); err != nil {
+			return fmt.Errorf("invalid database url: %w", err)
+		}
+		return nil
+	}
+	if c.Mode != ModeMemory && c.Path == "" {
+		return fmt.Errorf("database path is required in %s mode", c.Mode)
+	}
+	return nil
+}
+
+// DSN returns the data source name for the configuration's driver
+func (c Config) DSN() string {
+	if c.Dialect() == dialect.Postgres {
+		return c.URL
+	}
+
+	// Connection parameters apply to every pooled connection, unlike a
+	// one-off PRAGMA statement
+	params := "_foreign_keys=on&_busy_timeout=5000"
+	if c.Mode == ModeMemory {
+		return "file::memory:?" + params
+	}
+	// Write-ahead logging lets readers proceed while a review is written
+	return "file:" + c.Path + "?" + params + "&_journal_mode=WAL"
+}
+
+// String describes the configuration for logging. Postgres credentials
+// are left out.
+func (c Config) String() string {
+	if c.Dialect() == dialect.Postgres {
+		pg, err := pgconn.ParseConfig(c.URL)
+		if err != nil {
+			return string(dialect.Postgres)
+		}
+		return fmt.Sprintf("%s, %s:%d/%s", dialect.Postgres, pg.Host, pg.Port, pg.Database)
+	}
+	if c.Mode == ModeMemory {
+		return string(c.Mode)
+	}
+	return fmt.Sprintf("%s, %s", c.Mode, c.Path)
+}
+
+// redacted replaces a secret in a connection string
+const redacted = "xxxxx"
+
+// dsnPassword matches the password of a key=value connection string
+var dsnPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// Redacted returns the configuration with the password of the Postgres
+// URL masked, for printing it where it may be seen
+func (c Config) Redacted() Config {
+	if c.URL == "" {
+		return c
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || u.Scheme == "" {
+		c.URL = dsnPassword.ReplaceAllString(c.URL, "${1}"+redacted)
+		return c
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	if query := u.Query(); query.Has("password") {
+		query.Set("password", redacted)
+		u.RawQuery = query.Encode()
+	}
+	c.URL = u.String()
+	return c
+}
@@ -2,90 +2,178 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 
+	"github.com/erans/lang-portal/internal/dialect"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-var db *sql.DB
+// Initialize opens the database described by cfg and logs where it is
+func Initialize(cfg Config) (*sql.DB, error) {
+	conn, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-// Initialize initializes the database connection
-func Initialize() error {
-	// Ensure the database directory exists
-	dbPath := filepath.Join(".", "words.db")
+	log.Printf("Database connection established (%s)", cfg)
+	return conn, nil
+}
 
-	// For development, remove the database if it exists
-	if err := os.Remove(dbPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove existing database: %w", err)
+// Open opens a database connection according to the configured driver
+// and mode
+func Open(cfg Config) (*sql.DB, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
-	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
-		return err
+	if cfg.Dialect() == dialect.Postgres {
+		return connect(dialect.Postgres, cfg.DSN())
 	}
 
-	// Open the database
+	if cfg.Mode == ModeEphemeral {
+		// Ephemeral databases start from scratch on every boot
+		if err := removeDatabaseFiles(cfg.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Mode != ModeMemory {
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	var conn *sql.DB
 	var err error
-	db, err = sql.Open("sqlite3", dbPath)
+	if cfg.Mode != ModeMemory {
+		conn, err = connect(dialect.SQLite, cfg.DSN())
+	} else {
+		conn, err = openMemory(cfg.DSN())
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		return err
+	if err := requireFTS5(conn); err != nil {
+		conn.Close()
+		return nil, err
 	}
+	return conn, nil
+}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return fmt.Errorf("failed to enable foreign keys: %w", err)
+// openMemory opens an in-memory SQLite database
+func openMemory(dsn string) (*sql.DB, error) {
+	conn, err := sql.Open(dialect.SQLite.DriverName(), dsn)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Println("Database connection established")
-	return nil
-}
+	// Every connection to :memory: gets its own database, so pin the pool
+	// to a single connection that is never recycled
+	conn.SetMaxOpenConns(1)
+	conn.SetConnMaxLifetime(0)
+	conn.SetConnMaxIdleTime(0)
 
-// GetDB returns the database instance
-func GetDB() *sql.DB {
-	return db
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
 }
 
-// Close closes the database connection
-func Close() error {
-	if db != nil {
-		return db.Close()
+// ErrNoFTS5 is returned when SQLite was compiled without the FTS5
+// extension, which word search and its migration depend on
+var ErrNoFTS5 = errors.New("SQLite was built without FTS5, which word search needs: build with -tags sqlite_fts5 (mage build and mage dev do)")
+
+// requireFTS5 checks that SQLite has FTS5 compiled in, so a binary built
+// without the sqlite_fts5 tag stops at startup rather than failing its
+// first search or migration with "no such module: fts5"
+func requireFTS5(conn *sql.DB) error {
+	var enabled bool
+	if err := conn.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled); err != nil {
+		return fmt.Errorf("failed to check for FTS5: %w", err)
+	}
+	if !enabled {
+		return ErrNoFTS5
 	}
 	return nil
 }
 
-// RunMigrations runs all migration files in order
-func RunMigrations() error {
-	files, err := filepath.Glob("db/migrations/*.sql")
+// connect opens a connection pool for dsn and tests it
+func connect(d dialect.Dialect, dsn string) (*sql.DB, error) {
+	conn, err := sql.Open(d.DriverName(), dsn)
 	if err != nil {
-		return fmt.Errorf("failed to list migration files: %w", err)
+		return nil, err
 	}
 
-	log.Println("Running migrations...")
-	for _, file := range files {
-		log.Printf("Executing migration: %s", filepath.Base(file))
-		content, err := os.ReadFile(file)
-		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", file, err)
-		}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
 
-		if _, err := db.Exec(string(content)); err != nil {
-			return fmt.Errorf("failed to execute migration %s: %w", file, err)
+// removeDatabaseFiles deletes a database file along with its WAL and
+// shared-memory companions
+func removeDatabaseFiles(path string) error {
+	for _, file := range []string{path, path + "-wal", path + "-shm"} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove existing database: %w", err)
 		}
 	}
+	return nil
+}
+
+// Checkpoint copies every committed transaction from the write-ahead log
+// into the database file and truncates the log. SQLite databases that are
+// not in WAL mode are left alone, and Postgres manages its own log.
+func Checkpoint(conn *sql.DB) error {
+	if dialect.Of(conn) != dialect.SQLite {
+		return nil
+	}
 
-	log.Println("Migrations completed successfully")
+	var busy, logFrames, checkpointed int
+	err := conn.QueryRow("PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logFrames, &checkpointed)
+	if err != nil {
+		return fmt.Errorf("failed to checkpoint database: %w", err)
+	}
+	if busy != 0 {
+		return fmt.Errorf("failed to checkpoint database: %d of %d frames copied while busy", checkpointed, logFrames)
+	}
 	return nil
 }
 
-// RunSeeds runs all seed files
-func RunSeeds() error {
-	// TODO: Implement seeding from JSON files
+// Shutdown checkpoints the write-ahead log and closes the connection. The
+// connection is closed even when the checkpoint fails.
+func Shutdown(conn *sql.DB) error {
+	checkpointErr := Checkpoint(conn)
+	if err := conn.Close(); err != nil {
+		return err
+	}
+	return checkpointErr
+}
+
+// RunMigrations applies all pending migrations from the default directory
+func RunMigrations(db *sql.DB) error {
+	log.Println("Running migrations...")
+	applied, err := Migrate(db, MigrationsDir)
+	for _, m := range applied {
+		log.Printf("Applied migration: %s", m.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		log.Println("Database schema is up to date")
+		return nil
+	}
+
+	log.Println("Migrations completed successfully")
 	return nil
 }
@@ -0,0 +1,408 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/dialect"
+)
+
+// MigrationsDir is the default location of the SQL migration files. It
+// holds one directory per dialect, e.g. db/migrations/postgres.
+const MigrationsDir = "db/migrations"
+
+// downSuffix marks the file that reverts a migration, e.g. 0001_init.down.sql
+const downSuffix = ".down.sql"
+
+// Migration represents a single versioned schema change
+type Migration struct {
+	Version  int64
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string
+}
+
+// MigrationState describes whether a migration has been applied
+type MigrationState struct {
+	Migration
+	Applied   bool
+	AppliedAt string
+}
+
+// LoadMigrations reads the migration files in dir, ordered by version.
+// Up migrations are named NNNN_name.sql and their optional down
+// counterparts NNNN_name.down.sql.
+func LoadMigrations(dir string) ([]Migration, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migration files: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	downs := make(map[int64]string)
+	for _, file := range files {
+		base := filepath.Base(file)
+		isDown := strings.HasSuffix(base, downSuffix)
+
+		name := strings.TrimSuffix(base, ".sql")
+		if isDown {
+			name = strings.TrimSuffix(base, downSuffix)
+		}
+
+		version, err := parseVersion(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s: %w", base, err)
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", file, err)
+		}
+
+		if isDown {
+			downs[version] = string(content)
+			continue
+		}
+
+		if existing, ok := byVersion[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, existing.Name, name)
+		}
+
+		sum := sha256.Sum256(content)
+		byVersion[version] = &Migration{
+			Version:  version,
+			Name:     name,
+			UpSQL:    string(content),
+			Checksum: hex.EncodeToString(sum[:]),
+		}
+	}
+
+	for version, down := range downs {
+		m, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("down migration for version %d has no matching up migration", version)
+		}
+		m.DownSQL = down
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parseVersion extracts the numeric prefix of a migration name
+func parseVersion(name string) (int64, error) {
+	prefix, _, found := strings.Cut(name, "_")
+	if !found {
+		return 0, fmt.Errorf("expected NNNN_name format")
+	}
+	return strconv.ParseInt(prefix, 10, 64)
+}
+
+// dialectDir returns the directory holding the migrations for the
+// dialect of db under dir
+func dialectDir(db *sql.DB, dir string) string {
+	return filepath.Join(dir, string(dialect.Of(db)))
+}
+
+// ensureMigrationsTable creates the schema_migrations ledger if needed
+func ensureMigrationsTable(db *sql.DB) error {
+	timestamp := "DATETIME"
+	if dialect.Of(db) == dialect.Postgres {
+		timestamp = "TIMESTAMPTZ"
+	}
+
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at ` + timestamp + ` NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// appliedMigration is a row of the schema_migrations ledger
+type appliedMigration struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt string
+}
+
+// loadApplied returns the ledger rows keyed by version
+func loadApplied(db *sql.DB) (map[int64]appliedMigration, error) {
+	rows, err := db.Query("SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+// verifyApplied ensures every applied migration still exists on disk unchanged
+func verifyApplied(migrations []Migration, applied map[int64]appliedMigration) error {
+	known := make(map[int64]Migration, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+
+	for version, a := range applied {
+		m, ok := known[version]
+		if !ok {
+			return fmt.Errorf("applied migration %s is missing from the migrations directory", a.name)
+		}
+		if m.Checksum != a.checksum {
+			return fmt.Errorf("checksum mismatch for applied migration %s: file was modified after it was applied", m.Name)
+		}
+	}
+
+	return nil
+}
+
+// Migrate applies every pending migration for the dialect of db in dir,
+// each inside its own transaction, and returns the migrations that were
+// applied. It refuses to run if a previously applied migration file has
+// been modified or removed.
+func Migrate(db *sql.DB, dir string) ([]Migration, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	migrations, err := LoadMigrations(dialectDir(db, dir))
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := loadApplied(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	if err := verifyApplied(migrations, applied); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		if err := applyMigration(db, m); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// applyMigration runs a single up migration and records it in the ledger
+func applyMigration(db *sql.DB, m Migration) error {
+	return inMigrationTx(db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(m.UpSQL); err != nil {
+			if strings.Contains(err.Error(), "no such module: fts5") {
+				return fmt.Errorf("failed to execute migration %s: %w (build with -tags sqlite_fts5)", m.Name, err)
+			}
+			return fmt.Errorf("failed to execute migration %s: %w", m.Name, err)
+		}
+
+		if _, err := dialect.Of(db).Bind(tx).Exec(
+			"INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)",
+			m.Version, m.Name, m.Checksum,
+		); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", m.Name, err)
+		}
+		return nil
+	})
+}
+
+// inMigrationTx runs fn in a transaction. On SQLite the transaction runs
+// with foreign keys off, so a migration can rebuild a table other tables
+// reference without its DROP cascading to them, and the keys are checked
+// before it commits.
+func inMigrationTx(db *sql.DB, fn func(tx *sql.Tx) error) (err error) {
+	if dialect.Of(db) != dialect.SQLite {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	// The pragma is per connection and ignored inside a transaction
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer func() {
+		if _, onErr := conn.ExecContext(ctx, "PRAGMA foreign_keys = ON"); onErr != nil && err == nil {
+			err = fmt.Errorf("failed to enable foreign keys: %w", onErr)
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := checkForeignKeys(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkForeignKeys returns an error naming the first row that references
+// a missing parent
+func checkForeignKeys(tx *sql.Tx) error {
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var table, parent string
+		var rowID sql.NullInt64
+		var fkID int64
+		if err := rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			return err
+		}
+		return fmt.Errorf("foreign key check failed: %s row %d references a missing %s", table, rowID.Int64, parent)
+	}
+	return rows.Err()
+}
+
+// Rollback reverts the most recently applied migrations for the dialect of
+// db, newest first, and returns the migrations that were reverted
+func Rollback(db *sql.DB, dir string, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	migrations, err := LoadMigrations(dialectDir(db, dir))
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := loadApplied(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	if err := verifyApplied(migrations, applied); err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		if m.DownSQL == "" {
+			return reverted, fmt.Errorf("migration %s has no down migration", m.Name)
+		}
+
+		if err := revertMigration(db, m); err != nil {
+			return reverted, err
+		}
+		reverted = append(reverted, m)
+	}
+
+	return reverted, nil
+}
+
+// revertMigration runs a single down migration and removes it from the ledger
+func revertMigration(db *sql.DB, m Migration) error {
+	return inMigrationTx(db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(m.DownSQL); err != nil {
+			return fmt.Errorf("failed to revert migration %s: %w", m.Name, err)
+		}
+
+		if _, err := dialect.Of(db).Bind(tx).Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version); err != nil {
+			return fmt.Errorf("failed to unrecord migration %s: %w", m.Name, err)
+		}
+		return nil
+	})
+}
+
+// MigrationStatus reports every known migration for the dialect of db and
+// whether it is applied
+func MigrationStatus(db *sql.DB, dir string) ([]MigrationState, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	migrations, err := LoadMigrations(dialectDir(db, dir))
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := loadApplied(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		state := MigrationState{Migration: m}
+		if a, ok := applied[m.Version]; ok {
+			state.Applied = true
+			state.AppliedAt = a.appliedAt
+		}
+		states = append(states, state)
+	}
+
+	return states, nil
+}
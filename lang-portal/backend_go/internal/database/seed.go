@@ -0,0 +1,259 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/importer"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+	"github.com/erans/lang-portal/internal/repository/sqlstore"
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/erans/lang-portal/internal/validation"
+)
+
+// seedFile is a parsed JSON seed file bound to its manifest entry
+type seedFile struct {
+	entry       SeedEntry
+	description string
+	words       []importer.SeedWord
+}
+
+// SeedOptions controls how seeds are applied
+type SeedOptions struct {
+	// DryRun validates the seeds and reports what would change without
+	// committing anything
+	DryRun bool
+}
+
+// SeedFileReport summarizes the changes made for one seed file
+type SeedFileReport struct {
+	File           string `json:"file"`
+	Group          string `json:"group"`
+	GroupCreated   bool   `json:"group_created"`
+	WordsInserted  int    `json:"words_inserted"`
+	WordsUpdated   int    `json:"words_updated"`
+	WordsUnchanged int    `json:"words_unchanged"`
+	WordsLinked    int    `json:"words_linked"`
+}
+
+// SeedReport summarizes a seeding run
+type SeedReport struct {
+	DryRun bool             `json:"dry_run"`
+	Files  []SeedFileReport `json:"files"`
+}
+
+// String renders the report for terminal output
+func (r *SeedReport) String() string {
+	var b strings.Builder
+	if r.DryRun {
+		b.WriteString("Dry run, no changes were committed\n")
+	}
+	for _, f := range r.Files {
+		group := f.Group
+		if f.GroupCreated {
+			group += " (new)"
+		}
+		fmt.Fprintf(&b, "%s -> %s: %d inserted, %d updated, %d unchanged, %d linked\n",
+			f.File, group, f.WordsInserted, f.WordsUpdated, f.WordsUnchanged, f.WordsLinked)
+	}
+	return b.String()
+}
+
+// RunSeeds applies the default seed manifest to db
+func RunSeeds(db *sql.DB) error {
+	report, err := SeedFromManifest(db, filepath.Join(SeedsDir, ManifestFile), SeedOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range report.Files {
+		log.Printf("Seeded %s into %q: %d inserted, %d updated, %d unchanged",
+			f.File, f.Group, f.WordsInserted, f.WordsUpdated, f.WordsUnchanged)
+	}
+	return nil
+}
+
+// SeedFromManifest loads every JSON seed file declared in the manifest and
+// upserts its words, group and memberships in a single transaction. Seeding
+// is idempotent: words are matched on japanese and english, groups on name.
+func SeedFromManifest(db *sql.DB, manifestPath string, opts SeedOptions) (*SeedReport, error) {
+	entries, err := ParseManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate every file up front so nothing is written when any is invalid
+	var files []seedFile
+	var errs SeedErrors
+	for _, entry := range entries {
+		file, fileErrs := loadSeedFile(entry)
+		errs = append(errs, fileErrs...)
+		if file != nil {
+			files = append(files, *file)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	report := &SeedReport{DryRun: opts.DryRun}
+	err = sqlstore.New(db).Atomic(func(store repository.Store) error {
+		for _, file := range files {
+			fileReport, err := applySeedFile(store, file)
+			if err != nil {
+				return err
+			}
+			report.Files = append(report.Files, *fileReport)
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// errDryRun rolls back a dry run once its report is complete
+var errDryRun = errors.New("dry run")
+
+// loadSeedFile parses and validates a JSON seed file. The file is either an
+// object with optional "group" and required "words" keys, or a bare array of
+// words.
+func loadSeedFile(entry SeedEntry) (*seedFile, SeedErrors) {
+	name := filepath.Base(entry.File)
+	fail := func(line int, format string, args ...any) (*seedFile, SeedErrors) {
+		return nil, SeedErrors{{File: name, Line: line, Msg: fmt.Sprintf(format, args...)}}
+	}
+
+	data, err := os.ReadFile(entry.File)
+	if err != nil {
+		return fail(0, "failed to read seed file: %v", err)
+	}
+
+	seed, err := importer.DecodeSeed(data)
+	if err != nil {
+		var seedErr *importer.SeedError
+		if errors.As(err, &seedErr) {
+			return fail(seedErr.Line, "%s", seedErr.Msg)
+		}
+		return fail(0, "%v", err)
+	}
+
+	file := &seedFile{entry: entry, words: seed.Words}
+	if group := seed.Group; group != nil {
+		if group.Name != "" && group.Name != entry.Group {
+			return fail(0, "group name %q does not match manifest group %q", group.Name, entry.Group)
+		}
+		file.description = group.Description
+	}
+
+	var errs SeedErrors
+	for _, word := range file.words {
+		if word.Err != nil {
+			errs = append(errs, &SeedError{File: name, Line: word.Line, Msg: word.Err.Error()})
+			continue
+		}
+		for _, msg := range validateSeedWord(word.Word) {
+			errs = append(errs, &SeedError{File: name, Line: word.Line, Msg: msg})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return file, nil
+}
+
+// seedValidator checks seed words with the same rules as the API. Seeds
+// carry no foreign keys, so it needs no database.
+var seedValidator = validation.New(nil)
+
+// validateSeedWord returns a message for every problem with a word
+func validateSeedWord(word models.Word) []string {
+	err := seedValidator.Struct(word)
+
+	var fieldErrs validation.Errors
+	if !errors.As(err, &fieldErrs) {
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return nil
+	}
+
+	msgs := make([]string, len(fieldErrs))
+	for i, f := range fieldErrs {
+		msgs[i] = fmt.Sprintf("word %s %s", f.Field, f.Message)
+	}
+	return msgs
+}
+
+// applySeedFile upserts the group, words and memberships of one seed file
+func applySeedFile(store repository.Store, file seedFile) (*SeedFileReport, error) {
+	report := &SeedFileReport{
+		File:  filepath.Base(file.entry.File),
+		Group: file.entry.Group,
+	}
+
+	groupID, created, err := upsertSeedGroup(store, file.entry.Group, file.description)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to upsert group %q: %w", report.File, file.entry.Group, err)
+	}
+	report.GroupCreated = created
+
+	for _, seed := range file.words {
+		word := seed.Word
+		status, linked, err := service.UpsertWord(store, groupID, &word)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: failed to upsert word %q: %w", report.File, seed.Line, word.Japanese, err)
+		}
+
+		switch status {
+		case service.ImportCreated:
+			report.WordsInserted++
+		case service.ImportUpdated:
+			report.WordsUpdated++
+		default:
+			report.WordsUnchanged++
+		}
+		if linked {
+			report.WordsLinked++
+		}
+	}
+
+	return report, nil
+}
+
+// upsertSeedGroup finds a group by name, creating it if necessary. An
+// existing group's description is refreshed when the seed provides one.
+func upsertSeedGroup(store repository.Store, name, description string) (int64, bool, error) {
+	group, err := store.Groups().GetByName(name)
+	if errors.Is(err, repository.ErrNotFound) {
+		group = &models.Group{Name: name, Description: description}
+		if err := store.Groups().Create(group); err != nil {
+			return 0, false, err
+		}
+		return group.ID, true, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	if description != "" && description != group.Description {
+		group.Description = description
+		if err := store.Groups().Update(group); err != nil {
+			return 0, false, err
+		}
+	}
+
+	return group.ID, false, nil
+}
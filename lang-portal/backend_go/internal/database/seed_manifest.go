@@ -0,0 +1,172 @@
+package database
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SeedsDir is the default location of the seed files
+const SeedsDir = "db/seeds"
+
+// ManifestFile is the name of the seed manifest inside the seeds directory
+const ManifestFile = "seeds.manifest"
+
+// SeedEntry maps a JSON seed file to the group its words belong to
+type SeedEntry struct {
+	File  string
+	Group string
+	Line  int
+}
+
+// SeedError describes a problem in a seed file or the manifest
+type SeedError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *SeedError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Msg)
+}
+
+// SeedErrors collects every problem found while validating seeds
+type SeedErrors []*SeedError
+
+func (e SeedErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// ParseManifest reads a seed manifest. Each non-empty line that is not a
+// comment declares one seed file and its target group:
+//
+//	# comment
+//	seed basic_greetings.json into "Basic Greetings"
+//
+// File paths are relative to the manifest's directory.
+func ParseManifest(path string) ([]SeedEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open seed manifest: %w", err)
+	}
+	defer f.Close()
+
+	name := filepath.Base(path)
+	dir := filepath.Dir(path)
+
+	var entries []SeedEntry
+	var errs SeedErrors
+	seen := make(map[string]int)
+
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry, err := parseManifestLine(line)
+		if err != nil {
+			errs = append(errs, &SeedError{File: name, Line: lineNo, Msg: err.Error()})
+			continue
+		}
+
+		if prev, ok := seen[entry.File]; ok {
+			errs = append(errs, &SeedError{
+				File: name,
+				Line: lineNo,
+				Msg:  fmt.Sprintf("%s is already declared on line %d", entry.File, prev),
+			})
+			continue
+		}
+		seen[entry.File] = lineNo
+
+		entry.File = filepath.Join(dir, entry.File)
+		entry.Line = lineNo
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read seed manifest: %w", err)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return entries, nil
+}
+
+// parseManifestLine parses a single `seed <file> into "<group>"` statement
+func parseManifestLine(line string) (SeedEntry, error) {
+	tokens, err := tokenizeManifestLine(line)
+	if err != nil {
+		return SeedEntry{}, err
+	}
+
+	if len(tokens) == 0 || tokens[0] != "seed" {
+		return SeedEntry{}, fmt.Errorf(`expected statement to start with "seed"`)
+	}
+	if len(tokens) != 4 || tokens[2] != "into" {
+		return SeedEntry{}, fmt.Errorf(`expected: seed <file> into "<group name>"`)
+	}
+
+	file, group := tokens[1], tokens[3]
+	if filepath.Ext(file) != ".json" {
+		return SeedEntry{}, fmt.Errorf("seed file %s must be a .json file", file)
+	}
+	if strings.TrimSpace(group) == "" {
+		return SeedEntry{}, fmt.Errorf("group name must not be empty")
+	}
+
+	return SeedEntry{File: file, Group: group}, nil
+}
+
+// tokenizeManifestLine splits a line on whitespace, honouring double-quoted
+// strings and trailing comments
+func tokenizeManifestLine(line string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == '#':
+			return tokens, nil
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, fmt.Errorf("unterminated quoted string")
+			}
+			value, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted string %s", line[i:end+1])
+			}
+			tokens = append(tokens, value)
+			i = end + 1
+		default:
+			end := i
+			for end < len(line) && !unicode.IsSpace(rune(line[end])) && line[end] != '#' {
+				end++
+			}
+			tokens = append(tokens, line[i:end])
+			i = end
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,173 @@
+// Package dialect captures the differences between the SQL databases the
+// portal runs on. Queries are written once with ? placeholders and SQL
+// both engines accept; the few expressions that differ come from here.
+package dialect
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect names a supported database engine
+type Dialect string
+
+const (
+	// SQLite is a single-file database, the default
+	SQLite Dialect = "sqlite"
+	// Postgres is a PostgreSQL server
+	Postgres Dialect = "postgres"
+)
+
+// pgUniqueViolation is the SQLSTATE of a unique constraint failure
+const pgUniqueViolation = "23505"
+
+// Parse converts a configured driver name into a Dialect
+func Parse(s string) (Dialect, error) {
+	switch d := Dialect(strings.ToLower(strings.TrimSpace(s))); d {
+	case SQLite, Postgres:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unknown database driver %q (expected sqlite or postgres)", s)
+	}
+}
+
+// UnmarshalText parses a driver name, so config files are checked as they
+// are read
+func (d *Dialect) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Of returns the dialect of an open database
+func Of(db *sql.DB) Dialect {
+	if _, ok := db.Driver().(*stdlib.Driver); ok {
+		return Postgres
+	}
+	return SQLite
+}
+
+// DriverName returns the database/sql driver registered for the dialect
+func (d Dialect) DriverName() string {
+	if d == Postgres {
+		return "pgx"
+	}
+	return "sqlite3"
+}
+
+// Rebind rewrites the ? placeholders of query into the dialect's form.
+// Question marks inside string literals are left alone.
+func (d Dialect) Rebind(query string) string {
+	if d != Postgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	inString := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case r == '?' && !inString:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Seconds returns an expression for the seconds elapsed between two
+// timestamp expressions
+func (d Dialect) Seconds(start, end string) string {
+	if d == Postgres {
+		return "EXTRACT(EPOCH FROM (" + end + " - " + start + "))"
+	}
+	return "((JULIANDAY(" + end + ") - JULIANDAY(" + start + ")) * 86400)"
+}
+
+// Timestamp returns an expression that orders and compares a timestamp
+// expression by the instant it holds. SQLite keeps timestamps as text,
+// written with or without a zone offset depending on who wrote them.
+func (d Dialect) Timestamp(expr string) string {
+	if d == Postgres {
+		return expr
+	}
+	return "JULIANDAY(" + expr + ")"
+}
+
+// DaysBetween returns an expression for the whole days from one date
+// expression to a later one
+func (d Dialect) DaysBetween(earlier, later string) string {
+	if d == Postgres {
+		return "(" + later + " - " + earlier + ")"
+	}
+	return "(JULIANDAY(" + later + ") - JULIANDAY(" + earlier + "))"
+}
+
+// DaysAgo returns an expression for the date n days before today
+func (d Dialect) DaysAgo(n int) string {
+	if d == Postgres {
+		return "(CURRENT_DATE - " + strconv.Itoa(n) + ")"
+	}
+	return "DATE('now', '-" + strconv.Itoa(n) + " day')"
+}
+
+// IsUniqueViolation reports whether err is a unique constraint failure on
+// either engine
+func IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+// Querier is satisfied by *sql.DB, *sql.Tx and the result of Bind
+type Querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// Bind returns a Querier that rebinds every query for the dialect before
+// running it on q
+func (d Dialect) Bind(q Querier) Querier {
+	if d != Postgres {
+		return q
+	}
+	return &boundQuerier{q: q, d: d}
+}
+
+// boundQuerier rebinds queries before passing them on
+type boundQuerier struct {
+	q Querier
+	d Dialect
+}
+
+func (b *boundQuerier) Exec(query string, args ...any) (sql.Result, error) {
+	return b.q.Exec(b.d.Rebind(query), args...)
+}
+
+func (b *boundQuerier) Query(query string, args ...any) (*sql.Rows, error) {
+	return b.q.Query(b.d.Rebind(query), args...)
+}
+
+func (b *boundQuerier) QueryRow(query string, args ...any) *sql.Row {
+	return b.q.QueryRow(b.d.Rebind(query), args...)
+}
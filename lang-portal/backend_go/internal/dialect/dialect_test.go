@@ -0,0 +1,134 @@
+package dialect
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Dialect
+		wantErr bool
+	}{
+		{in: "sqlite", want: SQLite},
+		{in: " Postgres ", want: Postgres},
+		{in: "mysql", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var d Dialect
+			err := d.UnmarshalText([]byte(tt.in))
+			if (err != nil) != tt.wantErr || d != tt.want {
+				t.Fatalf("UnmarshalText(%q) = %q, %v, want %q", tt.in, d, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "no placeholders", query: "SELECT 1", want: "SELECT 1"},
+		{name: "placeholders", query: "SELECT * FROM words WHERE id = ? AND english = ?", want: "SELECT * FROM words WHERE id = $1 AND english = $2"},
+		{name: "question mark in a literal", query: "SELECT '?' || english FROM words WHERE id = ?", want: "SELECT '?' || english FROM words WHERE id = $1"},
+		{name: "escaped quote in a literal", query: "SELECT 'it''s?' WHERE a = ? AND b = ?", want: "SELECT 'it''s?' WHERE a = $1 AND b = $2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Postgres.Rebind(tt.query); got != tt.want {
+				t.Errorf("Postgres.Rebind = %q, want %q", got, tt.want)
+			}
+			if got := SQLite.Rebind(tt.query); got != tt.query {
+				t.Errorf("SQLite.Rebind = %q, want the query unchanged", got)
+			}
+		})
+	}
+}
+
+func TestOf(t *testing.T) {
+	for _, d := range []Dialect{SQLite, Postgres} {
+		// Opening does not connect, so no server is needed
+		db, err := sql.Open(d.DriverName(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Of(db); got != d {
+			t.Errorf("Of(%s database) = %s", d, got)
+		}
+		db.Close()
+	}
+}
+
+// TestSQLiteExpressions evaluates the SQLite expressions on timestamps
+// written the ways the portal writes them
+func TestSQLiteExpressions(t *testing.T) {
+	db, err := sql.Open(SQLite.DriverName(), ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// The second value is earlier even though it sorts later as text
+	const a, b = "'2026-03-01 10:00:00'", "'2026-03-01T11:30:00+02:00'"
+	tests := []struct {
+		name string
+		expr string
+		want float64
+	}{
+		{name: "timestamps compare by instant", expr: "CASE WHEN " + SQLite.Timestamp(b) + " < " + SQLite.Timestamp(a) + " THEN 1 ELSE 0 END", want: 1},
+		{name: "seconds between", expr: "ROUND(" + SQLite.Seconds(b, a) + ")", want: 30 * 60},
+		{name: "days between", expr: SQLite.DaysBetween("'2026-02-27'", "'2026-03-02'"), want: 3},
+		{name: "days ago", expr: SQLite.DaysBetween(SQLite.DaysAgo(7), "DATE('now')"), want: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got float64
+			if err := db.QueryRow("SELECT " + tt.expr).Scan(&got); err != nil {
+				t.Fatalf("%s: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	db, err := sql.Open(SQLite.DriverName(), ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("CREATE TABLE groups (name TEXT UNIQUE, size INTEGER NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO groups VALUES ('Animals', 1)"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, duplicate := db.Exec("INSERT INTO groups VALUES ('Animals', 2)")
+	if !IsUniqueViolation(duplicate) {
+		t.Errorf("IsUniqueViolation(%v) = false, want true", duplicate)
+	}
+	_, notNull := db.Exec("INSERT INTO groups VALUES ('Colors', NULL)")
+	if notNull == nil || IsUniqueViolation(notNull) {
+		t.Errorf("IsUniqueViolation(%v) = true, want false", notNull)
+	}
+
+	if !IsUniqueViolation(&pgconn.PgError{Code: pgUniqueViolation}) {
+		t.Error("a Postgres unique violation was not recognized")
+	}
+	if IsUniqueViolation(&pgconn.PgError{Code: "23503"}) || IsUniqueViolation(errors.New("unique")) {
+		t.Error("other errors were taken for unique violations")
+	}
+}
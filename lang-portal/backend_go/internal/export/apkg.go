@@ -0,0 +1,305 @@
+package export
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ankiSchema creates an empty Anki collection (schema version 11), the
+// format every Anki version imports
+const ankiSchema = `
+CREATE TABLE col (
+    id integer primary key, crt integer not null, mod integer not null,
+    scm integer not null, ver integer not null, dty integer not null,
+    usn integer not null, ls integer not null, conf text not null,
+    models text not null, decks text not null, dconf text not null,
+    tags text not null
+);
+CREATE TABLE notes (
+    id integer primary key, guid text not null, mid integer not null,
+    mod integer not null, usn integer not null, tags text not null,
+    flds text not null, sfld integer not null, csum integer not null,
+    flags integer not null, data text not null
+);
+CREATE TABLE cards (
+    id integer primary key, nid integer not null, did integer not null,
+    ord integer not null, mod integer not null, usn integer not null,
+    type integer not null, queue integer not null, due integer not null,
+    ivl integer not null, factor integer not null, reps integer not null,
+    lapses integer not null, left integer not null, odue integer not null,
+    odid integer not null, flags integer not null, data text not null
+);
+CREATE TABLE revlog (
+    id integer primary key, cid integer not null, usn integer not null,
+    ease integer not null, ivl integer not null, lastIvl integer not null,
+    factor integer not null, time integer not null, type integer not null
+);
+CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null);
+CREATE INDEX ix_notes_usn ON notes (usn);
+CREATE INDEX ix_cards_usn ON cards (usn);
+CREATE INDEX ix_revlog_usn ON revlog (usn);
+CREATE INDEX ix_cards_nid ON cards (nid);
+CREATE INDEX ix_cards_sched ON cards (did, queue, due);
+CREATE INDEX ix_revlog_cid ON revlog (cid);
+CREATE INDEX ix_notes_csum ON notes (csum);
+`
+
+// ankiTag marks every exported note
+const ankiTag = "lang-portal"
+
+// noteFields are the fields of the exported note type: the word itself,
+// then one per parts key
+func noteFields(deck *Deck) []string {
+	fields := []string{"Japanese", "Romaji", "English"}
+	for _, key := range deck.PartKeys() {
+		// Anki reserves these characters in field names
+		name := strings.NewReplacer(":", "_", "{", "(", "}", ")", `"`, "'").Replace(key)
+		name = strings.TrimLeft(name, "#/^ ")
+		if name == "" {
+			name = "Part"
+		}
+		for base, n := name, 2; containsFold(fields, name); n++ {
+			name = base + " " + strconv.Itoa(n)
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ankiID derives a stable ID from a name, so exporting a deck again
+// updates the note type and deck it created instead of adding new ones
+func ankiID(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	// Anki IDs are positive millisecond-style integers
+	return int64(h.Sum64()>>12) | 1<<40
+}
+
+// fieldChecksum is the checksum Anki keeps of a note's first field to
+// find duplicates
+func fieldChecksum(text string) int64 {
+	sum := sha1.Sum([]byte(text))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
+
+// writeAPKG writes the deck as an Anki package: a zip holding a collection
+// database with one note and one new card per word, and an empty media
+// index. The collection is built in a temporary file first.
+func writeAPKG(w io.Writer, deck *Deck) error {
+	tmp, err := os.CreateTemp("", "lang-portal-export-*.anki2")
+	if err != nil {
+		return err
+	}
+	path := tmp.Name()
+	tmp.Close()
+	defer os.Remove(path)
+
+	if err := buildCollection(path, deck); err != nil {
+		return fmt.Errorf("building the anki collection: %w", err)
+	}
+
+	collection, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer collection.Close()
+
+	zw := zip.NewWriter(w)
+	entry, err := zw.Create("collection.anki2")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(entry, collection); err != nil {
+		return err
+	}
+	media, err := zw.Create("media")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(media, "{}"); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// buildCollection writes the collection database of a deck to path
+func buildCollection(path string, deck *Deck) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(ankiSchema); err != nil {
+		return err
+	}
+
+	fields := noteFields(deck)
+	now := deck.ExportedAt
+	modelID := ankiID("lang-portal:" + strings.Join(fields, "\x1f"))
+	deckID := ankiID("lang-portal:" + deck.Name())
+	models, decks, dconf, conf, err := collectionConfig(deck, fields, modelID, deckID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')",
+		now.Truncate(24*time.Hour).Unix(), now.UnixMilli(), now.UnixMilli(),
+		conf, models, decks, dconf,
+	)
+	if err != nil {
+		return err
+	}
+
+	keys := deck.PartKeys()
+	base := now.UnixMilli()
+	for i, word := range deck.Words {
+		values := []string{word.Japanese, word.Romaji, word.English}
+		for _, key := range keys {
+			values = append(values, partText(word.Parts[key]))
+		}
+		for j, value := range values {
+			values[j] = html.EscapeString(value)
+		}
+
+		noteID := base + int64(i)
+		_, err := tx.Exec(
+			"INSERT INTO notes VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')",
+			noteID,
+			"lang-portal-"+strconv.FormatInt(word.ID, 10),
+			modelID,
+			now.Unix(),
+			" "+ankiTag+" ",
+			strings.Join(values, "\x1f"),
+			values[0],
+			fieldChecksum(word.Japanese),
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			"INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')",
+			noteID, noteID, deckID, now.Unix(), i+1,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// collectionConfig returns the JSON configuration of the collection: its
+// one note type, the default deck and the exported deck, the default deck
+// options and the collection settings
+func collectionConfig(deck *Deck, fields []string, modelID, deckID int64) (models, decks, dconf, conf string, err error) {
+	mod := deck.ExportedAt.Unix()
+
+	flds := make([]map[string]any, len(fields))
+	back := "{{FrontSide}}\n\n<hr id=answer>\n\n{{Romaji}}<br>\n{{English}}"
+	for i, name := range fields {
+		flds[i] = map[string]any{
+			"name": name, "ord": i, "sticky": false, "rtl": false,
+			"font": "Arial", "size": 20, "media": []any{},
+		}
+		if i >= 3 {
+			back += fmt.Sprintf("\n{{#%[1]s}}<div class=part>%[1]s: {{%[1]s}}</div>{{/%[1]s}}", name)
+		}
+	}
+	model := map[string]any{
+		"id": modelID, "name": "Lang Portal Word", "type": 0, "mod": mod, "usn": -1,
+		"sortf": 0, "did": deckID, "tags": []any{}, "vers": []any{},
+		"flds": flds,
+		"tmpls": []map[string]any{{
+			"name": "Recognition", "ord": 0, "did": nil, "bqfmt": "", "bafmt": "",
+			"qfmt": "<div class=japanese>{{Japanese}}</div>",
+			"afmt": back,
+		}},
+		"css":       ".card { font-family: arial; font-size: 20px; text-align: center; }\n.japanese { font-size: 40px; }\n.part { font-size: 14px; color: #666; }",
+		"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+		"req":       []any{[]any{0, "any", []int{0}}},
+	}
+
+	newDeck := func(id int64, name, desc string) map[string]any {
+		return map[string]any{
+			"id": id, "name": name, "desc": desc, "mod": mod, "usn": -1,
+			"collapsed": false, "dyn": 0, "conf": 1, "extendNew": 10, "extendRev": 50,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0},
+			"lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+		}
+	}
+	var desc string
+	if deck.Group != nil {
+		desc = deck.Group.Description
+	}
+
+	options := map[string]any{
+		"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60,
+		"autoplay": true, "timer": 0, "replayq": true, "dyn": false,
+		"new": map[string]any{
+			"delays": []int{1, 10}, "ints": []int{1, 4, 7}, "initialFactor": 2500,
+			"order": 1, "perDay": 20, "bury": true, "separate": true,
+		},
+		"rev": map[string]any{
+			"perDay": 100, "ease4": 1.3, "fuzz": 0.05, "maxIvl": 36500,
+			"bury": true, "minSpace": 1,
+		},
+		"lapse": map[string]any{
+			"delays": []int{10}, "mult": 0, "minInt": 1, "leechFails": 8, "leechAction": 0,
+		},
+	}
+	settings := map[string]any{
+		"nextPos": len(deck.Words) + 1, "estTimes": true, "activeDecks": []int64{1},
+		"sortType": "noteFld", "timeLim": 0, "sortBackwards": false, "addToCur": true,
+		"curDeck": 1, "newSpread": 0, "dueCounts": true, "curModel": modelID,
+		"collapseTime": 1200,
+	}
+
+	values := []any{
+		map[string]any{strconv.FormatInt(modelID, 10): model},
+		map[string]any{
+			"1":                           newDeck(1, "Default", ""),
+			strconv.FormatInt(deckID, 10): newDeck(deckID, deck.Name(), desc),
+		},
+		map[string]any{"1": options},
+		settings,
+	}
+	out := make([]string, len(values))
+	for i, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", "", "", "", err
+		}
+		out[i] = string(data)
+	}
+	return out[0], out[1], out[2], out[3], nil
+}
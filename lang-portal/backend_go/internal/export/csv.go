@@ -0,0 +1,35 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// writeCSV writes one row per word after a header row. Each parts key gets
+// its own column, named "parts.<key>".
+func writeCSV(w io.Writer, deck *Deck) error {
+	keys := deck.PartKeys()
+	out := csv.NewWriter(w)
+
+	header := []string{"id", "japanese", "romaji", "english"}
+	for _, key := range keys {
+		header = append(header, "parts."+key)
+	}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	for _, word := range deck.Words {
+		row := []string{strconv.FormatInt(word.ID, 10), word.Japanese, word.Romaji, word.English}
+		for _, key := range keys {
+			row = append(row, partText(word.Parts[key]))
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,132 @@
+// Package export writes vocabulary decks out in the formats other tools
+// read: CSV for spreadsheets, the JSON shape of the seed files, and Anki
+// packages.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+)
+
+// Format is a file format decks can be exported to
+type Format string
+
+// Supported formats
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+	APKG Format = "apkg"
+)
+
+// Formats lists every supported format
+var Formats = []Format{CSV, JSON, APKG}
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(name))
+	if !slices.Contains(Formats, format) {
+		return "", fmt.Errorf("unknown export format %q", name)
+	}
+	return format, nil
+}
+
+// ContentType returns the MIME type of a format
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case JSON:
+		return "application/json; charset=utf-8"
+	default:
+		return "application/zip"
+	}
+}
+
+// Deck is a set of words exported together. Decks of a group carry its
+// name and description; a deck of the whole vocabulary has no Group.
+type Deck struct {
+	Group      *models.Group
+	Words      []models.Word
+	ExportedAt time.Time
+}
+
+// Name names the deck after its group
+func (d *Deck) Name() string {
+	if d.Group != nil {
+		return d.Group.Name
+	}
+	return "Vocabulary"
+}
+
+// FileName returns the name to save the deck as in a format, made of the
+// ASCII letters and digits of the deck's name
+func (d *Deck) FileName(f Format) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(d.Name()) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("vocabulary")
+	}
+	return b.String() + "." + string(f)
+}
+
+// PartKeys returns every key used in the parts of the deck's words, sorted
+func (d *Deck) PartKeys() []string {
+	var keys []string
+	for _, word := range d.Words {
+		for key := range word.Parts {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Write writes the deck to w in a format
+func Write(w io.Writer, f Format, deck *Deck) error {
+	switch f {
+	case CSV:
+		return writeCSV(w, deck)
+	case JSON:
+		return writeJSON(w, deck)
+	case APKG:
+		return writeAPKG(w, deck)
+	default:
+		return fmt.Errorf("unknown export format %q", f)
+	}
+}
+
+// partText renders a parts value as text: strings as they are, anything
+// else as JSON
+func partText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	text, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(text)
+}
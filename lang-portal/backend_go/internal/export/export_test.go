@@ -0,0 +1,133 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/erans/lang-portal/internal/export"
+	"github.com/erans/lang-portal/internal/importer"
+	"github.com/erans/lang-portal/internal/models"
+)
+
+// deck has parts of several types, a word without parts and text that
+// needs quoting in CSV and escaping in Anki's HTML fields
+func deck() *export.Deck {
+	return &export.Deck{
+		Group: &models.Group{Name: "Food & Drink", Description: "Things to order"},
+		Words: []models.Word{
+			{ID: 1, Japanese: "みず", Romaji: "mizu", English: "water", Parts: map[string]any{"type": "noun", "jlpt": float64(5)}},
+			{ID: 2, Japanese: "おちゃ", Romaji: "ocha", English: "tea, green", Parts: map[string]any{"tags": []any{"drink"}}},
+			{ID: 3, Japanese: "すし", Romaji: "sushi", English: "<b>sushi</b>", Parts: map[string]any{}},
+		},
+		ExportedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"csv", "JSON", "apkg"} {
+		if _, err := export.ParseFormat(name); err != nil {
+			t.Errorf("ParseFormat(%q): %v", name, err)
+		}
+	}
+	if _, err := export.ParseFormat("tsv"); err == nil {
+		t.Error("ParseFormat(tsv) succeeded, want an error")
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		group *models.Group
+		want  string
+	}{
+		{group: nil, want: "vocabulary.csv"},
+		{group: &models.Group{Name: "Food & Drink"}, want: "food-drink.csv"},
+		{group: &models.Group{Name: "  JLPT N5: Verbs!"}, want: "jlpt-n5-verbs.csv"},
+		{group: &models.Group{Name: "挨拶"}, want: "vocabulary.csv"},
+	}
+	for _, tt := range tests {
+		d := &export.Deck{Group: tt.group}
+		if got := d.FileName(export.CSV); got != tt.want {
+			t.Errorf("FileName of %q = %q, want %q", d.Name(), got, tt.want)
+		}
+	}
+}
+
+func TestPartKeys(t *testing.T) {
+	if got := deck().PartKeys(); !slices.Equal(got, []string{"jlpt", "tags", "type"}) {
+		t.Fatalf("PartKeys = %v", got)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.Write(&buf, export.CSV, deck()); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"id", "japanese", "romaji", "english", "parts.jlpt", "parts.tags", "parts.type"},
+		{"1", "みず", "mizu", "water", "5", "", "noun"},
+		{"2", "おちゃ", "ocha", "tea, green", "", `["drink"]`, ""},
+		{"3", "すし", "sushi", "<b>sushi</b>", "", "", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("records = %q, want %q", records, want)
+	}
+}
+
+// TestRoundTrip checks that what is exported imports back as the same
+// words
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		export export.Format
+		read   importer.Format
+		// parts reports whether parts survive the format as they are;
+		// CSV and Anki keep them as text
+		parts bool
+	}{
+		{name: "json", export: export.JSON, read: importer.JSON, parts: true},
+		{name: "csv", export: export.CSV, read: importer.CSV},
+		{name: "apkg", export: export.APKG, read: importer.APKG},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := deck()
+			var buf bytes.Buffer
+			if err := export.Write(&buf, tt.export, d); err != nil {
+				t.Fatal(err)
+			}
+			file, err := importer.Read(&buf, tt.read, importer.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(file.Rows) != len(d.Words) {
+				t.Fatalf("read %d rows, want %d", len(file.Rows), len(d.Words))
+			}
+			for i, row := range file.Rows {
+				want := d.Words[i]
+				if row.Err != nil {
+					t.Fatalf("row %d: %v", row.Number, row.Err)
+				}
+				got := row.Word
+				if got.Japanese != want.Japanese || got.Romaji != want.Romaji || got.English != want.English {
+					t.Errorf("row %d = %s/%s/%s, want %s/%s/%s", row.Number,
+						got.Japanese, got.Romaji, got.English, want.Japanese, want.Romaji, want.English)
+				}
+				if tt.parts && !reflect.DeepEqual(got.Parts, want.Parts) {
+					t.Errorf("row %d parts = %v, want %v", row.Number, got.Parts, want.Parts)
+				}
+			}
+			if tt.export == export.JSON && (file.Group == nil || file.Group.Name != d.Group.Name || file.Group.Description != d.Group.Description) {
+				t.Errorf("group = %+v, want %+v", file.Group, d.Group)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// seedGroup and seedWord mirror the JSON seed files, so exported decks can
+// be added to the seed manifest as they are
+type seedGroup struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type seedWord struct {
+	Japanese string         `json:"japanese"`
+	Romaji   string         `json:"romaji"`
+	English  string         `json:"english"`
+	Parts    map[string]any `json:"parts"`
+}
+
+type seedFile struct {
+	Group *seedGroup `json:"group,omitempty"`
+	Words []seedWord `json:"words"`
+}
+
+// writeJSON writes the deck as a seed file. A deck without a group has no
+// group block.
+func writeJSON(w io.Writer, deck *Deck) error {
+	file := seedFile{Words: make([]seedWord, 0, len(deck.Words))}
+	if deck.Group != nil {
+		file.Group = &seedGroup{Name: deck.Group.Name, Description: deck.Group.Description}
+	}
+	for _, word := range deck.Words {
+		parts := word.Parts
+		if parts == nil {
+			parts = map[string]any{}
+		}
+		file.Words = append(file.Words, seedWord{
+			Japanese: word.Japanese,
+			Romaji:   word.Romaji,
+			English:  word.English,
+			Parts:    parts,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(file)
+}
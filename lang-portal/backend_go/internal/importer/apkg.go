@@ -0,0 +1,187 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// maxCollectionSize bounds the collection database unpacked from a package
+const maxCollectionSize = 256 << 20
+
+// breaks and tags match the HTML Anki stores in note fields
+var (
+	breaks = regexp.MustCompile(`(?i)<br\s*/?>|</div>|</p>`)
+	tags   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// fieldText turns the HTML of a note field into plain text
+func fieldText(value string) string {
+	value = breaks.ReplaceAllString(value, " ")
+	value = tags.ReplaceAllString(value, "")
+	return strings.Join(strings.Fields(html.UnescapeString(value)), " ")
+}
+
+// readAPKG reads the notes of an Anki package, one word each. Note fields
+// map to word fields by name; fields that are not mapped become parts.
+// Packages of Anki 2.1.50 and later must be exported with "Support older
+// Anki versions" ticked.
+func readAPKG(r io.Reader, opts Options) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an Anki package: %w", err)
+	}
+
+	entries := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		entries[f.Name] = f
+	}
+	// Newer packages keep their notes in collection.anki21b and leave a
+	// placeholder in the older collections
+	if entries["collection.anki21b"] != nil && entries["collection.anki21"] == nil {
+		return nil, errors.New(`the package uses a newer Anki format; export it with "Support older Anki versions" ticked`)
+	}
+	entry := cmp.Or(entries["collection.anki21"], entries["collection.anki2"])
+	if entry == nil {
+		return nil, errors.New("not an Anki package: no collection inside")
+	}
+
+	path, err := unpack(entry)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return readNotes(db, opts)
+}
+
+// unpack copies a zip entry to a temporary file and returns its path
+func unpack(entry *zip.File) (string, error) {
+	src, err := entry.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp("", "lang-portal-import-*.anki2")
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(tmp, io.LimitReader(src, maxCollectionSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > maxCollectionSize {
+		err = fmt.Errorf("the collection is larger than %d MiB", maxCollectionSize>>20)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// noteTypes returns the field names of each note type in a collection,
+// in field order
+func noteTypes(db *sql.DB) (map[int64][]string, error) {
+	var modelsJSON string
+	if err := db.QueryRow("SELECT models FROM col").Scan(&modelsJSON); err != nil {
+		return nil, fmt.Errorf("not an Anki collection: %w", err)
+	}
+	var models map[string]struct {
+		Flds []struct {
+			Name string `json:"name"`
+			Ord  int    `json:"ord"`
+		} `json:"flds"`
+	}
+	if err := json.Unmarshal([]byte(modelsJSON), &models); err != nil {
+		return nil, fmt.Errorf("invalid note types: %w", err)
+	}
+
+	types := make(map[int64][]string, len(models))
+	for id, model := range models {
+		mid, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid note type ID %q", id)
+		}
+		fields := model.Flds
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Ord < fields[j].Ord })
+		names := make([]string, len(fields))
+		for i, f := range fields {
+			names[i] = f.Name
+		}
+		types[mid] = names
+	}
+	return types, nil
+}
+
+// readNotes reads every note of a collection in the order they were added
+func readNotes(db *sql.DB, opts Options) (*File, error) {
+	types, err := noteTypes(db)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT mid, flds FROM notes ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("not an Anki collection: %w", err)
+	}
+	defer rows.Close()
+
+	file := &File{}
+	columns := make(map[int64]map[string]int)
+	for n := 1; rows.Next(); n++ {
+		var mid int64
+		var flds string
+		if err := rows.Scan(&mid, &flds); err != nil {
+			return nil, err
+		}
+
+		row := Row{Number: n}
+		names, ok := types[mid]
+		if !ok {
+			row.Err = fmt.Errorf("note type %d is missing from the collection", mid)
+			file.Rows = append(file.Rows, row)
+			continue
+		}
+		mapping, ok := columns[mid]
+		if !ok {
+			// Each note type maps its own fields; one that cannot be mapped
+			// fails the import
+			if mapping, err = columnMap(names, opts, true); err != nil {
+				return nil, err
+			}
+			columns[mid] = mapping
+		}
+
+		values := strings.Split(flds, "\x1f")
+		for i, value := range values {
+			values[i] = fieldText(value)
+		}
+		row.Word = wordFrom(values, mapping)
+		file.Rows = append(file.Rows, row)
+	}
+	return file, rows.Err()
+}
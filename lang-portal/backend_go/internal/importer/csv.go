@@ -0,0 +1,104 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// recordReader returns the next record of a file and the line it is on,
+// or io.EOF after the last one
+type recordReader func() ([]string, int, error)
+
+// csvRecords reads RFC 4180 CSV
+func csvRecords(r io.Reader) recordReader {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+	return func() ([]string, int, error) {
+		record, err := in.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, 0, fmt.Errorf("line %d: %w", parseErr.Line, parseErr.Err)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		line, _ := in.FieldPos(0)
+		return record, line, nil
+	}
+}
+
+// tsvRecords reads tab-separated lines. TSV has no quoting, so fields
+// cannot contain tabs or line breaks. Blank lines are skipped.
+func tsvRecords(r io.Reader) recordReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	line := 0
+	return func() ([]string, int, error) {
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSuffix(scanner.Text(), "\r")
+			if strings.TrimSpace(text) != "" {
+				return strings.Split(text, "\t"), line, nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, 0, err
+		}
+		return nil, 0, io.EOF
+	}
+}
+
+// readDelimited reads one word per record. Records with the wrong number
+// of fields are reported on their row.
+func readDelimited(next recordReader, opts Options) (*File, error) {
+	first, line, err := next()
+	if err == io.EOF {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Spreadsheets often start their exports with a byte order mark
+	first[0] = strings.TrimPrefix(first[0], "\ufeff")
+	names := first
+	if opts.NoHeader {
+		names = make([]string, len(first))
+		for i := range names {
+			names[i] = strconv.Itoa(i + 1)
+		}
+	}
+	columns, err := columnMap(names, opts, false)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{}
+	add := func(record []string, line int) {
+		row := Row{Number: line}
+		if len(record) != len(names) {
+			row.Err = fmt.Errorf("has %d fields, want %d", len(record), len(names))
+		} else {
+			row.Word = wordFrom(record, columns)
+		}
+		file.Rows = append(file.Rows, row)
+	}
+	if opts.NoHeader {
+		add(first, line)
+	}
+	for {
+		record, line, err := next()
+		if err == io.EOF {
+			return file, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		add(record, line)
+	}
+}
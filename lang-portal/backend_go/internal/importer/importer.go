@@ -0,0 +1,188 @@
+// Package importer reads vocabulary files into words: CSV and TSV with
+// mappable columns, the JSON seed files, and Anki packages. It only parses;
+// deciding what to create or update is up to the import service.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/models"
+)
+
+// Format is a file format words can be imported from
+type Format string
+
+// Supported formats
+const (
+	CSV  Format = "csv"
+	TSV  Format = "tsv"
+	JSON Format = "json"
+	APKG Format = "apkg"
+)
+
+// Formats lists every supported format
+var Formats = []Format{CSV, TSV, JSON, APKG}
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(name))
+	if !slices.Contains(Formats, format) {
+		return "", fmt.Errorf("unknown import format %q", name)
+	}
+	return format, nil
+}
+
+// wordFields are the word fields a column can be mapped to, besides
+// parts.<key>
+var wordFields = []string{"japanese", "romaji", "english"}
+
+// Options controls how a file is read
+type Options struct {
+	// Columns maps word fields (japanese, romaji, english or parts.<key>)
+	// to the column they are read from: a CSV or TSV header, an Anki note
+	// field name, or a 1-based column number. Fields left out are read from
+	// the column named after them.
+	Columns map[string]string
+	// NoHeader reads the first CSV or TSV record as a word. Columns must
+	// then be mapped by number.
+	NoHeader bool
+}
+
+// validate checks that every mapped field is a word field
+func (o Options) validate() error {
+	for field, column := range o.Columns {
+		key, isPart := strings.CutPrefix(field, "parts.")
+		if !slices.Contains(wordFields, field) && (!isPart || key == "") {
+			return fmt.Errorf("cannot map a column to %q: fields are %s or parts.<key>", field, strings.Join(wordFields, ", "))
+		}
+		if strings.TrimSpace(column) == "" {
+			return fmt.Errorf("no column given for %q", field)
+		}
+	}
+	return nil
+}
+
+// Group is the group block of a JSON seed file
+type Group struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Row is one word read from a file. Number is the line of a CSV or TSV
+// record, or the position of a JSON word or Anki note. Err is set when the
+// row could not be read into a word.
+type Row struct {
+	Number int
+	Word   models.Word
+	Err    error
+}
+
+// File is everything read from an import file. Only JSON seed files carry
+// a Group.
+type File struct {
+	Group *Group
+	Rows  []Row
+}
+
+// Read reads a file in a format. It fails when the file as a whole cannot be
+// read; problems with single words are reported on their rows.
+func Read(r io.Reader, f Format, opts Options) (*File, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	switch f {
+	case CSV:
+		return readDelimited(csvRecords(r), opts)
+	case TSV:
+		return readDelimited(tsvRecords(r), opts)
+	case JSON:
+		return readJSON(r)
+	case APKG:
+		return readAPKG(r, opts)
+	default:
+		return nil, fmt.Errorf("unknown import format %q", f)
+	}
+}
+
+// columnMap finds the column of each word field among names. Fields that
+// are not mapped use the column named after them; with all set, every
+// other column is read into parts under its own name.
+func columnMap(names []string, opts Options, all bool) (map[string]int, error) {
+	find := func(column string) int {
+		if n, err := strconv.Atoi(column); err == nil {
+			if n < 1 || n > len(names) {
+				return -1
+			}
+			return n - 1
+		}
+		for i, name := range names {
+			if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(column)) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	columns := make(map[string]int)
+	used := make(map[int]bool)
+	for field, column := range opts.Columns {
+		i := find(column)
+		if i < 0 {
+			return nil, fmt.Errorf("column %q mapped to %s does not exist", column, field)
+		}
+		columns[field] = i
+		used[i] = true
+	}
+	for _, field := range wordFields {
+		if _, ok := columns[field]; ok {
+			continue
+		}
+		if i := find(field); i >= 0 && !used[i] {
+			columns[field] = i
+			used[i] = true
+		}
+	}
+	for i, name := range names {
+		if used[i] {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if key, ok := strings.CutPrefix(name, "parts."); ok && key != "" {
+			columns[name] = i
+		} else if all && name != "" {
+			columns["parts."+name] = i
+		}
+	}
+	return columns, nil
+}
+
+// wordFrom builds a word from the values of a record
+func wordFrom(values []string, columns map[string]int) models.Word {
+	value := func(i int) string {
+		if i < len(values) {
+			return strings.TrimSpace(values[i])
+		}
+		return ""
+	}
+
+	word := models.Word{Parts: map[string]any{}}
+	for field, i := range columns {
+		switch field {
+		case "japanese":
+			word.Japanese = value(i)
+		case "romaji":
+			word.Romaji = value(i)
+		case "english":
+			word.English = value(i)
+		default:
+			if v := value(i); v != "" {
+				word.Parts[strings.TrimPrefix(field, "parts.")] = v
+			}
+		}
+	}
+	return word
+}
@@ -0,0 +1,247 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/erans/lang-portal/internal/models"
+)
+
+// word builds the word a row should hold
+func word(japanese, romaji, english string, parts map[string]any) models.Word {
+	if parts == nil {
+		parts = map[string]any{}
+	}
+	return models.Word{Japanese: japanese, Romaji: romaji, English: english, Parts: parts}
+}
+
+func TestReadDelimited(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+		opts   Options
+		want   []Row
+	}{
+		{
+			name:   "header names the fields",
+			format: CSV,
+			input:  "\ufeffenglish,japanese,romaji,parts.type\ncat,ねこ,neko,noun\n",
+			want:   []Row{{Number: 2, Word: word("ねこ", "neko", "cat", map[string]any{"type": "noun"})}},
+		},
+		{
+			name:   "columns mapped by name",
+			format: CSV,
+			input:  "Kana,Reading,Meaning,Notes\nいぬ,inu,dog,\n",
+			opts:   Options{Columns: map[string]string{"japanese": "kana", "romaji": "Reading", "english": "Meaning", "parts.notes": "Notes"}},
+			want:   []Row{{Number: 2, Word: word("いぬ", "inu", "dog", nil)}},
+		},
+		{
+			name:   "columns mapped by number without a header",
+			format: CSV,
+			input:  "dog,いぬ,inu\ncat,ねこ,neko\n",
+			opts:   Options{NoHeader: true, Columns: map[string]string{"english": "1", "japanese": "2", "romaji": "3"}},
+			want: []Row{
+				{Number: 1, Word: word("いぬ", "inu", "dog", nil)},
+				{Number: 2, Word: word("ねこ", "neko", "cat", nil)},
+			},
+		},
+		{
+			name:   "rows are numbered by line across quoted line breaks",
+			format: CSV,
+			input:  "japanese,romaji,english\nはな,hana,\"flower,\nblossom\"\nき,ki,tree\n",
+			want: []Row{
+				{Number: 2, Word: word("はな", "hana", "flower,\nblossom", nil)},
+				{Number: 4, Word: word("き", "ki", "tree", nil)},
+			},
+		},
+		{
+			name:   "short rows are reported on their row",
+			format: CSV,
+			input:  "japanese,romaji,english\nき,ki\n",
+			want:   []Row{{Number: 2}},
+		},
+		{
+			name:   "tsv skips blank lines",
+			format: TSV,
+			input:  "japanese\tromaji\tenglish\r\n\r\nやま\tyama\tmountain\r\n",
+			want:   []Row{{Number: 3, Word: word("やま", "yama", "mountain", nil)}},
+		},
+		{
+			name:   "empty file",
+			format: CSV,
+			input:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := Read(strings.NewReader(tt.input), tt.format, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(file.Rows) != len(tt.want) {
+				t.Fatalf("read %d rows, want %d: %+v", len(file.Rows), len(tt.want), file.Rows)
+			}
+			for i, got := range file.Rows {
+				want := tt.want[i]
+				if got.Number != want.Number {
+					t.Errorf("row %d is numbered %d, want %d", i, got.Number, want.Number)
+				}
+				if want.Word.Japanese == "" {
+					if got.Err == nil {
+						t.Errorf("row %d = %+v, want an error", got.Number, got.Word)
+					}
+					continue
+				}
+				if got.Err != nil || !reflect.DeepEqual(got.Word, want.Word) {
+					t.Errorf("row %d = %+v, %v, want %+v", got.Number, got.Word, got.Err, want.Word)
+				}
+			}
+		})
+	}
+}
+
+func TestReadRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		input   string
+		opts    Options
+		wantErr string
+	}{
+		{name: "mapping to an unknown field", format: CSV, input: "a\n", opts: Options{Columns: map[string]string{"kanji": "a"}}, wantErr: `cannot map a column to "kanji"`},
+		{name: "mapping to empty parts", format: CSV, input: "a\n", opts: Options{Columns: map[string]string{"parts.": "a"}}, wantErr: `cannot map a column to "parts."`},
+		{name: "mapping without a column", format: CSV, input: "a\n", opts: Options{Columns: map[string]string{"english": " "}}, wantErr: `no column given for "english"`},
+		{name: "mapped column missing", format: CSV, input: "a,b\n", opts: Options{Columns: map[string]string{"english": "c"}}, wantErr: `column "c" mapped to english does not exist`},
+		{name: "column number out of range", format: CSV, input: "a,b\n", opts: Options{NoHeader: true, Columns: map[string]string{"english": "3"}}, wantErr: `column "3"`},
+		{name: "bad CSV quoting", format: CSV, input: "japanese\n\"ねこ\n", wantErr: "line 2"},
+		{name: "not an Anki package", format: APKG, input: "japanese,english\n", wantErr: "not an Anki package"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.input), tt.format, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeSeed(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantGroup *Group
+		wantLines []int
+		// wantErrs lists the lines of the words that are not words
+		wantErrs []int
+	}{
+		{
+			name:      "bare array",
+			input:     "[\n  {\"japanese\": \"ねこ\", \"romaji\": \"neko\", \"english\": \"cat\"},\n  {\"japanese\": \"いぬ\", \"romaji\": \"inu\", \"english\": \"dog\"}\n]\n",
+			wantLines: []int{2, 3},
+		},
+		{
+			name: "object with a group",
+			input: `{
+  "group": {"name": "Animals", "description": "Pets"},
+  "words": [
+    {
+      "japanese": "ねこ", "romaji": "neko", "english": "cat",
+      "parts": {"type": "noun"}
+    }
+  ]
+}`,
+			wantGroup: &Group{Name: "Animals", Description: "Pets"},
+			wantLines: []int{4},
+		},
+		{
+			name:      "entries that are not words",
+			input:     "[\n  {\"japanese\": \"ねこ\", \"kanji\": \"猫\"},\n  {\"japanese\": 5},\n  {\"japanese\": \"いぬ\"}\n]",
+			wantLines: []int{2, 3, 4},
+			wantErrs:  []int{2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seed, err := DecodeSeed([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(seed.Group, tt.wantGroup) {
+				t.Errorf("group = %+v, want %+v", seed.Group, tt.wantGroup)
+			}
+			var lines, errs []int
+			for _, w := range seed.Words {
+				lines = append(lines, w.Line)
+				if w.Err != nil {
+					errs = append(errs, w.Line)
+				} else if w.Word.Parts == nil {
+					t.Errorf("word on line %d has nil parts", w.Line)
+				}
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) || !reflect.DeepEqual(errs, tt.wantErrs) {
+				t.Errorf("word lines = %v with errors on %v, want %v with errors on %v", lines, errs, tt.wantLines, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestDecodeSeedRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantLine int
+		wantMsg  string
+	}{
+		{name: "not JSON", input: "words:", wantLine: 1, wantMsg: "invalid JSON"},
+		{name: "not an object or array", input: `"words"`, wantLine: 1, wantMsg: "must contain a JSON object or array"},
+		{name: "missing words", input: `{"group": {"name": "Animals"}}`, wantMsg: `missing "words" array`},
+		{name: "words not an array", input: "{\n  \"words\": {}\n}", wantLine: 2, wantMsg: `"words" must be an array`},
+		{name: "unknown key", input: "{\n  \"words\": [],\n  \"lessons\": []\n}", wantLine: 3, wantMsg: `unknown key "lessons"`},
+		{name: "invalid group", input: "{\n  \"group\": \"Animals\",\n  \"words\": []\n}", wantLine: 2, wantMsg: "invalid group"},
+		{name: "syntax error in a word", input: "[\n  {\"japanese\": \"ねこ\"},\n  {\"japanese\" \"いぬ\"}\n]", wantLine: 3, wantMsg: "invalid word"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeSeed([]byte(tt.input))
+			seedErr, ok := err.(*SeedError)
+			if !ok {
+				t.Fatalf("error = %v, want a *SeedError", err)
+			}
+			if seedErr.Line != tt.wantLine || !strings.Contains(seedErr.Msg, tt.wantMsg) {
+				t.Fatalf("error = %v, want %q on line %d", err, tt.wantMsg, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestReadJSONNumbersRowsByPosition(t *testing.T) {
+	input := "{\"words\": [\n  {\"japanese\": \"ねこ\", \"romaji\": \"neko\", \"english\": \"cat\"},\n  {\"english\": \"dog\", \"legs\": 4}\n]}"
+	file, err := Read(strings.NewReader(input), JSON, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Rows) != 2 || file.Rows[0].Number != 1 || file.Rows[1].Number != 2 {
+		t.Fatalf("rows = %+v, want rows 1 and 2", file.Rows)
+	}
+	if file.Rows[0].Err != nil || file.Rows[1].Err == nil {
+		t.Fatalf("row errors = %v, %v, want only the second row to fail", file.Rows[0].Err, file.Rows[1].Err)
+	}
+}
+
+func TestFieldText(t *testing.T) {
+	tests := map[string]string{
+		"plain":                          "plain",
+		"<b>bold</b> &amp; <i>it</i>":    "bold & it",
+		"line<br>break<br/>again<br />x": "line break again x",
+		"<div>one</div><div>two</div>":   "one two",
+		"  spaced \n out  ":              "spaced out",
+	}
+	for in, want := range tests {
+		if got := fieldText(in); got != want {
+			t.Errorf("fieldText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package importer
+
+import "io"
+
+// readJSON reads a seed file. Words that do not decode are reported on
+// their row, numbered by their position in the words array.
+func readJSON(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := DecodeSeed(data)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{Group: seed.Group}
+	for i, word := range seed.Words {
+		file.Rows = append(file.Rows, Row{Number: i + 1, Word: word.Word, Err: word.Err})
+	}
+	return file, nil
+}
@@ -0,0 +1,165 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/models"
+)
+
+// Seed is a decoded JSON seed file, the format of db/seeds and of JSON
+// exports
+type Seed struct {
+	Group *Group
+	Words []SeedWord
+}
+
+// SeedWord is one entry of a seed file's words. Line is the line the entry
+// starts on; Err is set when the entry is not a word.
+type SeedWord struct {
+	Word models.Word
+	Line int
+	Err  error
+}
+
+// seedWord is the JSON form of a seed word
+type seedWord struct {
+	Japanese string         `json:"japanese"`
+	Romaji   string         `json:"romaji"`
+	English  string         `json:"english"`
+	Parts    map[string]any `json:"parts"`
+}
+
+// SeedError reports a seed file that cannot be decoded at all
+type SeedError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SeedError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return e.Msg
+}
+
+// DecodeSeed decodes a seed file: an object with an optional "group" and a
+// required "words" array, or a bare array of words. Entries that are not
+// words, such as ones with unknown fields, are reported on their SeedWord;
+// anything else wrong with the file is a *SeedError.
+func DecodeSeed(data []byte) (*Seed, error) {
+	fail := func(line int, format string, args ...any) (*Seed, error) {
+		return nil, &SeedError{Line: line, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	seed := &Seed{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return fail(lineAt(data, dec.InputOffset()), "invalid JSON: %v", err)
+	}
+
+	switch tok {
+	case json.Delim('['):
+		if err := decodeSeedWords(dec, data, seed); err != nil {
+			return nil, err
+		}
+	case json.Delim('{'):
+		foundWords := false
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return fail(lineAt(data, dec.InputOffset()), "invalid JSON: %v", err)
+			}
+			key := keyTok.(string)
+			line := lineAt(data, dec.InputOffset())
+
+			switch key {
+			case "group":
+				var group Group
+				if err := dec.Decode(&group); err != nil {
+					return fail(line, "invalid group: %v", err)
+				}
+				seed.Group = &group
+			case "words":
+				tok, err := dec.Token()
+				if err != nil || tok != json.Delim('[') {
+					return fail(line, `"words" must be an array`)
+				}
+				if err := decodeSeedWords(dec, data, seed); err != nil {
+					return nil, err
+				}
+				foundWords = true
+			default:
+				return fail(line, "unknown key %q", key)
+			}
+		}
+		if !foundWords {
+			return fail(0, `missing "words" array`)
+		}
+	default:
+		return fail(1, "seed file must contain a JSON object or array")
+	}
+
+	return seed, nil
+}
+
+// decodeSeedWords decodes the elements of a words array, recording the
+// line each word starts on. The opening bracket must already be consumed.
+func decodeSeedWords(dec *json.Decoder, data []byte, seed *Seed) *SeedError {
+	for dec.More() {
+		line := lineAt(data, dec.InputOffset())
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				line = lineAt(data, syntaxErr.Offset)
+			}
+			return &SeedError{Line: line, Msg: fmt.Sprintf("invalid word: %v", err)}
+		}
+		seed.Words = append(seed.Words, decodeSeedWord(raw, line))
+	}
+
+	// Consume the closing bracket
+	if _, err := dec.Token(); err != nil && err != io.EOF {
+		return &SeedError{Line: lineAt(data, dec.InputOffset()), Msg: fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	return nil
+}
+
+// decodeSeedWord decodes one entry of a words array. Words without parts
+// get an empty map.
+func decodeSeedWord(raw json.RawMessage, line int) SeedWord {
+	var word seedWord
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&word); err != nil {
+		return SeedWord{Line: line, Err: fmt.Errorf("invalid word: %w", err)}
+	}
+	if word.Parts == nil {
+		word.Parts = map[string]any{}
+	}
+	return SeedWord{
+		Word: models.Word{Japanese: word.Japanese, Romaji: word.Romaji, English: word.English, Parts: word.Parts},
+		Line: line,
+	}
+}
+
+// lineAt returns the 1-based line of the first significant character at or
+// after offset, skipping whitespace and separators
+func lineAt(data []byte, offset int64) int {
+	i := int(offset)
+	for i < len(data) && strings.ContainsRune(" \t\r\n,:", rune(data[i])) {
+		i++
+	}
+	if i > len(data) {
+		i = len(data)
+	}
+	return bytes.Count(data[:i], []byte("\n")) + 1
+}
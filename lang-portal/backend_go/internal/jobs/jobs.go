@@ -0,0 +1,79 @@
+// Package jobs runs background work alongside the server. Each job runs
+// once when the runner starts and then on its interval until the runner's
+// context is cancelled.
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Clock tells the time. Jobs read it instead of calling time.Now so they
+// can be run against a controlled clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock of the machine
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Job is a unit of background work
+type Job struct {
+	Name string
+	// Interval is the time between the end of one run and the start of
+	// the next
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Runner runs jobs on their intervals
+type Runner struct {
+	jobs []Job
+}
+
+// NewRunner creates a Runner for jobs
+func NewRunner(jobs ...Job) *Runner {
+	return &Runner{jobs: jobs}
+}
+
+// Run runs every job until ctx is cancelled and returns once the last run
+// in progress has finished. A failed run is logged and retried on the next
+// interval.
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range r.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loop(ctx, job)
+		}()
+	}
+	wg.Wait()
+}
+
+// loop runs job now and after each interval until ctx is cancelled
+func loop(ctx context.Context, job Job) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if err := job.Run(ctx); err != nil {
+			log.Printf("job %s failed: %v", job.Name, err)
+		}
+		timer.Reset(job.Interval)
+	}
+}
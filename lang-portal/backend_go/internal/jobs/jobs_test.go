@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// wait bounds every wait so a broken runner fails instead of hanging
+const wait = 5 * time.Second
+
+func TestRunnerRunsJobsRightAwayAndOnTheirInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var slowRuns atomic.Int32
+	fast := make(chan struct{}, 10)
+	runner := NewRunner(
+		Job{Name: "fast", Interval: time.Millisecond, Run: func(context.Context) error {
+			select {
+			case fast <- struct{}{}:
+			default:
+			}
+			return nil
+		}},
+		Job{Name: "slow", Interval: time.Hour, Run: func(context.Context) error {
+			slowRuns.Add(1)
+			return nil
+		}},
+	)
+
+	done := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(done)
+	}()
+
+	for range 3 {
+		select {
+		case <-fast:
+		case <-time.After(wait):
+			t.Fatal("the fast job did not run again")
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(wait):
+		t.Fatal("Run did not return after its context was cancelled")
+	}
+	if n := slowRuns.Load(); n != 1 {
+		t.Fatalf("the slow job ran %d times, want once at start", n)
+	}
+}
+
+func TestRunnerRetriesFailedRuns(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs atomic.Int32
+	retried := make(chan struct{})
+	runner := NewRunner(Job{Name: "flaky", Interval: time.Millisecond, Run: func(context.Context) error {
+		if runs.Add(1) == 2 {
+			close(retried)
+		}
+		return errors.New("database is locked")
+	}})
+	go runner.Run(ctx)
+
+	select {
+	case <-retried:
+	case <-time.After(wait):
+		t.Fatal("a failed job was not run again")
+	}
+}
+
+func TestRunnerWaitsForTheRunInProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	var finished atomic.Bool
+	runner := NewRunner(Job{Name: "long", Interval: time.Hour, Run: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	}})
+
+	done := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(wait):
+		t.Fatal("the job never started")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(wait):
+		t.Fatal("Run did not return")
+	}
+	if !finished.Load() {
+		t.Fatal("Run returned before the run in progress finished")
+	}
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/erans/lang-portal/internal/service"
+)
+
+// SessionReaper returns a job that abandons study sessions left open for
+// longer than timeout, e.g. by a study app that crashed, so they stop
+// counting towards study time and streaks
+func SessionReaper(sessions *service.StudySessionService, timeout, interval time.Duration, clock Clock) Job {
+	return Job{
+		Name:     "session-reaper",
+		Interval: interval,
+		Run: func(context.Context) error {
+			abandoned, err := sessions.AbandonIdle(clock.Now(), timeout)
+			for _, session := range abandoned {
+				log.Printf("session-reaper: abandoned study session %d of user %d, idle since %s",
+					session.ID, session.UserID, session.EndTime.Format(time.RFC3339))
+			}
+			if len(abandoned) > 0 {
+				log.Printf("session-reaper: abandoned %d idle sessions", len(abandoned))
+			}
+			return err
+		},
+	}
+}
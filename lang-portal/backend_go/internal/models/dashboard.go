@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// LastSession represents the last study session details
+type LastSession struct {
+	SessionID      int64      `json:"session_id"`
+	StartTime      time.Time  `json:"start_time"`
+	EndTime        *time.Time `json:"end_time,omitempty"`
+	Score          *float64   `json:"score"`
+	Status         string     `json:"status"`
+	ActivityType   string     `json:"activity_type"`
+	GroupID        int64      `json:"group_id"`
+	GroupName      string     `json:"group_name"`
+	WordsReviewed  int        `json:"words_reviewed"`
+	CorrectAnswers int        `json:"correct_answers"`
+}
+
+// StudyStats represents study statistics
+type StudyStats struct {
+	TotalStudyTime     int     `json:"total_study_time"` // in seconds
+	SessionsCompleted  int     `json:"sessions_completed"`
+	TotalWordsReviewed int     `json:"total_words_reviewed"`
+	SuccessRate        float64 `json:"success_rate"`
+	StudyStreakDays    int     `json:"study_streak_days"`
+}
+
+// StudyProgress represents learning progress
+type StudyProgress struct {
+	OverallCompletion   float64 `json:"overall_completion"`
+	TotalWordsStudied   int     `json:"total_words_studied"`
+	TotalAvailableWords int     `json:"total_available_words"`
+}
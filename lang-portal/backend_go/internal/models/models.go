@@ -4,20 +4,96 @@ import (
 	"time"
 )
 
+// DefaultUserID is the user that owns study history recorded before
+// accounts existed, and that anonymous requests act as
+const DefaultUserID int64 = 1
+
+// Roles decide what a user may change. Learners study; teachers also
+// curate the vocabulary and run maintenance; admins also manage users.
+const (
+	RoleLearner = "learner"
+	RoleTeacher = "teacher"
+	RoleAdmin   = "admin"
+)
+
+// User is a learner with their own sessions, reviews and schedules. Role
+// defaults to learner when a user is created.
+type User struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name" binding:"notblank,max=100"`
+	Role      string    `json:"role" binding:"omitempty,oneof=learner teacher admin"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SortKey returns the key of the user for a sort field
+func (u User) SortKey(field string) (any, int64) {
+	if field == "name" {
+		return u.Name, u.ID
+	}
+	return u.ID, u.ID
+}
+
+// APIToken authenticates requests for a user. Only a hash of the secret is
+// stored; the secret itself is shown once, when the token is issued.
+type APIToken struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	Name      string     `json:"name"`
+	Hash      string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
 // Word represents a vocabulary word in the system
 type Word struct {
 	ID       int64          `json:"id"`
-	Japanese string         `json:"japanese"`
-	Romaji   string         `json:"romaji"`
-	English  string         `json:"english"`
+	Japanese string         `json:"japanese" binding:"notblank"`
+	Romaji   string         `json:"romaji" binding:"notblank,romaji"`
+	English  string         `json:"english" binding:"notblank"`
 	Parts    map[string]any `json:"parts"`
 }
 
+// SortKey returns the key of the word for a sort field
+func (w Word) SortKey(field string) (any, int64) {
+	switch field {
+	case "japanese":
+		return w.Japanese, w.ID
+	case "romaji":
+		return w.Romaji, w.ID
+	case "english":
+		return w.English, w.ID
+	}
+	return w.ID, w.ID
+}
+
+// WordStats holds a word's review statistics and group membership
+type WordStats struct {
+	CorrectCount int64    `json:"correct_count"`
+	WrongCount   int64    `json:"wrong_count"`
+	SuccessRate  float64  `json:"success_rate"`
+	Groups       []string `json:"groups"`
+}
+
+// WordWithStats is a word together with its review statistics
+type WordWithStats struct {
+	Word
+	WordStats
+}
+
 // Group represents a collection of words
 type Group struct {
 	ID          int64  `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string `json:"name" binding:"notblank,max=100"`
+	Description string `json:"description" binding:"max=500"`
+	WordCount   int64  `json:"word_count"`
+}
+
+// SortKey returns the key of the group for a sort field
+func (g Group) SortKey(field string) (any, int64) {
+	if field == "name" {
+		return g.Name, g.ID
+	}
+	return g.ID, g.ID
 }
 
 // WordGroup represents the many-to-many relationship between words and groups
@@ -27,39 +103,158 @@ type WordGroup struct {
 	GroupID int64 `json:"group_id"`
 }
 
-// StudySession represents a learning session
+// GroupMembership describes the outcome of a change to a group's words
+type GroupMembership struct {
+	GroupID   int64   `json:"group_id"`
+	WordIDs   []int64 `json:"word_ids"`
+	WordCount int64   `json:"word_count"`
+}
+
+// Study session statuses. Sessions start active and are closed once, as
+// completed or abandoned.
+const (
+	SessionActive    = "active"
+	SessionCompleted = "completed"
+	SessionAbandoned = "abandoned"
+)
+
+// StudySession represents a learning session. UserID, the times, the score
+// and the status are set by the server: UserID to the learner the request
+// acts for, the rest as the session moves through its statuses.
 type StudySession struct {
-	ID              int64      `json:"id"`
-	StartTime       time.Time  `json:"start_time"`
-	EndTime         *time.Time `json:"end_time,omitempty"`
-	Score           *float64   `json:"score,omitempty"`
-	Status          string     `json:"status"` // active, completed, abandoned
-	StudyActivityID int64      `json:"study_activity_id"`
+	ID              int64           `json:"id"`
+	StartTime       time.Time       `json:"start_time"`
+	EndTime         *time.Time      `json:"end_time,omitempty"`
+	Score           *float64        `json:"score,omitempty"`
+	ScoreBreakdown  *ScoreBreakdown `json:"score_breakdown,omitempty"`
+	Status          string          `json:"status"`
+	StudyActivityID int64           `json:"study_activity_id" binding:"required,exists=study_activities"`
+	UserID          int64           `json:"user_id"`
 }
 
-// StudyActivity represents a study activity type
+// SortKey returns the key of the session for a sort field
+func (s StudySession) SortKey(field string) (any, int64) {
+	if field == "start_time" {
+		return s.StartTime, s.ID
+	}
+	return s.ID, s.ID
+}
+
+// ScoreBreakdown records how a completed session was scored. Each answer
+// earns up to MaxPoints, as weighed by the strategy of the session's
+// activity type, and the score is Points as a percentage of MaxPoints.
+type ScoreBreakdown struct {
+	Strategy  string         `json:"strategy"`
+	Answers   int            `json:"answers"`
+	Correct   int            `json:"correct"`
+	Accuracy  float64        `json:"accuracy"`
+	Points    float64        `json:"points"`
+	MaxPoints float64        `json:"max_points"`
+	Items     []ScoredAnswer `json:"items"`
+}
+
+// ScoredAnswer is one review answer as a scoring strategy saw it.
+// Difficulty is the share of the learner's answers for the word in other
+// sessions that were wrong, smoothed so that an unseen word is 0.5 and
+// only long histories approach 0 or 1. ResponseSeconds is the time since
+// the previous answer, or since the session started.
+type ScoredAnswer struct {
+	ReviewItemID    int64   `json:"review_item_id"`
+	WordID          int64   `json:"word_id"`
+	Correct         bool    `json:"correct"`
+	Difficulty      float64 `json:"difficulty"`
+	ResponseSeconds float64 `json:"response_seconds"`
+	Points          float64 `json:"points"`
+	MaxPoints       float64 `json:"max_points"`
+}
+
+// StudyActivity represents a study activity type. Activities launched from
+// the catalog link to their app, whose name and thumbnail are included
+// when read.
 type StudyActivity struct {
 	ID           int64     `json:"id"`
-	GroupID      int64     `json:"group_id"`
-	ActivityType string    `json:"activity_type"`
+	GroupID      int64     `json:"group_id" binding:"required,exists=groups"`
+	ActivityType string    `json:"activity_type" binding:"required,activity_type"`
+	AppID        *int64    `json:"app_id" binding:"omitempty,exists=activity_apps"`
+	Name         string    `json:"name,omitempty"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SortKey returns the key of the activity for a sort field
+func (a StudyActivity) SortKey(field string) (any, int64) {
+	if field == "created_at" {
+		return a.CreatedAt, a.ID
+	}
+	return a.ID, a.ID
+}
+
+// ActivityApp is a learning app in the activity catalog. LaunchURL is a
+// template whose {placeholders} are filled in for each launch.
+type ActivityApp struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name" binding:"notblank,max=100"`
+	ActivityType string    `json:"activity_type" binding:"required,activity_type"`
+	ThumbnailURL string    `json:"thumbnail_url" binding:"omitempty,url"`
+	LaunchURL    string    `json:"launch_url" binding:"required,launch_url"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+// SortKey returns the key of the app for a sort field
+func (a ActivityApp) SortKey(field string) (any, int64) {
+	if field == "name" {
+		return a.Name, a.ID
+	}
+	return a.ID, a.ID
+}
+
+// ActivityLaunch records a user opening an app for a group, with the
+// session it started and the URL they were sent to
+type ActivityLaunch struct {
+	ID         int64     `json:"id"`
+	AppID      int64     `json:"app_id"`
+	UserID     int64     `json:"user_id"`
+	GroupID    int64     `json:"group_id"`
+	SessionID  int64     `json:"session_id"`
+	LaunchURL  string    `json:"launch_url"`
+	LaunchedAt time.Time `json:"launched_at"`
+}
+
+// SortKey returns the key of the launch for a sort field
+func (l ActivityLaunch) SortKey(field string) (any, int64) {
+	if field == "launched_at" {
+		return l.LaunchedAt, l.ID
+	}
+	return l.ID, l.ID
+}
+
 // WordReviewItem represents a single word review instance
 type WordReviewItem struct {
 	ID         int64     `json:"id"`
 	SessionID  int64     `json:"session_id"`
 	WordID     int64     `json:"word_id"`
 	IsCorrect  bool      `json:"is_correct"`
+	Response   string    `json:"response"`
 	ReviewedAt time.Time `json:"reviewed_at"`
 }
 
+// SortKey returns the key of the answer for a sort field
+func (i WordReviewItem) SortKey(field string) (any, int64) {
+	if field == "reviewed_at" {
+		return i.ReviewedAt, i.ID
+	}
+	return i.ID, i.ID
+}
+
 // Pagination represents pagination parameters and metadata
 type Pagination struct {
-	CurrentPage  int   `json:"current_page"`
+	// CurrentPage is left out for pages requested by cursor
+	CurrentPage  int   `json:"current_page,omitempty"`
 	TotalPages   int   `json:"total_pages"`
 	TotalItems   int64 `json:"total_items"`
 	ItemsPerPage int   `json:"items_per_page"`
+	// NextCursor continues the listing; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // PaginatedResponse represents a paginated API response
@@ -73,3 +268,26 @@ type ListResult struct {
 	Items      interface{}
 	TotalItems int64
 }
+
+// ReviewSchedule holds the spaced-repetition state of a word
+type ReviewSchedule struct {
+	UserID         int64     `json:"user_id"`
+	WordID         int64     `json:"word_id"`
+	EaseFactor     float64   `json:"ease_factor"`
+	IntervalDays   int       `json:"interval_days"`
+	Repetitions    int       `json:"repetitions"`
+	DueAt          time.Time `json:"due_at"`
+	LastReviewedAt time.Time `json:"last_reviewed_at"`
+}
+
+// DueWord is a word that is due for review along with its schedule.
+// Words that have never been reviewed are new and have no schedule yet.
+type DueWord struct {
+	Word
+	IsNew          bool       `json:"is_new"`
+	EaseFactor     float64    `json:"ease_factor"`
+	IntervalDays   int        `json:"interval_days"`
+	Repetitions    int        `json:"repetitions"`
+	DueAt          *time.Time `json:"due_at,omitempty"`
+	LastReviewedAt *time.Time `json:"last_reviewed_at,omitempty"`
+}
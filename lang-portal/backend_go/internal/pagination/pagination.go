@@ -0,0 +1,249 @@
+// Package pagination describes the pages of list endpoints. A page starts
+// either at an offset or after a keyset cursor. A cursor marks the last
+// item of the previous page by its sort key and ID, so following cursors
+// neither skips nor repeats items while the listing changes underneath.
+package pagination
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSort is returned for a sort on a field the listing does not
+	// support
+	ErrInvalidSort = errors.New("invalid sort")
+	// ErrInvalidCursor is returned for a cursor that is malformed or was
+	// made for another order
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Kind is the type of a sort field's keys
+type Kind int
+
+// Sort field kinds
+const (
+	Int Kind = iota
+	String
+	Time
+)
+
+// Sort orders a listing by a field. Ties are broken by ID in the same
+// direction, so the order is total.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// String returns the sort as a query parameter: the field, prefixed with
+// "-" when descending
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Spec lists the fields a listing can be sorted by and its default order
+type Spec struct {
+	Fields  map[string]Kind
+	Default Sort
+}
+
+// Names returns the sortable fields, sorted
+func (s Spec) Names() []string {
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ParseSort reads a sort such as "name" or "-start_time". An empty string
+// is the default order.
+func (s Spec) ParseSort(raw string) (Sort, error) {
+	if raw == "" {
+		return s.Default, nil
+	}
+	sort := Sort{Field: strings.TrimPrefix(raw, "-"), Desc: strings.HasPrefix(raw, "-")}
+	if _, ok := s.Fields[sort.Field]; !ok {
+		return Sort{}, fmt.Errorf("%w: use one of %s, prefixed with - for descending order",
+			ErrInvalidSort, strings.Join(s.Names(), ", "))
+	}
+	return sort, nil
+}
+
+// Cursor marks the last item of a page by its sort key and ID
+type Cursor struct {
+	Sort Sort
+	Key  any
+	ID   int64
+}
+
+// cursorJSON is the encoded form of a Cursor. Time keys are RFC 3339
+// strings.
+type cursorJSON struct {
+	Sort string `json:"s"`
+	Key  any    `json:"k"`
+	ID   int64  `json:"id"`
+}
+
+// Encode returns the cursor as an opaque URL-safe token
+func (c *Cursor) Encode() string {
+	key := c.Key
+	if t, ok := key.(time.Time); ok {
+		key = t.Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(cursorJSON{Sort: c.Sort.String(), Key: key, ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseCursor decodes a token made by Encode for this listing
+func (s Spec) ParseCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var raw struct {
+		Sort string          `json:"s"`
+		Key  json.RawMessage `json:"k"`
+		ID   int64           `json:"id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sort, err := s.ParseSort(raw.Sort)
+	if err != nil || raw.Sort == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{Sort: sort, ID: raw.ID}
+	switch s.Fields[sort.Field] {
+	case Int:
+		var key int64
+		err = json.Unmarshal(raw.Key, &key)
+		cursor.Key = key
+	case String:
+		var key string
+		err = json.Unmarshal(raw.Key, &key)
+		cursor.Key = key
+	case Time:
+		var key time.Time
+		err = json.Unmarshal(raw.Key, &key)
+		cursor.Key = key
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// Request asks for a page of a listing: Limit items in Sort order, starting
+// after the After cursor or, without one, at Offset
+type Request struct {
+	Sort   Sort
+	Limit  int
+	Offset int
+	After  *Cursor
+}
+
+// Keyed is implemented by the items of a listing. SortKey returns the key
+// of the item for a sort field, as an int64, string or time.Time, and the
+// item's ID.
+type Keyed interface {
+	SortKey(field string) (key any, id int64)
+}
+
+// Page is one page of a listing
+type Page[T any] struct {
+	Items []T
+	// Total is the number of items over every page
+	Total int64
+	// Next continues the listing after this page; nil on the last page
+	Next *Cursor
+}
+
+// NewPage returns the page of a request from the items read for it. Stores
+// read up to Limit+1 items: the extra one only tells that another page
+// follows.
+func NewPage[T Keyed](req Request, items []T, total int64) *Page[T] {
+	page := &Page[T]{Items: items, Total: total}
+	if len(items) > req.Limit {
+		page.Items = items[:req.Limit]
+		key, id := page.Items[req.Limit-1].SortKey(req.Sort.Field)
+		page.Next = &Cursor{Sort: req.Sort, Key: key, ID: id}
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
+
+// Map returns a page with the same position whose items are converted by
+// fn
+func Map[T, U any](page *Page[T], fn func([]T) ([]U, error)) (*Page[U], error) {
+	items, err := fn(page.Items)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []U{}
+	}
+	return &Page[U]{Items: items, Total: page.Total, Next: page.Next}, nil
+}
+
+// Compare orders two sort keys of the same kind
+func Compare(a, b any) int {
+	switch a := a.(type) {
+	case int64:
+		return cmp.Compare(a, b.(int64))
+	case string:
+		return cmp.Compare(a, b.(string))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	panic(fmt.Sprintf("pagination: unsupported sort key %T", a))
+}
+
+// compareItems orders two items by a sort
+func compareItems[T Keyed](sort Sort, a, b T) int {
+	aKey, aID := a.SortKey(sort.Field)
+	bKey, bID := b.SortKey(sort.Field)
+	c := cmp.Or(Compare(aKey, bKey), cmp.Compare(aID, bID))
+	if sort.Desc {
+		return -c
+	}
+	return c
+}
+
+// Slice returns the page of a request from every item of a listing, for
+// stores that list in memory
+func Slice[T Keyed](req Request, items []T) *Page[T] {
+	sorted := slices.Clone(items)
+	slices.SortFunc(sorted, func(a, b T) int { return compareItems(req.Sort, a, b) })
+
+	start := min(req.Offset, len(sorted))
+	if req.After != nil {
+		start = len(sorted)
+		for i, item := range sorted {
+			key, id := item.SortKey(req.Sort.Field)
+			c := cmp.Or(Compare(key, req.After.Key), cmp.Compare(id, req.After.ID))
+			if req.Sort.Desc {
+				c = -c
+			}
+			if c > 0 {
+				start = i
+				break
+			}
+		}
+	}
+	end := min(start+req.Limit+1, len(sorted))
+	return NewPage(req, slices.Clone(sorted[start:end]), int64(len(sorted)))
+}
@@ -0,0 +1,233 @@
+package pagination
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// item is a Keyed listing item with a name and a creation time
+type item struct {
+	id      int64
+	name    string
+	created time.Time
+}
+
+func (i item) SortKey(field string) (any, int64) {
+	switch field {
+	case "name":
+		return i.name, i.id
+	case "created_at":
+		return i.created, i.id
+	}
+	return i.id, i.id
+}
+
+var spec = Spec{
+	Fields:  map[string]Kind{"id": Int, "name": String, "created_at": Time},
+	Default: Sort{Field: "id"},
+}
+
+var base = time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+
+// items has duplicate names and times so ties are broken by ID
+var items = []item{
+	{id: 1, name: "b", created: base.Add(2 * time.Hour)},
+	{id: 2, name: "a", created: base},
+	{id: 3, name: "b", created: base.Add(time.Hour)},
+	{id: 4, name: "c", created: base},
+	{id: 5, name: "a", created: base.Add(time.Hour)},
+}
+
+func ids(page *Page[item]) []int64 {
+	var ids []int64
+	for _, i := range page.Items {
+		ids = append(ids, i.id)
+	}
+	return ids
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Sort
+		wantErr bool
+	}{
+		{raw: "", want: Sort{Field: "id"}},
+		{raw: "name", want: Sort{Field: "name"}},
+		{raw: "-created_at", want: Sort{Field: "created_at", Desc: true}},
+		{raw: "english", wantErr: true},
+		{raw: "--name", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := spec.ParseSort(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSort) {
+					t.Fatalf("error = %v, want ErrInvalidSort", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("ParseSort(%q) = %+v, %v, want %+v", tt.raw, got, err, tt.want)
+			}
+			if tt.raw != "" && got.String() != tt.raw {
+				t.Errorf("String() = %q, want %q", got.String(), tt.raw)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{Sort: Sort{Field: "id"}, Key: int64(42), ID: 42},
+		{Sort: Sort{Field: "name", Desc: true}, Key: "ねこ", ID: 7},
+		{Sort: Sort{Field: "created_at"}, Key: base, ID: 3},
+	}
+	for _, want := range tests {
+		t.Run(want.Sort.String(), func(t *testing.T) {
+			got, err := spec.ParseCursor(want.Encode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Sort != want.Sort || got.ID != want.ID || Compare(got.Key, want.Key) != 0 {
+				t.Fatalf("cursor = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseCursorRejects(t *testing.T) {
+	other := Spec{Fields: map[string]Kind{"title": String}, Default: Sort{Field: "title"}}
+	tests := map[string]string{
+		"not base64":          "%%%",
+		"not JSON":            "bm90IGpzb24",
+		"no sort":             (&Cursor{Key: int64(1), ID: 1}).Encode(),
+		"another listing":     (&Cursor{Sort: other.Default, Key: "x", ID: 1}).Encode(),
+		"key of wrong kind":   (&Cursor{Sort: Sort{Field: "id"}, Key: "one", ID: 1}).Encode(),
+		"time key not a time": (&Cursor{Sort: Sort{Field: "created_at"}, Key: "yesterday", ID: 1}).Encode(),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := spec.ParseCursor(token); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestSlice(t *testing.T) {
+	tests := []struct {
+		name string
+		req  Request
+		want []int64
+	}{
+		{name: "by id", req: Request{Sort: Sort{Field: "id"}, Limit: 10}, want: []int64{1, 2, 3, 4, 5}},
+		{name: "by name", req: Request{Sort: Sort{Field: "name"}, Limit: 10}, want: []int64{2, 5, 1, 3, 4}},
+		{name: "by name descending", req: Request{Sort: Sort{Field: "name", Desc: true}, Limit: 10}, want: []int64{4, 3, 1, 5, 2}},
+		{name: "by time", req: Request{Sort: Sort{Field: "created_at"}, Limit: 10}, want: []int64{2, 4, 3, 5, 1}},
+		{name: "offset", req: Request{Sort: Sort{Field: "id"}, Limit: 2, Offset: 3}, want: []int64{4, 5}},
+		{name: "offset past the end", req: Request{Sort: Sort{Field: "id"}, Limit: 2, Offset: 10}, want: nil},
+		{
+			name: "after a cursor on a tie",
+			req:  Request{Sort: Sort{Field: "name"}, Limit: 2, After: &Cursor{Sort: Sort{Field: "name"}, Key: "b", ID: 1}},
+			want: []int64{3, 4},
+		},
+		{
+			name: "after a cursor descending",
+			req:  Request{Sort: Sort{Field: "created_at", Desc: true}, Limit: 10, After: &Cursor{Key: base.Add(time.Hour), ID: 3}},
+			want: []int64{4, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := Slice(tt.req, items)
+			if got := ids(page); !slices.Equal(got, tt.want) {
+				t.Fatalf("items = %v, want %v", got, tt.want)
+			}
+			if page.Items == nil {
+				t.Error("items are nil, want an empty slice")
+			}
+			if page.Total != int64(len(items)) {
+				t.Errorf("total = %d, want %d", page.Total, len(items))
+			}
+		})
+	}
+}
+
+func TestSliceFollowsCursors(t *testing.T) {
+	for _, sort := range []Sort{{Field: "id"}, {Field: "name"}, {Field: "created_at", Desc: true}} {
+		t.Run(sort.String(), func(t *testing.T) {
+			all := ids(Slice(Request{Sort: sort, Limit: len(items)}, items))
+
+			var got []int64
+			req := Request{Sort: sort, Limit: 2}
+			for range items {
+				page := Slice(req, items)
+				got = append(got, ids(page)...)
+				if page.Next == nil {
+					break
+				}
+				// The cursor survives encoding, as it does between requests
+				next, err := spec.ParseCursor(page.Next.Encode())
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.After = next
+			}
+			if !slices.Equal(got, all) {
+				t.Fatalf("pages = %v, want %v", got, all)
+			}
+		})
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	req := Request{Sort: Sort{Field: "name"}, Limit: 2}
+
+	page := NewPage(req, items[:3], 5)
+	if got := ids(page); !slices.Equal(got, []int64{1, 2}) {
+		t.Fatalf("items = %v, want the first 2", got)
+	}
+	if page.Next == nil || page.Next.Key != "a" || page.Next.ID != 2 || page.Next.Sort != req.Sort {
+		t.Fatalf("next = %+v, want the cursor of item 2 by name", page.Next)
+	}
+
+	last := NewPage(req, items[:2], 2)
+	if last.Next != nil {
+		t.Fatalf("next = %+v on the last page, want nil", last.Next)
+	}
+
+	empty := NewPage[item](req, nil, 0)
+	if empty.Items == nil || len(empty.Items) != 0 || empty.Next != nil {
+		t.Fatalf("empty page = %+v, want no items and no cursor", empty)
+	}
+}
+
+func TestMap(t *testing.T) {
+	page := &Page[item]{Items: items[:2], Total: 5, Next: &Cursor{ID: 2}}
+	names, err := Map(page, func(items []item) ([]string, error) {
+		var names []string
+		for _, i := range items {
+			names = append(names, i.name)
+		}
+		return names, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names.Items, []string{"b", "a"}) || names.Total != 5 || names.Next != page.Next {
+		t.Fatalf("mapped page = %+v", names)
+	}
+
+	failure := errors.New("lookup failed")
+	if _, err := Map(page, func([]item) ([]string, error) { return nil, failure }); !errors.Is(err, failure) {
+		t.Fatalf("error = %v, want %v", err, failure)
+	}
+
+	none, err := Map(&Page[item]{}, func([]item) ([]string, error) { return nil, nil })
+	if err != nil || none.Items == nil {
+		t.Fatalf("mapping an empty page = %+v, %v, want empty items", none, err)
+	}
+}
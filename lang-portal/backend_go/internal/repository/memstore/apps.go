@@ -0,0 +1,136 @@
+package memstore
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// appRepo implements repository.AppRepository
+type appRepo struct {
+	s *Store
+}
+
+// appNameTaken reports whether another app already has the name
+func (d *data) appNameTaken(name string, id int64) bool {
+	for _, app := range d.apps {
+		if app.Name == name && app.ID != id {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *appRepo) Get(id int64) (*models.ActivityApp, error) {
+	defer r.s.lock()()
+
+	app, ok := r.s.data.apps[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &app, nil
+}
+
+func (r *appRepo) List(req pagination.Request) (*pagination.Page[models.ActivityApp], error) {
+	defer r.s.lock()()
+
+	return pagination.Slice(req, slices.Collect(maps.Values(r.s.data.apps))), nil
+}
+
+func (r *appRepo) Create(app *models.ActivityApp) error {
+	defer r.s.lock()()
+
+	if r.s.data.appNameTaken(app.Name, 0) {
+		return repository.ErrDuplicate
+	}
+	app.ID = r.s.data.nextID("activity_apps")
+	r.s.data.apps[app.ID] = *app
+	return nil
+}
+
+// Update keeps the stored creation time, like the SQL UPDATE
+func (r *appRepo) Update(app *models.ActivityApp) error {
+	defer r.s.lock()()
+
+	stored, ok := r.s.data.apps[app.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if r.s.data.appNameTaken(app.Name, app.ID) {
+		return repository.ErrDuplicate
+	}
+	stored.Name = app.Name
+	stored.ActivityType = app.ActivityType
+	stored.ThumbnailURL = app.ThumbnailURL
+	stored.LaunchURL = app.LaunchURL
+	r.s.data.apps[app.ID] = stored
+	return nil
+}
+
+func (r *appRepo) Delete(id int64) error {
+	defer r.s.lock()()
+
+	if _, ok := r.s.data.apps[id]; !ok {
+		return repository.ErrNotFound
+	}
+	r.s.data.deleteApp(id)
+	return nil
+}
+
+// deleteApp removes an app, cascades to its launches and unlinks its
+// activities
+func (d *data) deleteApp(id int64) {
+	delete(d.apps, id)
+	for launchID, launch := range d.launches {
+		if launch.AppID == id {
+			delete(d.launches, launchID)
+		}
+	}
+	for activityID, activity := range d.activities {
+		if activity.AppID != nil && *activity.AppID == id {
+			activity.AppID = nil
+			d.activities[activityID] = activity
+		}
+	}
+}
+
+// launchRepo implements repository.LaunchRepository
+type launchRepo struct {
+	s *Store
+}
+
+func (r *launchRepo) Create(launch *models.ActivityLaunch) error {
+	defer r.s.lock()()
+
+	d := r.s.data
+	if _, ok := d.apps[launch.AppID]; !ok {
+		return errMissingReference
+	}
+	if _, ok := d.users[launch.UserID]; !ok {
+		return errMissingReference
+	}
+	if _, ok := d.groups[launch.GroupID]; !ok {
+		return errMissingReference
+	}
+	if _, ok := d.sessions[launch.SessionID]; !ok {
+		return errMissingReference
+	}
+	launch.ID = d.nextID("activity_launches")
+	d.launches[launch.ID] = *launch
+	return nil
+}
+
+func (r *launchRepo) List(userID int64, req pagination.Request) (*pagination.Page[models.ActivityLaunch], error) {
+	defer r.s.lock()()
+
+	var launches []models.ActivityLaunch
+	for _, launch := range r.s.data.launches {
+		if launch.UserID == userID {
+			launches = append(launches, launch)
+		}
+	}
+	return pagination.Slice(req, launches), nil
+}
@@ -0,0 +1,129 @@
+package memstore
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// dashboardRepo implements repository.DashboardRepository
+type dashboardRepo struct {
+	s *Store
+}
+
+// round1 rounds x to one decimal place, like ROUND(x, 1)
+func round1(x float64) float64 {
+	return math.Round(x*10) / 10
+}
+
+// ownReviews returns the answers recorded in sessions
+func (d *data) ownReviews(sessions []models.StudySession) []models.WordReviewItem {
+	ids := make(map[int64]bool, len(sessions))
+	for _, session := range sessions {
+		ids[session.ID] = true
+	}
+	var items []models.WordReviewItem
+	for _, item := range d.reviews {
+		if ids[item.SessionID] {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ownSessions returns a user's sessions, latest first
+func (d *data) ownSessions(userID int64) []models.StudySession {
+	return d.sessionsWhere(func(s models.StudySession) bool { return s.UserID == userID })
+}
+
+func (r *dashboardRepo) LastSession(userID int64) (*models.LastSession, error) {
+	defer r.s.lock()()
+
+	sessions := r.s.data.ownSessions(userID)
+	if len(sessions) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	session := sessions[0]
+	activity := r.s.data.activities[session.StudyActivityID]
+	last := &models.LastSession{
+		SessionID:    session.ID,
+		StartTime:    session.StartTime,
+		EndTime:      session.EndTime,
+		Score:        session.Score,
+		Status:       session.Status,
+		ActivityType: activity.ActivityType,
+		GroupID:      activity.GroupID,
+		GroupName:    r.s.data.groups[activity.GroupID].Name,
+	}
+	for _, item := range r.s.data.ownReviews(sessions[:1]) {
+		last.WordsReviewed++
+		if item.IsCorrect {
+			last.CorrectAnswers++
+		}
+	}
+	return last, nil
+}
+
+func (r *dashboardRepo) Stats(userID int64) (*models.StudyStats, error) {
+	defer r.s.lock()()
+
+	var stats models.StudyStats
+	sessions := r.s.data.ownSessions(userID)
+	var days []time.Time
+	for _, session := range sessions {
+		if session.EndTime != nil {
+			stats.TotalStudyTime += int(math.Round(session.EndTime.Sub(session.StartTime).Seconds()))
+		}
+		if session.Status == models.SessionCompleted {
+			stats.SessionsCompleted++
+		}
+		day := session.StartTime.UTC().Truncate(24 * time.Hour)
+		if !slices.ContainsFunc(days, day.Equal) {
+			days = append(days, day)
+		}
+	}
+
+	var correct int
+	for _, item := range r.s.data.ownReviews(sessions) {
+		stats.TotalWordsReviewed++
+		if item.IsCorrect {
+			correct++
+		}
+	}
+	if stats.TotalWordsReviewed > 0 {
+		stats.SuccessRate = round1(float64(correct) * 100 / float64(stats.TotalWordsReviewed))
+	}
+
+	// Sessions are latest first, so days are too
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	if len(days) > 0 && !days[0].Before(yesterday) {
+		stats.StudyStreakDays = 1
+		for i := 1; i < len(days) && days[i].Equal(days[i-1].AddDate(0, 0, -1)); i++ {
+			stats.StudyStreakDays++
+		}
+	}
+	return &stats, nil
+}
+
+func (r *dashboardRepo) Progress(userID int64) (*models.StudyProgress, error) {
+	defer r.s.lock()()
+
+	studied := make(map[int64]bool)
+	for _, item := range r.s.data.ownReviews(r.s.data.ownSessions(userID)) {
+		if _, ok := r.s.data.words[item.WordID]; ok {
+			studied[item.WordID] = true
+		}
+	}
+
+	progress := &models.StudyProgress{
+		TotalWordsStudied:   len(studied),
+		TotalAvailableWords: len(r.s.data.words),
+	}
+	if progress.TotalAvailableWords > 0 {
+		progress.OverallCompletion = round1(float64(progress.TotalWordsStudied) * 100 / float64(progress.TotalAvailableWords))
+	}
+	return progress, nil
+}
@@ -0,0 +1,190 @@
+package memstore
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// groupRepo implements repository.GroupRepository
+type groupRepo struct {
+	s *Store
+}
+
+// withWordCount returns group with its current word count
+func (d *data) withWordCount(group models.Group) models.Group {
+	group.WordCount = d.wordCount(group.ID)
+	return group
+}
+
+func (d *data) wordCount(groupID int64) int64 {
+	var count int64
+	for m := range d.members {
+		if m.groupID == groupID {
+			count++
+		}
+	}
+	return count
+}
+
+// nameTaken reports whether a group other than id has the name
+func (d *data) nameTaken(name string, id int64) bool {
+	for _, group := range d.groups {
+		if group.Name == name && group.ID != id {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *groupRepo) Get(id int64) (*models.Group, error) {
+	defer r.s.lock()()
+
+	group, ok := r.s.data.groups[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	group = r.s.data.withWordCount(group)
+	return &group, nil
+}
+
+func (r *groupRepo) GetByName(name string) (*models.Group, error) {
+	defer r.s.lock()()
+
+	for _, group := range r.s.data.groups {
+		if group.Name == name {
+			group = r.s.data.withWordCount(group)
+			return &group, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *groupRepo) List(req pagination.Request) (*pagination.Page[models.Group], error) {
+	defer r.s.lock()()
+
+	result := pagination.Slice(req, slices.Collect(maps.Values(r.s.data.groups)))
+	for i := range result.Items {
+		result.Items[i] = r.s.data.withWordCount(result.Items[i])
+	}
+	return result, nil
+}
+
+func (r *groupRepo) Create(group *models.Group) error {
+	defer r.s.lock()()
+
+	if r.s.data.nameTaken(group.Name, 0) {
+		return repository.ErrDuplicate
+	}
+	group.ID = r.s.data.nextID("groups")
+	r.s.data.groups[group.ID] = *group
+	return nil
+}
+
+func (r *groupRepo) Update(group *models.Group) error {
+	defer r.s.lock()()
+
+	if r.s.data.nameTaken(group.Name, group.ID) {
+		return repository.ErrDuplicate
+	}
+	if _, ok := r.s.data.groups[group.ID]; !ok {
+		return repository.ErrNotFound
+	}
+	r.s.data.groups[group.ID] = *group
+	return nil
+}
+
+func (r *groupRepo) Delete(id int64) error {
+	defer r.s.lock()()
+
+	if _, ok := r.s.data.groups[id]; !ok {
+		return repository.ErrNotFound
+	}
+	r.s.data.deleteGroup(id)
+	return nil
+}
+
+// deleteGroup removes a group and cascades to its memberships, launches
+// and activities
+func (d *data) deleteGroup(id int64) {
+	delete(d.groups, id)
+	for m := range d.members {
+		if m.groupID == id {
+			delete(d.members, m)
+		}
+	}
+	for launchID, launch := range d.launches {
+		if launch.GroupID == id {
+			delete(d.launches, launchID)
+		}
+	}
+	for activityID, activity := range d.activities {
+		if activity.GroupID == id {
+			d.deleteActivity(activityID)
+		}
+	}
+}
+
+func (r *groupRepo) Names(wordIDs []int64) (map[int64][]string, error) {
+	defer r.s.lock()()
+
+	wanted := make(map[int64]bool, len(wordIDs))
+	for _, id := range wordIDs {
+		wanted[id] = true
+	}
+
+	names := make(map[int64][]string, len(wordIDs))
+	for m := range r.s.data.members {
+		if wanted[m.wordID] {
+			names[m.wordID] = append(names[m.wordID], r.s.data.groups[m.groupID].Name)
+		}
+	}
+	for _, list := range names {
+		slices.Sort(list)
+	}
+	return names, nil
+}
+
+func (r *groupRepo) HasWord(groupID, wordID int64) (bool, error) {
+	defer r.s.lock()()
+
+	return r.s.data.members[membership{groupID: groupID, wordID: wordID}], nil
+}
+
+func (r *groupRepo) WordCount(groupID int64) (int64, error) {
+	defer r.s.lock()()
+
+	return r.s.data.wordCount(groupID), nil
+}
+
+func (r *groupRepo) AddWord(groupID, wordID int64) error {
+	defer r.s.lock()()
+
+	if _, ok := r.s.data.groups[groupID]; !ok {
+		return errMissingReference
+	}
+	if _, ok := r.s.data.words[wordID]; !ok {
+		return errMissingReference
+	}
+
+	m := membership{groupID: groupID, wordID: wordID}
+	if r.s.data.members[m] {
+		return repository.ErrDuplicate
+	}
+	r.s.data.members[m] = true
+	return nil
+}
+
+func (r *groupRepo) RemoveWord(groupID, wordID int64) error {
+	defer r.s.lock()()
+
+	m := membership{groupID: groupID, wordID: wordID}
+	if !r.s.data.members[m] {
+		return repository.ErrNotFound
+	}
+	delete(r.s.data.members, m)
+	return nil
+}
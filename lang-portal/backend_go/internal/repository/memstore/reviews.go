@@ -0,0 +1,138 @@
+package memstore
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// reviewRepo implements repository.ReviewRepository
+type reviewRepo struct {
+	s *Store
+}
+
+func (r *reviewRepo) Create(item *models.WordReviewItem) error {
+	defer r.s.lock()()
+
+	if _, ok := r.s.data.sessions[item.SessionID]; !ok {
+		return errMissingReference
+	}
+	if _, ok := r.s.data.words[item.WordID]; !ok {
+		return errMissingReference
+	}
+	item.ID = r.s.data.nextID("word_review_items")
+	r.s.data.reviews[item.ID] = *item
+	return nil
+}
+
+func (r *reviewRepo) ListBySession(sessionID int64) ([]models.WordReviewItem, error) {
+	defer r.s.lock()()
+
+	var items []models.WordReviewItem
+	for _, item := range r.s.data.reviews {
+		if item.SessionID == sessionID {
+			items = append(items, item)
+		}
+	}
+	slices.SortFunc(items, func(a, b models.WordReviewItem) int {
+		return cmp.Or(a.ReviewedAt.Compare(b.ReviewedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return items, nil
+}
+
+func (r *reviewRepo) List(sessionID int64, filter repository.ReviewFilter, req pagination.Request) (*pagination.Page[models.WordReviewItem], error) {
+	defer r.s.lock()()
+
+	var items []models.WordReviewItem
+	for _, item := range r.s.data.reviews {
+		if item.SessionID == sessionID && (filter.Correct == nil || item.IsCorrect == *filter.Correct) {
+			items = append(items, item)
+		}
+	}
+	return pagination.Slice(req, items), nil
+}
+
+func (r *reviewRepo) Counts(userID int64, wordIDs []int64) (map[int64]repository.ReviewCounts, error) {
+	defer r.s.lock()()
+
+	wanted := make(map[int64]bool, len(wordIDs))
+	for _, id := range wordIDs {
+		wanted[id] = true
+	}
+
+	counts := make(map[int64]repository.ReviewCounts, len(wordIDs))
+	for _, item := range r.s.data.reviews {
+		if !wanted[item.WordID] || r.s.data.sessions[item.SessionID].UserID != userID {
+			continue
+		}
+		c := counts[item.WordID]
+		if item.IsCorrect {
+			c.Correct++
+		} else {
+			c.Wrong++
+		}
+		counts[item.WordID] = c
+	}
+	return counts, nil
+}
+
+func (r *reviewRepo) Schedule(userID, wordID int64) (*models.ReviewSchedule, error) {
+	defer r.s.lock()()
+
+	schedule, ok := r.s.data.schedules[scheduleKey{userID: userID, wordID: wordID}]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &schedule, nil
+}
+
+func (r *reviewRepo) SaveSchedule(schedule models.ReviewSchedule) error {
+	defer r.s.lock()()
+
+	if _, ok := r.s.data.words[schedule.WordID]; !ok {
+		return errMissingReference
+	}
+	if _, ok := r.s.data.users[schedule.UserID]; !ok {
+		return errMissingReference
+	}
+	r.s.data.schedules[scheduleKey{userID: schedule.UserID, wordID: schedule.WordID}] = schedule
+	return nil
+}
+
+func (r *reviewRepo) Due(userID int64, groupID *int64, before time.Time, limit int) ([]models.DueWord, error) {
+	defer r.s.lock()()
+
+	var scheduled, unscheduled []models.DueWord
+	for _, word := range sortedValues(r.s.data.words, byWordID) {
+		if groupID != nil && !r.s.data.members[membership{groupID: *groupID, wordID: word.ID}] {
+			continue
+		}
+
+		due := models.DueWord{Word: copyWord(word)}
+		schedule, ok := r.s.data.schedules[scheduleKey{userID: userID, wordID: word.ID}]
+		if !ok {
+			due.IsNew = true
+			unscheduled = append(unscheduled, due)
+			continue
+		}
+		if schedule.DueAt.After(before) {
+			continue
+		}
+
+		due.EaseFactor = schedule.EaseFactor
+		due.IntervalDays = schedule.IntervalDays
+		due.Repetitions = schedule.Repetitions
+		due.DueAt = &schedule.DueAt
+		due.LastReviewedAt = &schedule.LastReviewedAt
+		scheduled = append(scheduled, due)
+	}
+
+	slices.SortStableFunc(scheduled, func(a, b models.DueWord) int {
+		return a.DueAt.Compare(*b.DueAt)
+	})
+	return page(append(scheduled, unscheduled...), 0, limit), nil
+}
@@ -0,0 +1,258 @@
+package memstore
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// activityRepo implements repository.ActivityRepository
+type activityRepo struct {
+	s *Store
+}
+
+func (r *activityRepo) Get(id int64) (*models.StudyActivity, error) {
+	defer r.s.lock()()
+
+	activity, ok := r.s.data.activities[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	activity = r.s.data.withApp(activity)
+	return &activity, nil
+}
+
+// withApp returns a copy of activity with its own app ID and the name and
+// thumbnail of its app, like the SQL join
+func (d *data) withApp(activity models.StudyActivity) models.StudyActivity {
+	activity.Name, activity.ThumbnailURL = "", ""
+	if activity.AppID != nil {
+		appID := *activity.AppID
+		activity.AppID = &appID
+		app := d.apps[appID]
+		activity.Name, activity.ThumbnailURL = app.Name, app.ThumbnailURL
+	}
+	return activity
+}
+
+// appMissing reports whether an activity links to an app that does not
+// exist
+func (d *data) appMissing(activity *models.StudyActivity) bool {
+	if activity.AppID == nil {
+		return false
+	}
+	_, ok := d.apps[*activity.AppID]
+	return !ok
+}
+
+func (r *activityRepo) List(filter repository.ActivityFilter, req pagination.Request) (*pagination.Page[models.StudyActivity], error) {
+	defer r.s.lock()()
+
+	var activities []models.StudyActivity
+	for _, activity := range r.s.data.activities {
+		if (filter.GroupID == nil || activity.GroupID == *filter.GroupID) &&
+			(filter.ActivityType == "" || activity.ActivityType == filter.ActivityType) {
+			activities = append(activities, activity)
+		}
+	}
+	result := pagination.Slice(req, activities)
+	for i, activity := range result.Items {
+		result.Items[i] = r.s.data.withApp(activity)
+	}
+	return result, nil
+}
+
+func (r *activityRepo) FindByApp(appID, groupID int64) (*models.StudyActivity, error) {
+	defer r.s.lock()()
+
+	for _, activity := range sortedValues(r.s.data.activities, func(a, b models.StudyActivity) int {
+		return cmp.Compare(a.ID, b.ID)
+	}) {
+		if activity.AppID != nil && *activity.AppID == appID && activity.GroupID == groupID {
+			activity = r.s.data.withApp(activity)
+			return &activity, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *activityRepo) Create(activity *models.StudyActivity) error {
+	defer r.s.lock()()
+
+	if _, ok := r.s.data.groups[activity.GroupID]; !ok {
+		return errMissingReference
+	}
+	if r.s.data.appMissing(activity) {
+		return errMissingReference
+	}
+	activity.ID = r.s.data.nextID("study_activities")
+	r.s.data.activities[activity.ID] = r.s.data.withApp(*activity)
+	return nil
+}
+
+// Update keeps the stored creation time, like the SQL UPDATE
+func (r *activityRepo) Update(activity *models.StudyActivity) error {
+	defer r.s.lock()()
+
+	stored, ok := r.s.data.activities[activity.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := r.s.data.groups[activity.GroupID]; !ok {
+		return errMissingReference
+	}
+	if r.s.data.appMissing(activity) {
+		return errMissingReference
+	}
+	stored.GroupID = activity.GroupID
+	stored.ActivityType = activity.ActivityType
+	stored.AppID = activity.AppID
+	r.s.data.activities[activity.ID] = r.s.data.withApp(stored)
+	return nil
+}
+
+func (r *activityRepo) Delete(id int64) error {
+	defer r.s.lock()()
+
+	if _, ok := r.s.data.activities[id]; !ok {
+		return repository.ErrNotFound
+	}
+	r.s.data.deleteActivity(id)
+	return nil
+}
+
+// deleteActivity removes an activity and cascades to its sessions
+func (d *data) deleteActivity(id int64) {
+	delete(d.activities, id)
+	for sessionID, session := range d.sessions {
+		if session.StudyActivityID == id {
+			d.deleteSession(sessionID)
+		}
+	}
+}
+
+// sessionRepo implements repository.SessionRepository
+type sessionRepo struct {
+	s *Store
+}
+
+// copySession returns session with its own end time, score and breakdown
+func copySession(session models.StudySession) models.StudySession {
+	if session.EndTime != nil {
+		endTime := *session.EndTime
+		session.EndTime = &endTime
+	}
+	if session.Score != nil {
+		score := *session.Score
+		session.Score = &score
+	}
+	if session.ScoreBreakdown != nil {
+		breakdown := *session.ScoreBreakdown
+		breakdown.Items = slices.Clone(breakdown.Items)
+		session.ScoreBreakdown = &breakdown
+	}
+	return session
+}
+
+// latestFirst orders sessions by start time, latest first
+func latestFirst(a, b models.StudySession) int {
+	return cmp.Or(b.StartTime.Compare(a.StartTime), cmp.Compare(b.ID, a.ID))
+}
+
+// sessionsWhere returns the sessions keep accepts, latest first
+func (d *data) sessionsWhere(keep func(models.StudySession) bool) []models.StudySession {
+	var sessions []models.StudySession
+	for _, session := range sortedValues(d.sessions, latestFirst) {
+		if keep(session) {
+			sessions = append(sessions, copySession(session))
+		}
+	}
+	return sessions
+}
+
+func (r *sessionRepo) Get(id int64) (*models.StudySession, error) {
+	defer r.s.lock()()
+
+	session, ok := r.s.data.sessions[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	session = copySession(session)
+	return &session, nil
+}
+
+func (r *sessionRepo) List(userID int64, filter repository.SessionFilter, req pagination.Request) (*pagination.Page[models.StudySession], error) {
+	defer r.s.lock()()
+
+	sessions := r.s.data.sessionsWhere(func(session models.StudySession) bool {
+		return session.UserID == userID &&
+			(filter.ActivityID == nil || session.StudyActivityID == *filter.ActivityID) &&
+			(filter.GroupID == nil || r.s.data.activities[session.StudyActivityID].GroupID == *filter.GroupID) &&
+			(filter.Status == "" || session.Status == filter.Status)
+	})
+	return pagination.Slice(req, sessions), nil
+}
+
+func (r *sessionRepo) ListActive() ([]models.StudySession, error) {
+	defer r.s.lock()()
+
+	sessions := r.s.data.sessionsWhere(func(session models.StudySession) bool {
+		return session.Status == models.SessionActive
+	})
+	slices.Reverse(sessions)
+	return sessions, nil
+}
+
+func (r *sessionRepo) Create(session *models.StudySession) error {
+	defer r.s.lock()()
+
+	if _, ok := r.s.data.activities[session.StudyActivityID]; !ok {
+		return errMissingReference
+	}
+	if _, ok := r.s.data.users[session.UserID]; !ok {
+		return errMissingReference
+	}
+	if !slices.Contains(sessionStatuses, session.Status) {
+		return errInvalidStatus
+	}
+	session.ID = r.s.data.nextID("study_sessions")
+	r.s.data.sessions[session.ID] = copySession(*session)
+	return nil
+}
+
+func (r *sessionRepo) Close(session *models.StudySession) error {
+	defer r.s.lock()()
+
+	stored, ok := r.s.data.sessions[session.ID]
+	if !ok || stored.Status != models.SessionActive {
+		return repository.ErrNotFound
+	}
+	if !slices.Contains(sessionStatuses, session.Status) {
+		return errInvalidStatus
+	}
+	stored.EndTime = session.EndTime
+	stored.Score = session.Score
+	stored.ScoreBreakdown = session.ScoreBreakdown
+	stored.Status = session.Status
+	r.s.data.sessions[session.ID] = copySession(stored)
+	return nil
+}
+
+// deleteSession removes a session and cascades to its review answers and
+// launches
+func (d *data) deleteSession(id int64) {
+	delete(d.sessions, id)
+	for itemID, item := range d.reviews {
+		if item.SessionID == id {
+			delete(d.reviews, itemID)
+		}
+	}
+	for launchID, launch := range d.launches {
+		if launch.SessionID == id {
+			delete(d.launches, launchID)
+		}
+	}
+}
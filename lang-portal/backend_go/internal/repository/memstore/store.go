@@ -0,0 +1,228 @@
+// Package memstore implements the repositories in memory. It follows the
+// SQLite schema's rules, including unique keys, foreign keys and cascading
+// deletes, so services behave the same on either store; use it to exercise
+// business logic without a database.
+package memstore
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+var (
+	// errMissingReference mirrors a foreign key violation
+	errMissingReference = errors.New("memstore: referenced row does not exist")
+	// errInvalidStatus mirrors the CHECK constraint on study session status
+	errInvalidStatus = errors.New("memstore: invalid study session status")
+	// errInvalidRole mirrors the CHECK constraint on user roles
+	errInvalidRole = errors.New("memstore: invalid user role")
+)
+
+// sessionStatuses are the statuses the schema allows
+var sessionStatuses = []string{models.SessionActive, models.SessionCompleted, models.SessionAbandoned}
+
+// userRoles are the roles the schema allows
+var userRoles = []string{models.RoleLearner, models.RoleTeacher, models.RoleAdmin}
+
+// membership is a row of word_groups
+type membership struct {
+	groupID int64
+	wordID  int64
+}
+
+// scheduleKey is the primary key of word_review_schedules
+type scheduleKey struct {
+	userID int64
+	wordID int64
+}
+
+// data holds every table. Rows are stored by value and copied on the way
+// in and out, so callers never share memory with the store.
+type data struct {
+	users      map[int64]models.User
+	tokens     map[int64]models.APIToken
+	words      map[int64]models.Word
+	groups     map[int64]models.Group
+	members    map[membership]bool
+	apps       map[int64]models.ActivityApp
+	launches   map[int64]models.ActivityLaunch
+	activities map[int64]models.StudyActivity
+	sessions   map[int64]models.StudySession
+	reviews    map[int64]models.WordReviewItem
+	schedules  map[scheduleKey]models.ReviewSchedule
+	// lastID is the last ID handed out per table; like AUTOINCREMENT, IDs
+	// are never reused
+	lastID map[string]int64
+}
+
+func newData() *data {
+	return &data{
+		users:      make(map[int64]models.User),
+		tokens:     make(map[int64]models.APIToken),
+		words:      make(map[int64]models.Word),
+		groups:     make(map[int64]models.Group),
+		members:    make(map[membership]bool),
+		apps:       make(map[int64]models.ActivityApp),
+		launches:   make(map[int64]models.ActivityLaunch),
+		activities: make(map[int64]models.StudyActivity),
+		sessions:   make(map[int64]models.StudySession),
+		reviews:    make(map[int64]models.WordReviewItem),
+		schedules:  make(map[scheduleKey]models.ReviewSchedule),
+		lastID:     make(map[string]int64),
+	}
+}
+
+// clone copies every table. Rows are immutable once stored, so copying the
+// maps is enough.
+func (d *data) clone() *data {
+	return &data{
+		users:      maps.Clone(d.users),
+		tokens:     maps.Clone(d.tokens),
+		words:      maps.Clone(d.words),
+		groups:     maps.Clone(d.groups),
+		members:    maps.Clone(d.members),
+		apps:       maps.Clone(d.apps),
+		launches:   maps.Clone(d.launches),
+		activities: maps.Clone(d.activities),
+		sessions:   maps.Clone(d.sessions),
+		reviews:    maps.Clone(d.reviews),
+		schedules:  maps.Clone(d.schedules),
+		lastID:     maps.Clone(d.lastID),
+	}
+}
+
+// nextID returns a fresh ID for a table
+func (d *data) nextID(table string) int64 {
+	d.lastID[table]++
+	return d.lastID[table]
+}
+
+// Store is a repository.Store kept in memory. It is safe for concurrent
+// use; Atomic holds the lock for the whole unit of work.
+type Store struct {
+	mu   *sync.Mutex
+	data *data
+	// inTx is set on the Store handed to an Atomic function, whose lock is
+	// already held
+	inTx bool
+}
+
+// New creates a Store holding only the default user, an admin, like a
+// freshly migrated database
+func New() *Store {
+	d := newData()
+	d.users[models.DefaultUserID] = models.User{
+		ID:        models.DefaultUserID,
+		Name:      "default",
+		Role:      models.RoleAdmin,
+		CreatedAt: time.Now(),
+	}
+	d.lastID["users"] = models.DefaultUserID
+	return &Store{mu: &sync.Mutex{}, data: d}
+}
+
+// lock acquires the store lock unless it is already held by Atomic and
+// returns the function that releases it
+func (s *Store) lock() func() {
+	if s.inTx {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// Users returns the user repository
+func (s *Store) Users() repository.UserRepository {
+	return &userRepo{s: s}
+}
+
+// Tokens returns the API token repository
+func (s *Store) Tokens() repository.TokenRepository {
+	return &tokenRepo{s: s}
+}
+
+// Words returns the word repository
+func (s *Store) Words() repository.WordRepository {
+	return &wordRepo{s: s}
+}
+
+// Groups returns the group repository
+func (s *Store) Groups() repository.GroupRepository {
+	return &groupRepo{s: s}
+}
+
+// Apps returns the activity catalog repository
+func (s *Store) Apps() repository.AppRepository {
+	return &appRepo{s: s}
+}
+
+// Launches returns the app launch repository
+func (s *Store) Launches() repository.LaunchRepository {
+	return &launchRepo{s: s}
+}
+
+// Activities returns the study activity repository
+func (s *Store) Activities() repository.ActivityRepository {
+	return &activityRepo{s: s}
+}
+
+// Sessions returns the study session repository
+func (s *Store) Sessions() repository.SessionRepository {
+	return &sessionRepo{s: s}
+}
+
+// Reviews returns the review repository
+func (s *Store) Reviews() repository.ReviewRepository {
+	return &reviewRepo{s: s}
+}
+
+// Dashboard returns the dashboard repository
+func (s *Store) Dashboard() repository.DashboardRepository {
+	return &dashboardRepo{s: s}
+}
+
+// System returns the system repository
+func (s *Store) System() repository.SystemRepository {
+	return &systemRepo{s: s}
+}
+
+// Atomic runs fn with the store locked and restores the previous contents
+// when fn fails
+func (s *Store) Atomic(fn func(repository.Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.data.clone()
+	if err := fn(&Store{mu: s.mu, data: s.data, inTx: true}); err != nil {
+		*s.data = *snapshot
+		return err
+	}
+	return nil
+}
+
+// sortedValues returns the rows of a table sorted by cmp
+func sortedValues[T any](rows map[int64]T, cmp func(a, b T) int) []T {
+	values := slices.Collect(maps.Values(rows))
+	slices.SortFunc(values, cmp)
+	return values
+}
+
+// page returns the rows from offset up to limit, or an empty slice when
+// there are none
+func page[T any](rows []T, offset, limit int) []T {
+	if offset >= len(rows) || limit <= 0 {
+		return []T{}
+	}
+	end := min(offset+limit, len(rows))
+	return slices.Clone(rows[offset:end])
+}
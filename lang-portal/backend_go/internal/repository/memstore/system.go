@@ -0,0 +1,70 @@
+package memstore
+
+import (
+	"math"
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// systemRepo implements repository.SystemRepository. The store has no file
+// to measure or back up and never records backups.
+type systemRepo struct {
+	s *Store
+}
+
+func (r *systemRepo) Stats() (*models.SystemStats, error) {
+	defer r.s.lock()()
+
+	stats := &models.SystemStats{
+		TotalWords:    int64(len(r.s.data.words)),
+		TotalGroups:   int64(len(r.s.data.groups)),
+		TotalSessions: int64(len(r.s.data.sessions)),
+	}
+	var scored int
+	var scores float64
+	for _, session := range r.s.data.sessions {
+		if session.Status != models.SessionCompleted {
+			continue
+		}
+		if session.Score != nil {
+			scored++
+			scores += *session.Score
+		}
+		if session.EndTime != nil {
+			stats.TotalStudyTimeMinutes += int64(math.Round(session.EndTime.Sub(session.StartTime).Seconds())) / 60
+		}
+	}
+	if scored > 0 {
+		stats.AverageSessionScore = scores / float64(scored)
+	}
+	return stats, nil
+}
+
+func (r *systemRepo) Check() error {
+	return nil
+}
+
+func (r *systemRepo) Size() (int64, error) {
+	return 0, nil
+}
+
+func (r *systemRepo) Backup(path string) error {
+	return repository.ErrUnsupported
+}
+
+func (r *systemRepo) LastBackup() (*models.BackupInfo, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (r *systemRepo) PruneSessions(cutoff time.Time) error {
+	defer r.s.lock()()
+
+	for id, session := range r.s.data.sessions {
+		if session.Status == models.SessionCompleted && session.EndTime != nil && session.EndTime.Before(cutoff) {
+			r.s.data.deleteSession(id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package memstore
+
+import (
+	"cmp"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// tokenRepo implements repository.TokenRepository
+type tokenRepo struct {
+	s *Store
+}
+
+func (r *tokenRepo) GetByHash(hash string) (*models.APIToken, error) {
+	defer r.s.lock()()
+
+	for _, token := range r.s.data.tokens {
+		if token.Hash == hash {
+			return &token, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *tokenRepo) List(userID int64) ([]models.APIToken, error) {
+	defer r.s.lock()()
+
+	var tokens []models.APIToken
+	for _, token := range sortedValues(r.s.data.tokens, func(a, b models.APIToken) int {
+		return cmp.Compare(a.ID, b.ID)
+	}) {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *tokenRepo) Create(token *models.APIToken) error {
+	defer r.s.lock()()
+
+	if _, ok := r.s.data.users[token.UserID]; !ok {
+		return errMissingReference
+	}
+	for _, existing := range r.s.data.tokens {
+		if existing.Hash == token.Hash {
+			return repository.ErrDuplicate
+		}
+	}
+	token.ID = r.s.data.nextID("api_tokens")
+	r.s.data.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *tokenRepo) Delete(userID, id int64) error {
+	defer r.s.lock()()
+
+	token, ok := r.s.data.tokens[id]
+	if !ok || token.UserID != userID {
+		return repository.ErrNotFound
+	}
+	delete(r.s.data.tokens, id)
+	return nil
+}
@@ -0,0 +1,85 @@
+package memstore
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// userRepo implements repository.UserRepository
+type userRepo struct {
+	s *Store
+}
+
+func (r *userRepo) Get(id int64) (*models.User, error) {
+	defer r.s.lock()()
+
+	user, ok := r.s.data.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r *userRepo) GetByName(name string) (*models.User, error) {
+	defer r.s.lock()()
+
+	for _, user := range r.s.data.users {
+		if user.Name == name {
+			return &user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *userRepo) List(req pagination.Request) (*pagination.Page[models.User], error) {
+	defer r.s.lock()()
+
+	return pagination.Slice(req, slices.Collect(maps.Values(r.s.data.users))), nil
+}
+
+func (r *userRepo) Create(user *models.User) error {
+	defer r.s.lock()()
+
+	if !slices.Contains(userRoles, user.Role) {
+		return errInvalidRole
+	}
+	for _, existing := range r.s.data.users {
+		if existing.Name == user.Name {
+			return repository.ErrDuplicate
+		}
+	}
+	user.ID = r.s.data.nextID("users")
+	r.s.data.users[user.ID] = *user
+	return nil
+}
+
+func (r *userRepo) SetRole(id int64, role string) error {
+	defer r.s.lock()()
+
+	user, ok := r.s.data.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if !slices.Contains(userRoles, role) {
+		return errInvalidRole
+	}
+	user.Role = role
+	r.s.data.users[id] = user
+	return nil
+}
+
+func (r *userRepo) CountRole(role string) (int64, error) {
+	defer r.s.lock()()
+
+	var count int64
+	for _, user := range r.s.data.users {
+		if user.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,204 @@
+package memstore
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// wordRepo implements repository.WordRepository
+type wordRepo struct {
+	s *Store
+}
+
+// copyWord returns word with its own copy of the parts
+func copyWord(word models.Word) models.Word {
+	word.Parts = maps.Clone(word.Parts)
+	return word
+}
+
+func byWordID(a, b models.Word) int {
+	return cmp.Compare(a.ID, b.ID)
+}
+
+func (r *wordRepo) Get(id int64) (*models.Word, error) {
+	defer r.s.lock()()
+
+	word, ok := r.s.data.words[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	word = copyWord(word)
+	return &word, nil
+}
+
+func (r *wordRepo) FindByText(japanese, english string) (*models.Word, error) {
+	defer r.s.lock()()
+
+	for _, word := range sortedValues(r.s.data.words, byWordID) {
+		if word.Japanese == japanese && word.English == english {
+			word = copyWord(word)
+			return &word, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *wordRepo) List(filter repository.WordFilter, req pagination.Request) (*pagination.Page[models.Word], error) {
+	defer r.s.lock()()
+
+	var words []models.Word
+	for _, word := range r.s.data.words {
+		if filter.GroupID == nil || r.s.data.members[membership{groupID: *filter.GroupID, wordID: word.ID}] {
+			words = append(words, word)
+		}
+	}
+	result := pagination.Slice(req, words)
+	result.Items = copyWords(result.Items)
+	return result, nil
+}
+
+// Search matches each term as a case-insensitive substring. Words equal to
+// the whole query rank first, then words are ordered by ID; unlike the
+// SQLite store there is no relevance ranking.
+func (r *wordRepo) Search(search repository.WordSearch, offset, limit int) ([]models.Word, int64, error) {
+	defer r.s.lock()()
+
+	whole := strings.ToLower(strings.Join(search.Terms, " "))
+	exact := func(word models.Word) bool {
+		return strings.ToLower(word.Japanese) == whole ||
+			strings.ToLower(word.Romaji) == whole ||
+			strings.ToLower(word.English) == whole
+	}
+
+	var matches []models.Word
+	for _, word := range sortedValues(r.s.data.words, byWordID) {
+		if matchesTerms(word, search.Terms) && matchesParts(word, search.Parts) {
+			matches = append(matches, word)
+		}
+	}
+	// Stable, so words of equal exactness stay in ID order
+	slices.SortStableFunc(matches, func(a, b models.Word) int {
+		return cmp.Compare(rank(exact(a)), rank(exact(b)))
+	})
+
+	return copyWords(page(matches, offset, limit)), int64(len(matches)), nil
+}
+
+// matchesTerms reports whether every term occurs in the japanese, romaji
+// or english text of word
+func matchesTerms(word models.Word, terms []string) bool {
+	fields := []string{
+		strings.ToLower(word.Japanese),
+		strings.ToLower(word.Romaji),
+		strings.ToLower(word.English),
+	}
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		found := false
+		for _, field := range fields {
+			if strings.Contains(field, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesParts reports whether the parts of word hold every filter value.
+// Like json_extract compared with text, only string values can match.
+func matchesParts(word models.Word, filters map[string]string) bool {
+	for key, want := range filters {
+		got, ok := word.Parts[key].(string)
+		if !ok || got != want {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *wordRepo) Missing(ids []int64) ([]int64, error) {
+	defer r.s.lock()()
+
+	var missing []int64
+	for _, id := range ids {
+		if _, ok := r.s.data.words[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+func (r *wordRepo) Create(word *models.Word) error {
+	defer r.s.lock()()
+
+	word.ID = r.s.data.nextID("words")
+	r.s.data.words[word.ID] = copyWord(*word)
+	return nil
+}
+
+func (r *wordRepo) Update(word *models.Word) error {
+	defer r.s.lock()()
+
+	if _, ok := r.s.data.words[word.ID]; !ok {
+		return repository.ErrNotFound
+	}
+	r.s.data.words[word.ID] = copyWord(*word)
+	return nil
+}
+
+func (r *wordRepo) Delete(id int64) error {
+	defer r.s.lock()()
+
+	if _, ok := r.s.data.words[id]; !ok {
+		return repository.ErrNotFound
+	}
+	r.s.data.deleteWord(id)
+	return nil
+}
+
+// deleteWord removes a word and cascades to its memberships, reviews and
+// schedules
+func (d *data) deleteWord(id int64) {
+	delete(d.words, id)
+	for key := range d.schedules {
+		if key.wordID == id {
+			delete(d.schedules, key)
+		}
+	}
+	for m := range d.members {
+		if m.wordID == id {
+			delete(d.members, m)
+		}
+	}
+	for itemID, item := range d.reviews {
+		if item.WordID == id {
+			delete(d.reviews, itemID)
+		}
+	}
+}
+
+// copyWords copies every word so callers cannot modify stored parts
+func copyWords(words []models.Word) []models.Word {
+	for i := range words {
+		words[i] = copyWord(words[i])
+	}
+	return words
+}
+
+// rank orders matches that satisfy a preference first
+func rank(preferred bool) int {
+	if preferred {
+		return 0
+	}
+	return 1
+}
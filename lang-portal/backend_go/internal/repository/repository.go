@@ -0,0 +1,292 @@
+// Package repository defines the storage the services are built on. The
+// sqlstore package implements it on top of SQLite and Postgres; memstore
+// keeps everything in memory so business logic can be exercised without a
+// database.
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate is returned when a row clashes with a unique key
+	ErrDuplicate = errors.New("duplicate")
+	// ErrUnsupported is returned for an operation the store cannot perform
+	ErrUnsupported = errors.New("unsupported")
+)
+
+// The orders each listing supports. Stores implement every field listed.
+var (
+	WordListing = pagination.Spec{
+		Fields: map[string]pagination.Kind{
+			"id":       pagination.Int,
+			"japanese": pagination.String,
+			"romaji":   pagination.String,
+			"english":  pagination.String,
+		},
+		Default: pagination.Sort{Field: "id"},
+	}
+	GroupListing = pagination.Spec{
+		Fields:  map[string]pagination.Kind{"id": pagination.Int, "name": pagination.String},
+		Default: pagination.Sort{Field: "id"},
+	}
+	ActivityListing = pagination.Spec{
+		Fields:  map[string]pagination.Kind{"id": pagination.Int, "created_at": pagination.Time},
+		Default: pagination.Sort{Field: "created_at", Desc: true},
+	}
+	SessionListing = pagination.Spec{
+		Fields:  map[string]pagination.Kind{"id": pagination.Int, "start_time": pagination.Time},
+		Default: pagination.Sort{Field: "start_time", Desc: true},
+	}
+	ReviewListing = pagination.Spec{
+		Fields:  map[string]pagination.Kind{"id": pagination.Int, "reviewed_at": pagination.Time},
+		Default: pagination.Sort{Field: "reviewed_at"},
+	}
+	UserListing = pagination.Spec{
+		Fields:  map[string]pagination.Kind{"id": pagination.Int, "name": pagination.String},
+		Default: pagination.Sort{Field: "id"},
+	}
+	AppListing = pagination.Spec{
+		Fields:  map[string]pagination.Kind{"id": pagination.Int, "name": pagination.String},
+		Default: pagination.Sort{Field: "name"},
+	}
+	LaunchListing = pagination.Spec{
+		Fields:  map[string]pagination.Kind{"id": pagination.Int, "launched_at": pagination.Time},
+		Default: pagination.Sort{Field: "launched_at", Desc: true},
+	}
+)
+
+// Store gives access to every repository and runs units of work atomically
+type Store interface {
+	Users() UserRepository
+	Tokens() TokenRepository
+	Words() WordRepository
+	Groups() GroupRepository
+	Apps() AppRepository
+	Launches() LaunchRepository
+	Activities() ActivityRepository
+	Sessions() SessionRepository
+	Reviews() ReviewRepository
+	Dashboard() DashboardRepository
+	System() SystemRepository
+
+	// Atomic runs fn against a Store whose changes are committed together
+	// when fn returns nil and discarded otherwise. Calling Atomic on the
+	// Store passed to fn runs in the same unit of work.
+	Atomic(fn func(Store) error) error
+}
+
+// UserRepository stores users and their roles
+type UserRepository interface {
+	Get(id int64) (*models.User, error)
+	// List returns a page of users, in UserListing order
+	List(page pagination.Request) (*pagination.Page[models.User], error)
+	GetByName(name string) (*models.User, error)
+	// Create returns ErrDuplicate when the name is taken
+	Create(user *models.User) error
+	SetRole(id int64, role string) error
+	// CountRole returns the number of users with a role
+	CountRole(role string) (int64, error)
+}
+
+// TokenRepository stores API tokens by the hash of their secret
+type TokenRepository interface {
+	GetByHash(hash string) (*models.APIToken, error)
+	// List returns a user's tokens, oldest first
+	List(userID int64) ([]models.APIToken, error)
+	Create(token *models.APIToken) error
+	// Delete returns ErrNotFound unless the user has the token
+	Delete(userID, id int64) error
+}
+
+// WordSearch selects words whose japanese, romaji or english text contains
+// every term and whose parts have every given value
+type WordSearch struct {
+	Terms []string
+	// Parts maps a parts key to the string it must equal. Keys are
+	// validated by the caller.
+	Parts map[string]string
+}
+
+// WordFilter selects the words of a listing
+type WordFilter struct {
+	// GroupID keeps the members of a group
+	GroupID *int64
+}
+
+// WordRepository stores vocabulary words
+type WordRepository interface {
+	Get(id int64) (*models.Word, error)
+	// List returns a page of the words filter selects, in WordListing order
+	List(filter WordFilter, page pagination.Request) (*pagination.Page[models.Word], error)
+	// Search returns a page of matching words and the number of matches.
+	// Words equal to the whole query rank first.
+	Search(search WordSearch, offset, limit int) ([]models.Word, int64, error)
+	// FindByText returns the oldest word with the given japanese and
+	// english text
+	FindByText(japanese, english string) (*models.Word, error)
+	// Missing returns the IDs in ids that have no word, in the given order
+	Missing(ids []int64) ([]int64, error)
+	Create(word *models.Word) error
+	Update(word *models.Word) error
+	// Delete removes a word with its memberships, reviews and schedule
+	Delete(id int64) error
+}
+
+// GroupRepository stores groups and their word memberships
+type GroupRepository interface {
+	// Get returns a group with its word count
+	Get(id int64) (*models.Group, error)
+	// List returns a page of groups, in GroupListing order
+	List(page pagination.Request) (*pagination.Page[models.Group], error)
+	GetByName(name string) (*models.Group, error)
+	// Create returns ErrDuplicate when the name is taken
+	Create(group *models.Group) error
+	// Update returns ErrDuplicate when the name is taken
+	Update(group *models.Group) error
+	// Delete removes a group with its memberships and activities
+	Delete(id int64) error
+
+	// Names returns the names of the groups each word belongs to, sorted
+	Names(wordIDs []int64) (map[int64][]string, error)
+	HasWord(groupID, wordID int64) (bool, error)
+	WordCount(groupID int64) (int64, error)
+	// AddWord returns ErrDuplicate when the word is already a member
+	AddWord(groupID, wordID int64) error
+	// RemoveWord returns ErrNotFound when the word is not a member
+	RemoveWord(groupID, wordID int64) error
+}
+
+// AppRepository stores the activity catalog
+type AppRepository interface {
+	Get(id int64) (*models.ActivityApp, error)
+	// List returns a page of apps, in AppListing order
+	List(page pagination.Request) (*pagination.Page[models.ActivityApp], error)
+	// Create returns ErrDuplicate when the name is taken
+	Create(app *models.ActivityApp) error
+	// Update returns ErrDuplicate when the name is taken
+	Update(app *models.ActivityApp) error
+	// Delete removes an app with its launches and unlinks its activities
+	Delete(id int64) error
+}
+
+// LaunchRepository stores app launches
+type LaunchRepository interface {
+	Create(launch *models.ActivityLaunch) error
+	// List returns a page of a user's launches, in LaunchListing order
+	List(userID int64, page pagination.Request) (*pagination.Page[models.ActivityLaunch], error)
+}
+
+// ActivityFilter selects the activities of a listing
+type ActivityFilter struct {
+	GroupID      *int64
+	ActivityType string
+}
+
+// ActivityRepository stores study activities. Activities are read with the
+// name and thumbnail of their app.
+type ActivityRepository interface {
+	Get(id int64) (*models.StudyActivity, error)
+	// List returns a page of the activities filter selects, in
+	// ActivityListing order
+	List(filter ActivityFilter, page pagination.Request) (*pagination.Page[models.StudyActivity], error)
+	// FindByApp returns the oldest activity of an app for a group
+	FindByApp(appID, groupID int64) (*models.StudyActivity, error)
+	Create(activity *models.StudyActivity) error
+	Update(activity *models.StudyActivity) error
+	// Delete removes an activity with its sessions
+	Delete(id int64) error
+}
+
+// SessionFilter selects the sessions of a listing
+type SessionFilter struct {
+	ActivityID *int64
+	// GroupID keeps the sessions of every activity of a group
+	GroupID *int64
+	Status  string
+}
+
+// SessionRepository stores study sessions. Listings return the sessions of
+// one user, except ListActive, which serves background jobs.
+type SessionRepository interface {
+	Get(id int64) (*models.StudySession, error)
+	// List returns a page of the user's sessions filter selects, in
+	// SessionListing order
+	List(userID int64, filter SessionFilter, page pagination.Request) (*pagination.Page[models.StudySession], error)
+	// ListActive returns the active sessions of every user, oldest first
+	ListActive() ([]models.StudySession, error)
+	Create(session *models.StudySession) error
+	// Close saves the end time, score, score breakdown and status of a
+	// session that is still active. It returns ErrNotFound when no active
+	// session has the ID, so a session is only ever closed once.
+	Close(session *models.StudySession) error
+}
+
+// ReviewCounts tallies the answers recorded for a word
+type ReviewCounts struct {
+	Correct int64
+	Wrong   int64
+}
+
+// ReviewFilter selects the answers of a listing
+type ReviewFilter struct {
+	Correct *bool
+}
+
+// ReviewRepository stores review answers and spaced-repetition schedules.
+// Answers belong to the owner of their session; schedules are kept per
+// user and word.
+type ReviewRepository interface {
+	Create(item *models.WordReviewItem) error
+	// ListBySession returns the answers of a session in the order given
+	ListBySession(sessionID int64) ([]models.WordReviewItem, error)
+	// List returns a page of the answers of a session filter selects, in
+	// ReviewListing order
+	List(sessionID int64, filter ReviewFilter, page pagination.Request) (*pagination.Page[models.WordReviewItem], error)
+	// Counts tallies a user's answers for each word that has any
+	Counts(userID int64, wordIDs []int64) (map[int64]ReviewCounts, error)
+
+	Schedule(userID, wordID int64) (*models.ReviewSchedule, error)
+	// SaveSchedule creates or replaces the schedule of a user's word
+	SaveSchedule(schedule models.ReviewSchedule) error
+	// Due returns up to limit words due for a user at or before the given
+	// time, optionally in one group: overdue words by due date, then words
+	// the user has no schedule for, which are marked new
+	Due(userID int64, groupID *int64, before time.Time, limit int) ([]models.DueWord, error)
+}
+
+// DashboardRepository computes the figures of a user's dashboard from their
+// study history
+type DashboardRepository interface {
+	// LastSession returns the user's latest session by start time, or
+	// ErrNotFound when they have none
+	LastSession(userID int64) (*models.LastSession, error)
+	// Stats counts as the study streak the consecutive days with a session
+	// that end on the latest one, when it was today or yesterday
+	Stats(userID int64) (*models.StudyStats, error)
+	Progress(userID int64) (*models.StudyProgress, error)
+}
+
+// SystemRepository reports on and maintains the whole database
+type SystemRepository interface {
+	Stats() (*models.SystemStats, error)
+	// Check returns the first problem found reaching the database or its
+	// tables
+	Check() error
+	// Size returns the size of the database in bytes
+	Size() (int64, error)
+	// Backup copies the database to path, or returns ErrUnsupported when
+	// the store cannot
+	Backup(path string) error
+	// LastBackup returns ErrNotFound when no backup was recorded
+	LastBackup() (*models.BackupInfo, error)
+	// PruneSessions deletes the completed sessions that ended before
+	// cutoff, with their answers
+	PruneSessions(cutoff time.Time) error
+}
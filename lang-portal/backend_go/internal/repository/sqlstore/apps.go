@@ -0,0 +1,177 @@
+package sqlstore
+
+import (
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// appColumns are the columns scanApp reads, in order
+const appColumns = "id, name, activity_type, thumbnail_url, launch_url, created_at"
+
+// appRepo implements repository.AppRepository
+type appRepo struct {
+	q querier
+}
+
+func scanApp(row interface{ Scan(...any) error }) (models.ActivityApp, error) {
+	var app models.ActivityApp
+	err := row.Scan(&app.ID, &app.Name, &app.ActivityType, &app.ThumbnailURL, &app.LaunchURL, &app.CreatedAt)
+	return app, err
+}
+
+func (r *appRepo) Get(id int64) (*models.ActivityApp, error) {
+	app, err := scanApp(r.q.QueryRow("SELECT "+appColumns+" FROM activity_apps WHERE id = ?", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &app, nil
+}
+
+func (r *appRepo) List(page pagination.Request) (*pagination.Page[models.ActivityApp], error) {
+	l := listing{
+		from:    " FROM activity_apps",
+		spec:    repository.AppListing,
+		columns: map[string]string{"id": "id", "name": "name"},
+	}
+
+	total, err := l.count(r.q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := l.query(r.q, appColumns, page)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apps []models.ActivityApp
+	for rows.Next() {
+		app, err := scanApp(rows)
+		if err != nil {
+			return nil, err
+		}
+		apps = append(apps, app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return pagination.NewPage(page, apps, total), nil
+}
+
+func (r *appRepo) Create(app *models.ActivityApp) error {
+	id, err := insert(r.q,
+		"INSERT INTO activity_apps (name, activity_type, thumbnail_url, launch_url, created_at) VALUES (?, ?, ?, ?, ?)",
+		app.Name, app.ActivityType, app.ThumbnailURL, app.LaunchURL, app.CreatedAt,
+	)
+	if err != nil {
+		if dialect.IsUniqueViolation(err) {
+			return repository.ErrDuplicate
+		}
+		return err
+	}
+
+	app.ID = id
+	return nil
+}
+
+// Update keeps the stored creation time
+func (r *appRepo) Update(app *models.ActivityApp) error {
+	result, err := r.q.Exec(
+		"UPDATE activity_apps SET name = ?, activity_type = ?, thumbnail_url = ?, launch_url = ? WHERE id = ?",
+		app.Name, app.ActivityType, app.ThumbnailURL, app.LaunchURL, app.ID,
+	)
+	if err != nil {
+		if dialect.IsUniqueViolation(err) {
+			return repository.ErrDuplicate
+		}
+		return err
+	}
+	return expectAffected(result)
+}
+
+// Delete relies on ON DELETE CASCADE for launches and on ON DELETE SET
+// NULL for activities
+func (r *appRepo) Delete(id int64) error {
+	result, err := r.q.Exec("DELETE FROM activity_apps WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+// launchColumns are the columns scanLaunch reads, in order
+const launchColumns = "id, app_id, user_id, group_id, session_id, launch_url, launched_at"
+
+// launchRepo implements repository.LaunchRepository
+type launchRepo struct {
+	q       querier
+	dialect dialect.Dialect
+}
+
+func scanLaunch(row interface{ Scan(...any) error }) (models.ActivityLaunch, error) {
+	var launch models.ActivityLaunch
+	err := row.Scan(
+		&launch.ID,
+		&launch.AppID,
+		&launch.UserID,
+		&launch.GroupID,
+		&launch.SessionID,
+		&launch.LaunchURL,
+		&launch.LaunchedAt,
+	)
+	return launch, err
+}
+
+func (r *launchRepo) Create(launch *models.ActivityLaunch) error {
+	id, err := insert(r.q, `
+		INSERT INTO activity_launches (app_id, user_id, group_id, session_id, launch_url, launched_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		launch.AppID,
+		launch.UserID,
+		launch.GroupID,
+		launch.SessionID,
+		launch.LaunchURL,
+		launch.LaunchedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	launch.ID = id
+	return nil
+}
+
+func (r *launchRepo) List(userID int64, page pagination.Request) (*pagination.Page[models.ActivityLaunch], error) {
+	l := listing{
+		from:    " FROM activity_launches",
+		spec:    repository.LaunchListing,
+		columns: map[string]string{"id": "id", "launched_at": "launched_at"},
+		dialect: r.dialect,
+	}
+	l.filter("user_id = ?", userID)
+
+	total, err := l.count(r.q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := l.query(r.q, launchColumns, page)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var launches []models.ActivityLaunch
+	for rows.Next() {
+		launch, err := scanLaunch(rows)
+		if err != nil {
+			return nil, err
+		}
+		launches = append(launches, launch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return pagination.NewPage(page, launches, total), nil
+}
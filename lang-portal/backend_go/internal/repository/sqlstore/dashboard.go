@@ -0,0 +1,143 @@
+package sqlstore
+
+import (
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/erans/lang-portal/internal/models"
+)
+
+// dashboardRepo implements repository.DashboardRepository
+type dashboardRepo struct {
+	q       querier
+	dialect dialect.Dialect
+}
+
+func (r *dashboardRepo) LastSession(userID int64) (*models.LastSession, error) {
+	query := `
+		SELECT 
+			ss.id,
+			ss.start_time,
+			ss.end_time,
+			ss.score,
+			ss.status,
+			sa.activity_type,
+			g.id,
+			g.name,
+			(SELECT COUNT(*) FROM word_review_items wri WHERE wri.session_id = ss.id) as words_reviewed,
+			(SELECT COUNT(*) FROM word_review_items wri WHERE wri.session_id = ss.id AND wri.is_correct) as correct_answers
+		FROM study_sessions ss
+		JOIN study_activities sa ON sa.id = ss.study_activity_id
+		JOIN groups g ON g.id = sa.group_id
+		WHERE ss.user_id = ?
+		ORDER BY ` + r.dialect.Timestamp("ss.start_time") + ` DESC, ss.id DESC
+		LIMIT 1
+	`
+
+	var last models.LastSession
+	err := r.q.QueryRow(query, userID).Scan(
+		&last.SessionID,
+		&last.StartTime,
+		&last.EndTime,
+		&last.Score,
+		&last.Status,
+		&last.ActivityType,
+		&last.GroupID,
+		&last.GroupName,
+		&last.WordsReviewed,
+		&last.CorrectAnswers,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &last, nil
+}
+
+func (r *dashboardRepo) Stats(userID int64) (*models.StudyStats, error) {
+	query := `
+		WITH own_sessions AS (
+			SELECT id, start_time, end_time, status
+			FROM study_sessions
+			WHERE user_id = ?
+		),
+		own_reviews AS (
+			SELECT wri.is_correct
+			FROM word_review_items wri
+			JOIN own_sessions ss ON ss.id = wri.session_id
+		),
+		study_days AS (
+			SELECT DISTINCT DATE(start_time) as study_date
+			FROM own_sessions
+		),
+		ranked_days AS (
+			SELECT study_date,
+			       ROW_NUMBER() OVER (ORDER BY study_date DESC) as rn
+			FROM study_days
+		),
+		latest AS (
+			SELECT MAX(study_date) as study_date FROM study_days
+		),
+		streak AS (
+			SELECT COUNT(*) as streak_days
+			FROM ranked_days, latest
+			WHERE latest.study_date >= ` + r.dialect.DaysAgo(1) + `
+			  AND ` + r.dialect.DaysBetween("ranked_days.study_date", "latest.study_date") + ` = ranked_days.rn - 1
+		)
+		SELECT
+			COALESCE((
+				SELECT CAST(SUM(ROUND(` + r.dialect.Seconds("start_time", "end_time") + `)) AS INTEGER)
+				FROM own_sessions
+				WHERE end_time IS NOT NULL
+			), 0) as total_study_time,
+			(SELECT COUNT(*) FROM own_sessions WHERE status = 'completed') as completed_sessions,
+			(SELECT COUNT(*) FROM own_reviews) as total_reviews,
+			COALESCE((
+				SELECT ROUND(AVG(CASE WHEN is_correct THEN 100.0 ELSE 0.0 END), 1)
+				FROM own_reviews
+			), 0) as success_rate,
+			(SELECT streak_days FROM streak) as streak_days
+	`
+
+	var stats models.StudyStats
+	err := r.q.QueryRow(query, userID).Scan(
+		&stats.TotalStudyTime,
+		&stats.SessionsCompleted,
+		&stats.TotalWordsReviewed,
+		&stats.SuccessRate,
+		&stats.StudyStreakDays,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *dashboardRepo) Progress(userID int64) (*models.StudyProgress, error) {
+	query := `
+		WITH studied_words AS (
+			SELECT DISTINCT wri.word_id
+			FROM word_review_items wri
+			JOIN study_sessions ss ON ss.id = wri.session_id
+			WHERE ss.user_id = ?
+		)
+		SELECT
+			CASE 
+				WHEN COUNT(w.id) > 0 THEN 
+					ROUND(COUNT(DISTINCT sw.word_id) * 100.0 / COUNT(w.id), 1)
+				ELSE 0.0
+			END as completion_rate,
+			COUNT(DISTINCT sw.word_id) as words_studied,
+			COUNT(w.id) as total_words
+		FROM words w
+		LEFT JOIN studied_words sw ON sw.word_id = w.id
+	`
+
+	var progress models.StudyProgress
+	err := r.q.QueryRow(query, userID).Scan(
+		&progress.OverallCompletion,
+		&progress.TotalWordsStudied,
+		&progress.TotalAvailableWords,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
@@ -0,0 +1,185 @@
+package sqlstore
+
+import (
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// groupColumns are the columns scanGroup reads, in order
+const groupColumns = `g.id, g.name, g.description,
+	(SELECT COUNT(*) FROM word_groups wg WHERE wg.group_id = g.id)`
+
+// groupRepo implements repository.GroupRepository
+type groupRepo struct {
+	q querier
+}
+
+func scanGroup(row interface{ Scan(...any) error }) (models.Group, error) {
+	var group models.Group
+	err := row.Scan(&group.ID, &group.Name, &group.Description, &group.WordCount)
+	return group, err
+}
+
+func (r *groupRepo) Get(id int64) (*models.Group, error) {
+	group, err := scanGroup(r.q.QueryRow("SELECT "+groupColumns+" FROM groups g WHERE g.id = ?", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &group, nil
+}
+
+func (r *groupRepo) GetByName(name string) (*models.Group, error) {
+	group, err := scanGroup(r.q.QueryRow("SELECT "+groupColumns+" FROM groups g WHERE g.name = ?", name))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &group, nil
+}
+
+func (r *groupRepo) List(page pagination.Request) (*pagination.Page[models.Group], error) {
+	l := listing{
+		from:    " FROM groups g",
+		spec:    repository.GroupListing,
+		columns: map[string]string{"id": "g.id", "name": "g.name"},
+	}
+
+	total, err := l.count(r.q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := l.query(r.q, groupColumns, page)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []models.Group
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return pagination.NewPage(page, groups, total), nil
+}
+
+func (r *groupRepo) Create(group *models.Group) error {
+	id, err := insert(r.q,
+		"INSERT INTO groups (name, description) VALUES (?, ?)",
+		group.Name, group.Description,
+	)
+	if err != nil {
+		if dialect.IsUniqueViolation(err) {
+			return repository.ErrDuplicate
+		}
+		return err
+	}
+
+	group.ID = id
+	return nil
+}
+
+func (r *groupRepo) Update(group *models.Group) error {
+	result, err := r.q.Exec(
+		"UPDATE groups SET name = ?, description = ? WHERE id = ?",
+		group.Name, group.Description, group.ID,
+	)
+	if err != nil {
+		if dialect.IsUniqueViolation(err) {
+			return repository.ErrDuplicate
+		}
+		return err
+	}
+	return expectAffected(result)
+}
+
+// Delete relies on ON DELETE CASCADE for memberships and activities
+func (r *groupRepo) Delete(id int64) error {
+	result, err := r.q.Exec("DELETE FROM groups WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (r *groupRepo) Names(wordIDs []int64) (map[int64][]string, error) {
+	names := make(map[int64][]string, len(wordIDs))
+	if len(wordIDs) == 0 {
+		return names, nil
+	}
+
+	placeholders, args := inClause(wordIDs)
+	rows, err := r.q.Query(`
+		SELECT wg.word_id, g.name
+		FROM word_groups wg
+		JOIN groups g ON g.id = wg.group_id
+		WHERE wg.word_id IN (`+placeholders+`)
+		ORDER BY g.name`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var wordID int64
+		var name string
+		if err := rows.Scan(&wordID, &name); err != nil {
+			return nil, err
+		}
+		names[wordID] = append(names[wordID], name)
+	}
+	return names, rows.Err()
+}
+
+func (r *groupRepo) HasWord(groupID, wordID int64) (bool, error) {
+	var found bool
+	err := r.q.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM word_groups WHERE word_id = ? AND group_id = ?)",
+		wordID, groupID,
+	).Scan(&found)
+	return found, err
+}
+
+func (r *groupRepo) WordCount(groupID int64) (int64, error) {
+	var count int64
+	err := r.q.QueryRow("SELECT COUNT(*) FROM word_groups WHERE group_id = ?", groupID).Scan(&count)
+	return count, err
+}
+
+// AddWord uses ON CONFLICT so a duplicate does not abort the surrounding
+// transaction, letting callers report every duplicate of a batch
+func (r *groupRepo) AddWord(groupID, wordID int64) error {
+	result, err := r.q.Exec(`
+		INSERT INTO word_groups (word_id, group_id) VALUES (?, ?)
+		ON CONFLICT(word_id, group_id) DO NOTHING`,
+		wordID, groupID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrDuplicate
+	}
+	return nil
+}
+
+func (r *groupRepo) RemoveWord(groupID, wordID int64) error {
+	result, err := r.q.Exec(
+		"DELETE FROM word_groups WHERE word_id = ? AND group_id = ?",
+		wordID, groupID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
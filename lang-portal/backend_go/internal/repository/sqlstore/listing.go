@@ -0,0 +1,83 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/erans/lang-portal/internal/pagination"
+)
+
+// listing builds the queries of a paginated listing. from and the filters
+// select its rows, which are counted over every page and read a page at
+// a time.
+type listing struct {
+	from  string
+	where []string
+	args  []any
+	// spec gives the kind of each sort field; columns maps it to its
+	// column. "id" must be the ID column, which breaks ties.
+	spec    pagination.Spec
+	columns map[string]string
+	// dialect compares the timestamps of time fields
+	dialect dialect.Dialect
+}
+
+// filter keeps the rows matching cond
+func (l *listing) filter(cond string, args ...any) {
+	l.where = append(l.where, cond)
+	l.args = append(l.args, args...)
+}
+
+// whereClause joins conditions into a WHERE clause, or "" for none
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// count returns the number of rows over every page
+func (l *listing) count(q querier) (int64, error) {
+	var total int64
+	err := q.QueryRow("SELECT COUNT(*)"+l.from+whereClause(l.where), l.args...).Scan(&total)
+	return total, err
+}
+
+// query reads the columns of a page's rows, plus the first row of the next
+// page when there is one, for pagination.NewPage
+func (l *listing) query(q querier, columns string, page pagination.Request) (*sql.Rows, error) {
+	where := append([]string(nil), l.where...)
+	args := append([]any(nil), l.args...)
+
+	key, id := l.columns[page.Sort.Field], l.columns["id"]
+	param := "?"
+	if l.spec.Fields[page.Sort.Field] == pagination.Time {
+		key, param = l.dialect.Timestamp(key), l.dialect.Timestamp(param)
+	}
+	op, dir := ">", " ASC"
+	if page.Sort.Desc {
+		op, dir = "<", " DESC"
+	}
+
+	offset := page.Offset
+	if after := page.After; after != nil {
+		offset = 0
+		if key == id {
+			where = append(where, id+" "+op+" ?")
+			args = append(args, after.ID)
+		} else {
+			where = append(where, "("+key+" "+op+" "+param+" OR ("+key+" = "+param+" AND "+id+" "+op+" ?))")
+			args = append(args, after.Key, after.Key, after.ID)
+		}
+	}
+
+	order := " ORDER BY " + key + dir
+	if key != id {
+		order += ", " + id + dir
+	}
+	return q.Query(
+		"SELECT "+columns+l.from+whereClause(where)+order+" LIMIT ? OFFSET ?",
+		append(args, page.Limit+1, offset)...,
+	)
+}
@@ -0,0 +1,237 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// reviewRepo implements repository.ReviewRepository
+type reviewRepo struct {
+	q       querier
+	dialect dialect.Dialect
+}
+
+func (r *reviewRepo) Create(item *models.WordReviewItem) error {
+	id, err := insert(r.q, `
+		INSERT INTO word_review_items (session_id, word_id, is_correct, response, reviewed_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		item.SessionID,
+		item.WordID,
+		item.IsCorrect,
+		item.Response,
+		item.ReviewedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	item.ID = id
+	return nil
+}
+
+// reviewColumns are the columns scanReviewItems reads, in order
+const reviewColumns = "wri.id, wri.word_id, wri.session_id, wri.is_correct, wri.response, wri.reviewed_at"
+
+// scanReviewItems scans every row of a reviewColumns query
+func scanReviewItems(rows *sql.Rows) ([]models.WordReviewItem, error) {
+	defer rows.Close()
+
+	var items []models.WordReviewItem
+	for rows.Next() {
+		var item models.WordReviewItem
+		if err := rows.Scan(
+			&item.ID,
+			&item.WordID,
+			&item.SessionID,
+			&item.IsCorrect,
+			&item.Response,
+			&item.ReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *reviewRepo) ListBySession(sessionID int64) ([]models.WordReviewItem, error) {
+	rows, err := r.q.Query(`
+		SELECT `+reviewColumns+`
+		FROM word_review_items wri
+		WHERE wri.session_id = ?
+		ORDER BY wri.reviewed_at ASC, wri.id ASC`,
+		sessionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanReviewItems(rows)
+}
+
+func (r *reviewRepo) List(sessionID int64, filter repository.ReviewFilter, page pagination.Request) (*pagination.Page[models.WordReviewItem], error) {
+	l := listing{
+		from:    " FROM word_review_items wri",
+		spec:    repository.ReviewListing,
+		columns: map[string]string{"id": "wri.id", "reviewed_at": "wri.reviewed_at"},
+		dialect: r.dialect,
+	}
+	l.filter("wri.session_id = ?", sessionID)
+	if filter.Correct != nil {
+		l.filter("wri.is_correct = ?", *filter.Correct)
+	}
+
+	total, err := l.count(r.q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := l.query(r.q, reviewColumns, page)
+	if err != nil {
+		return nil, err
+	}
+	items, err := scanReviewItems(rows)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.NewPage(page, items, total), nil
+}
+
+func (r *reviewRepo) Counts(userID int64, wordIDs []int64) (map[int64]repository.ReviewCounts, error) {
+	counts := make(map[int64]repository.ReviewCounts, len(wordIDs))
+	if len(wordIDs) == 0 {
+		return counts, nil
+	}
+
+	placeholders, args := inClause(wordIDs)
+	rows, err := r.q.Query(`
+		SELECT wri.word_id,
+			SUM(CASE WHEN wri.is_correct THEN 1 ELSE 0 END),
+			SUM(CASE WHEN wri.is_correct THEN 0 ELSE 1 END)
+		FROM word_review_items wri
+		JOIN study_sessions ss ON ss.id = wri.session_id
+		WHERE ss.user_id = ? AND wri.word_id IN (`+placeholders+`)
+		GROUP BY wri.word_id`,
+		append([]any{userID}, args...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var wordID int64
+		var c repository.ReviewCounts
+		if err := rows.Scan(&wordID, &c.Correct, &c.Wrong); err != nil {
+			return nil, err
+		}
+		counts[wordID] = c
+	}
+	return counts, rows.Err()
+}
+
+func (r *reviewRepo) Schedule(userID, wordID int64) (*models.ReviewSchedule, error) {
+	schedule := models.ReviewSchedule{UserID: userID, WordID: wordID}
+	err := r.q.QueryRow(`
+		SELECT ease_factor, interval_days, repetitions, due_at, last_reviewed_at
+		FROM word_review_schedules
+		WHERE user_id = ? AND word_id = ?`,
+		userID, wordID,
+	).Scan(
+		&schedule.EaseFactor,
+		&schedule.IntervalDays,
+		&schedule.Repetitions,
+		&schedule.DueAt,
+		&schedule.LastReviewedAt,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &schedule, nil
+}
+
+func (r *reviewRepo) SaveSchedule(schedule models.ReviewSchedule) error {
+	_, err := r.q.Exec(`
+		INSERT INTO word_review_schedules
+			(user_id, word_id, ease_factor, interval_days, repetitions, due_at, last_reviewed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, word_id) DO UPDATE SET
+			ease_factor = excluded.ease_factor,
+			interval_days = excluded.interval_days,
+			repetitions = excluded.repetitions,
+			due_at = excluded.due_at,
+			last_reviewed_at = excluded.last_reviewed_at`,
+		schedule.UserID,
+		schedule.WordID,
+		schedule.EaseFactor,
+		schedule.IntervalDays,
+		schedule.Repetitions,
+		schedule.DueAt,
+		schedule.LastReviewedAt,
+	)
+	return err
+}
+
+// Due compares due_at as text on SQLite, so before must be normalized the
+// same way as the stored schedule times. The group is cast so Postgres can
+// type the parameter when it is NULL.
+func (r *reviewRepo) Due(userID int64, groupID *int64, before time.Time, limit int) ([]models.DueWord, error) {
+	rows, err := r.q.Query(`
+		SELECT w.id, w.japanese, w.romaji, w.english, w.parts,
+			rs.ease_factor, rs.interval_days, rs.repetitions, rs.due_at, rs.last_reviewed_at
+		FROM words w
+		LEFT JOIN word_review_schedules rs ON rs.word_id = w.id AND rs.user_id = ?
+		WHERE (rs.word_id IS NULL OR rs.due_at <= ?)
+			AND (CAST(? AS BIGINT) IS NULL OR EXISTS (
+				SELECT 1 FROM word_groups wg WHERE wg.word_id = w.id AND wg.group_id = ?
+			))
+		ORDER BY rs.word_id IS NULL, rs.due_at, w.id
+		LIMIT ?`,
+		userID, before, groupID, groupID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var words []models.DueWord
+	for rows.Next() {
+		var word models.DueWord
+		var partsJSON string
+		var easeFactor sql.NullFloat64
+		var intervalDays, repetitions sql.NullInt64
+
+		if err := rows.Scan(
+			&word.ID,
+			&word.Japanese,
+			&word.Romaji,
+			&word.English,
+			&partsJSON,
+			&easeFactor,
+			&intervalDays,
+			&repetitions,
+			&word.DueAt,
+			&word.LastReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(partsJSON), &word.Parts); err != nil {
+			return nil, err
+		}
+
+		word.IsNew = !easeFactor.Valid
+		if easeFactor.Valid {
+			word.EaseFactor = easeFactor.Float64
+			word.IntervalDays = int(intervalDays.Int64)
+			word.Repetitions = int(repetitions.Int64)
+		}
+
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
@@ -0,0 +1,293 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// activityColumns are the columns scanActivity reads, in order. Queries
+// join the activity's app as a.
+const activityColumns = `sa.id, sa.group_id, sa.activity_type, sa.app_id,
+	COALESCE(a.name, ''), COALESCE(a.thumbnail_url, ''), sa.created_at`
+
+// activityFrom joins each activity to its app, if any
+const activityFrom = " FROM study_activities sa LEFT JOIN activity_apps a ON a.id = sa.app_id"
+
+// activityRepo implements repository.ActivityRepository
+type activityRepo struct {
+	q       querier
+	dialect dialect.Dialect
+}
+
+func scanActivity(row interface{ Scan(...any) error }) (models.StudyActivity, error) {
+	var activity models.StudyActivity
+	err := row.Scan(
+		&activity.ID,
+		&activity.GroupID,
+		&activity.ActivityType,
+		&activity.AppID,
+		&activity.Name,
+		&activity.ThumbnailURL,
+		&activity.CreatedAt,
+	)
+	return activity, err
+}
+
+func (r *activityRepo) Get(id int64) (*models.StudyActivity, error) {
+	activity, err := scanActivity(r.q.QueryRow("SELECT "+activityColumns+activityFrom+" WHERE sa.id = ?", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &activity, nil
+}
+
+func (r *activityRepo) List(filter repository.ActivityFilter, page pagination.Request) (*pagination.Page[models.StudyActivity], error) {
+	l := listing{
+		from:    activityFrom,
+		spec:    repository.ActivityListing,
+		columns: map[string]string{"id": "sa.id", "created_at": "sa.created_at"},
+		dialect: r.dialect,
+	}
+	if filter.GroupID != nil {
+		l.filter("sa.group_id = ?", *filter.GroupID)
+	}
+	if filter.ActivityType != "" {
+		l.filter("sa.activity_type = ?", filter.ActivityType)
+	}
+
+	total, err := l.count(r.q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := l.query(r.q, activityColumns, page)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []models.StudyActivity
+	for rows.Next() {
+		activity, err := scanActivity(rows)
+		if err != nil {
+			return nil, err
+		}
+		activities = append(activities, activity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return pagination.NewPage(page, activities, total), nil
+}
+
+func (r *activityRepo) FindByApp(appID, groupID int64) (*models.StudyActivity, error) {
+	activity, err := scanActivity(r.q.QueryRow(
+		"SELECT "+activityColumns+activityFrom+" WHERE sa.app_id = ? AND sa.group_id = ? ORDER BY sa.id LIMIT 1",
+		appID, groupID,
+	))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &activity, nil
+}
+
+func (r *activityRepo) Create(activity *models.StudyActivity) error {
+	id, err := insert(r.q, `
+		INSERT INTO study_activities (group_id, activity_type, app_id, created_at)
+		VALUES (?, ?, ?, ?)`,
+		activity.GroupID,
+		activity.ActivityType,
+		activity.AppID,
+		activity.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	activity.ID = id
+	return nil
+}
+
+func (r *activityRepo) Update(activity *models.StudyActivity) error {
+	result, err := r.q.Exec(`
+		UPDATE study_activities
+		SET group_id = ?, activity_type = ?, app_id = ?
+		WHERE id = ?`,
+		activity.GroupID,
+		activity.ActivityType,
+		activity.AppID,
+		activity.ID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+// Delete relies on ON DELETE CASCADE for the activity's sessions
+func (r *activityRepo) Delete(id int64) error {
+	result, err := r.q.Exec("DELETE FROM study_activities WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+// sessionColumns are the columns scanSession reads, in order
+const sessionColumns = "ss.id, ss.start_time, ss.end_time, ss.score, ss.score_breakdown, ss.status, ss.study_activity_id, ss.user_id"
+
+// sessionRepo implements repository.SessionRepository
+type sessionRepo struct {
+	q       querier
+	dialect dialect.Dialect
+}
+
+// scanSession scans sessionColumns and decodes the JSON score breakdown
+func scanSession(row interface{ Scan(...any) error }) (models.StudySession, error) {
+	var session models.StudySession
+	var breakdownJSON *string
+	err := row.Scan(
+		&session.ID,
+		&session.StartTime,
+		&session.EndTime,
+		&session.Score,
+		&breakdownJSON,
+		&session.Status,
+		&session.StudyActivityID,
+		&session.UserID,
+	)
+	if err != nil || breakdownJSON == nil {
+		return session, err
+	}
+	session.ScoreBreakdown = &models.ScoreBreakdown{}
+	return session, json.Unmarshal([]byte(*breakdownJSON), session.ScoreBreakdown)
+}
+
+// encodeBreakdown returns a score breakdown as JSON, or nil for none
+func encodeBreakdown(breakdown *models.ScoreBreakdown) (any, error) {
+	if breakdown == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(breakdown)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// scanSessions scans every row of a sessionColumns query
+func scanSessions(rows *sql.Rows) ([]models.StudySession, error) {
+	defer rows.Close()
+
+	var sessions []models.StudySession
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *sessionRepo) Get(id int64) (*models.StudySession, error) {
+	session, err := scanSession(r.q.QueryRow("SELECT "+sessionColumns+" FROM study_sessions ss WHERE ss.id = ?", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &session, nil
+}
+
+func (r *sessionRepo) List(userID int64, filter repository.SessionFilter, page pagination.Request) (*pagination.Page[models.StudySession], error) {
+	l := listing{
+		from:    " FROM study_sessions ss",
+		spec:    repository.SessionListing,
+		columns: map[string]string{"id": "ss.id", "start_time": "ss.start_time"},
+		dialect: r.dialect,
+	}
+	l.filter("ss.user_id = ?", userID)
+	if filter.ActivityID != nil {
+		l.filter("ss.study_activity_id = ?", *filter.ActivityID)
+	}
+	if filter.GroupID != nil {
+		l.filter("ss.study_activity_id IN (SELECT id FROM study_activities WHERE group_id = ?)", *filter.GroupID)
+	}
+	if filter.Status != "" {
+		l.filter("ss.status = ?", filter.Status)
+	}
+
+	total, err := l.count(r.q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := l.query(r.q, sessionColumns, page)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := scanSessions(rows)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.NewPage(page, sessions, total), nil
+}
+
+func (r *sessionRepo) ListActive() ([]models.StudySession, error) {
+	rows, err := r.q.Query(`
+		SELECT ` + sessionColumns + `
+		FROM study_sessions ss
+		WHERE ss.status = 'active'
+		ORDER BY ` + r.dialect.Timestamp("ss.start_time") + `, ss.id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanSessions(rows)
+}
+
+func (r *sessionRepo) Create(session *models.StudySession) error {
+	breakdown, err := encodeBreakdown(session.ScoreBreakdown)
+	if err != nil {
+		return err
+	}
+	id, err := insert(r.q, `
+		INSERT INTO study_sessions (start_time, end_time, score, score_breakdown, status, study_activity_id, user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		session.StartTime,
+		session.EndTime,
+		session.Score,
+		breakdown,
+		session.Status,
+		session.StudyActivityID,
+		session.UserID,
+	)
+	if err != nil {
+		return err
+	}
+
+	session.ID = id
+	return nil
+}
+
+func (r *sessionRepo) Close(session *models.StudySession) error {
+	breakdown, err := encodeBreakdown(session.ScoreBreakdown)
+	if err != nil {
+		return err
+	}
+	result, err := r.q.Exec(`
+		UPDATE study_sessions
+		SET end_time = ?, score = ?, score_breakdown = ?, status = ?
+		WHERE id = ? AND status = 'active'`,
+		session.EndTime,
+		session.Score,
+		breakdown,
+		session.Status,
+		session.ID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
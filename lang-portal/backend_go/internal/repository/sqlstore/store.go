@@ -0,0 +1,142 @@
+// Package sqlstore implements the repositories on top of the SQLite and
+// Postgres schemas in db/migrations
+package sqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// querier is satisfied by *sql.DB and *sql.Tx bound to the dialect, so
+// every repository runs the same way inside and outside a transaction
+type querier = dialect.Querier
+
+// Store is a repository.Store backed by a SQLite or Postgres database
+type Store struct {
+	db      *sql.DB
+	dialect dialect.Dialect
+	q       querier
+	inTx    bool
+}
+
+// New creates a Store on db, speaking the dialect of its driver
+func New(db *sql.DB) *Store {
+	d := dialect.Of(db)
+	return &Store{db: db, dialect: d, q: d.Bind(db)}
+}
+
+// Users returns the user repository
+func (s *Store) Users() repository.UserRepository {
+	return &userRepo{q: s.q}
+}
+
+// Tokens returns the API token repository
+func (s *Store) Tokens() repository.TokenRepository {
+	return &tokenRepo{q: s.q}
+}
+
+// Words returns the word repository
+func (s *Store) Words() repository.WordRepository {
+	return &wordRepo{q: s.q, dialect: s.dialect}
+}
+
+// Groups returns the group repository
+func (s *Store) Groups() repository.GroupRepository {
+	return &groupRepo{q: s.q}
+}
+
+// Apps returns the activity catalog repository
+func (s *Store) Apps() repository.AppRepository {
+	return &appRepo{q: s.q}
+}
+
+// Launches returns the app launch repository
+func (s *Store) Launches() repository.LaunchRepository {
+	return &launchRepo{q: s.q, dialect: s.dialect}
+}
+
+// Activities returns the study activity repository
+func (s *Store) Activities() repository.ActivityRepository {
+	return &activityRepo{q: s.q, dialect: s.dialect}
+}
+
+// Sessions returns the study session repository
+func (s *Store) Sessions() repository.SessionRepository {
+	return &sessionRepo{q: s.q, dialect: s.dialect}
+}
+
+// Reviews returns the review repository
+func (s *Store) Reviews() repository.ReviewRepository {
+	return &reviewRepo{q: s.q, dialect: s.dialect}
+}
+
+// Dashboard returns the dashboard repository
+func (s *Store) Dashboard() repository.DashboardRepository {
+	return &dashboardRepo{q: s.q, dialect: s.dialect}
+}
+
+// System returns the system repository
+func (s *Store) System() repository.SystemRepository {
+	return &systemRepo{db: s.db, q: s.q, dialect: s.dialect}
+}
+
+// Atomic runs fn inside a transaction
+func (s *Store) Atomic(fn func(repository.Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Store{db: s.db, dialect: s.dialect, q: s.dialect.Bind(tx), inTx: true}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// expectAffected returns repository.ErrNotFound when a statement changed
+// no rows
+func expectAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// notFound converts sql.ErrNoRows into repository.ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
+// insert runs an INSERT statement and returns the id of the new row.
+// RETURNING works on both SQLite and Postgres, unlike LastInsertId.
+func insert(q querier, query string, args ...any) (int64, error) {
+	var id int64
+	err := q.QueryRow(query+" RETURNING id", args...).Scan(&id)
+	return id, err
+}
+
+// inClause returns the placeholders and arguments for an IN (...) list
+func inClause(ids []int64) (string, []any) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return placeholders, args
+}
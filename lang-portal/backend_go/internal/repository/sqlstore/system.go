@@ -0,0 +1,133 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// systemRepo implements repository.SystemRepository
+type systemRepo struct {
+	db      *sql.DB
+	q       querier
+	dialect dialect.Dialect
+}
+
+// healthTables are the tables Check reads
+var healthTables = []string{"words", "groups", "word_groups", "study_sessions", "study_activities"}
+
+func (r *systemRepo) Stats() (*models.SystemStats, error) {
+	var stats models.SystemStats
+
+	err := r.q.QueryRow("SELECT COUNT(*) FROM words").Scan(&stats.TotalWords)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.q.QueryRow("SELECT COUNT(*) FROM groups").Scan(&stats.TotalGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.q.QueryRow("SELECT COUNT(*) FROM study_sessions").Scan(&stats.TotalSessions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.q.QueryRow(`
+		SELECT COALESCE(AVG(score), 0) 
+		FROM study_sessions 
+		WHERE status = 'completed'
+	`).Scan(&stats.AverageSessionScore)
+	if err != nil {
+		return nil, err
+	}
+
+	// Study time in whole minutes per session
+	err = r.q.QueryRow(`
+		SELECT COALESCE(SUM(CAST(
+			ROUND(` + r.dialect.Seconds("start_time", "end_time") + `) AS INTEGER
+		) / 60), 0)
+		FROM study_sessions 
+		WHERE status = 'completed'
+	`).Scan(&stats.TotalStudyTimeMinutes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+func (r *systemRepo) Check() error {
+	if err := r.db.Ping(); err != nil {
+		return fmt.Errorf("database connection error: %w", err)
+	}
+	for _, table := range healthTables {
+		var count int
+		if err := r.q.QueryRow("SELECT COUNT(*) FROM " + table + " LIMIT 1").Scan(&count); err != nil {
+			return fmt.Errorf("table check error for %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func (r *systemRepo) Size() (int64, error) {
+	if r.dialect == dialect.Postgres {
+		var size int64
+		err := r.q.QueryRow("SELECT pg_database_size(current_database())").Scan(&size)
+		return size, err
+	}
+
+	var pageCount, pageSize int64
+	if err := r.q.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, err
+	}
+	if err := r.q.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pageCount * pageSize, nil
+}
+
+// Backup is only supported on SQLite; Postgres is backed up with pg_dump
+func (r *systemRepo) Backup(path string) error {
+	if r.dialect != dialect.SQLite {
+		return repository.ErrUnsupported
+	}
+	_, err := r.q.Exec("VACUUM INTO ?", path)
+	return err
+}
+
+func (r *systemRepo) LastBackup() (*models.BackupInfo, error) {
+	var info models.BackupInfo
+	err := r.q.QueryRow(`
+		SELECT backup_path, created_at, size_bytes
+		FROM backup_history
+		ORDER BY `+r.dialect.Timestamp("created_at")+` DESC
+		LIMIT 1
+	`).Scan(&info.Path, &info.CreatedAt, &info.SizeBytes)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &info, nil
+}
+
+// PruneSessions also deletes answers left without a session
+func (r *systemRepo) PruneSessions(cutoff time.Time) error {
+	_, err := r.q.Exec(`
+		DELETE FROM study_sessions
+		WHERE end_time < ? AND status = 'completed'
+	`, cutoff)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.q.Exec(`
+		DELETE FROM word_review_items
+		WHERE session_id NOT IN (SELECT id FROM study_sessions)
+	`)
+	return err
+}
@@ -0,0 +1,65 @@
+package sqlstore
+
+import (
+	"github.com/erans/lang-portal/internal/models"
+)
+
+// tokenRepo implements repository.TokenRepository
+type tokenRepo struct {
+	q querier
+}
+
+const tokenColumns = "id, user_id, name, token_hash, created_at, expires_at"
+
+func scanToken(row interface{ Scan(...any) error }) (models.APIToken, error) {
+	var token models.APIToken
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Hash, &token.CreatedAt, &token.ExpiresAt)
+	return token, err
+}
+
+func (r *tokenRepo) GetByHash(hash string) (*models.APIToken, error) {
+	token, err := scanToken(r.q.QueryRow("SELECT "+tokenColumns+" FROM api_tokens WHERE token_hash = ?", hash))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+func (r *tokenRepo) List(userID int64) ([]models.APIToken, error) {
+	rows, err := r.q.Query("SELECT "+tokenColumns+" FROM api_tokens WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []models.APIToken
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *tokenRepo) Create(token *models.APIToken) error {
+	id, err := insert(r.q,
+		"INSERT INTO api_tokens (user_id, name, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		token.UserID, token.Name, token.Hash, token.CreatedAt, token.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	token.ID = id
+	return nil
+}
+
+func (r *tokenRepo) Delete(userID, id int64) error {
+	result, err := r.q.Exec("DELETE FROM api_tokens WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
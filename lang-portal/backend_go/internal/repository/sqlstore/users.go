@@ -0,0 +1,98 @@
+package sqlstore
+
+import (
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// userRepo implements repository.UserRepository
+type userRepo struct {
+	q querier
+}
+
+const userColumns = "id, name, role, created_at"
+
+func scanUser(row interface{ Scan(...any) error }) (models.User, error) {
+	var user models.User
+	err := row.Scan(&user.ID, &user.Name, &user.Role, &user.CreatedAt)
+	return user, err
+}
+
+func (r *userRepo) Get(id int64) (*models.User, error) {
+	user, err := scanUser(r.q.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ?", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepo) GetByName(name string) (*models.User, error) {
+	user, err := scanUser(r.q.QueryRow("SELECT "+userColumns+" FROM users WHERE name = ?", name))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepo) List(page pagination.Request) (*pagination.Page[models.User], error) {
+	l := listing{
+		from:    " FROM users",
+		spec:    repository.UserListing,
+		columns: map[string]string{"id": "id", "name": "name"},
+	}
+
+	total, err := l.count(r.q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := l.query(r.q, userColumns, page)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return pagination.NewPage(page, users, total), nil
+}
+
+func (r *userRepo) Create(user *models.User) error {
+	id, err := insert(r.q,
+		"INSERT INTO users (name, role, created_at) VALUES (?, ?, ?)",
+		user.Name, user.Role, user.CreatedAt,
+	)
+	if err != nil {
+		if dialect.IsUniqueViolation(err) {
+			return repository.ErrDuplicate
+		}
+		return err
+	}
+
+	user.ID = id
+	return nil
+}
+
+func (r *userRepo) SetRole(id int64, role string) error {
+	result, err := r.q.Exec("UPDATE users SET role = ? WHERE id = ?", role, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (r *userRepo) CountRole(role string) (int64, error) {
+	var count int64
+	err := r.q.QueryRow("SELECT COUNT(*) FROM users WHERE role = ?", role).Scan(&count)
+	return count, err
+}
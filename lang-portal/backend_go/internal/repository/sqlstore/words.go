@@ -0,0 +1,295 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// minTrigramLength is the shortest term the trigram index can match;
+// shorter terms fall back to a substring scan
+const minTrigramLength = 3
+
+// wordColumns are the columns scanWord reads, in order
+const wordColumns = "w.id, w.japanese, w.romaji, w.english, w.parts"
+
+// wordSortColumns are the columns of the WordListing sort fields
+var wordSortColumns = map[string]string{
+	"id":       "w.id",
+	"japanese": "w.japanese",
+	"romaji":   "w.romaji",
+	"english":  "w.english",
+}
+
+// wordRepo implements repository.WordRepository
+type wordRepo struct {
+	q       querier
+	dialect dialect.Dialect
+}
+
+// scanWord scans wordColumns and decodes the JSON parts
+func scanWord(row interface{ Scan(...any) error }) (models.Word, error) {
+	var word models.Word
+	var partsJSON string
+	if err := row.Scan(&word.ID, &word.Japanese, &word.Romaji, &word.English, &partsJSON); err != nil {
+		return word, err
+	}
+	if err := json.Unmarshal([]byte(partsJSON), &word.Parts); err != nil {
+		return word, err
+	}
+	return word, nil
+}
+
+// scanWords scans every row of a wordColumns query
+func scanWords(rows *sql.Rows) ([]models.Word, error) {
+	defer rows.Close()
+
+	var words []models.Word
+	for rows.Next() {
+		word, err := scanWord(rows)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+func (r *wordRepo) Get(id int64) (*models.Word, error) {
+	word, err := scanWord(r.q.QueryRow("SELECT "+wordColumns+" FROM words w WHERE w.id = ?", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &word, nil
+}
+
+func (r *wordRepo) FindByText(japanese, english string) (*models.Word, error) {
+	word, err := scanWord(r.q.QueryRow(
+		"SELECT "+wordColumns+" FROM words w WHERE w.japanese = ? AND w.english = ? ORDER BY w.id LIMIT 1",
+		japanese, english,
+	))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &word, nil
+}
+
+func (r *wordRepo) List(filter repository.WordFilter, page pagination.Request) (*pagination.Page[models.Word], error) {
+	l := listing{from: " FROM words w", spec: repository.WordListing, columns: wordSortColumns}
+	if filter.GroupID != nil {
+		l.filter("EXISTS (SELECT 1 FROM word_groups wg WHERE wg.word_id = w.id AND wg.group_id = ?)", *filter.GroupID)
+	}
+
+	total, err := l.count(r.q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := l.query(r.q, wordColumns, page)
+	if err != nil {
+		return nil, err
+	}
+	words, err := scanWords(rows)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.NewPage(page, words, total), nil
+}
+
+// match is the dialect-specific part of a search: the FROM and WHERE
+// clauses selecting matching words and a relevance ordering
+type match struct {
+	from  string
+	where []string
+	args  []any
+	// rank orders matches by relevance, best first; empty when the
+	// dialect has nothing beyond exactness to rank by
+	rank     string
+	rankArgs []any
+}
+
+// Search ranks matches by exactness, then by the dialect's relevance
+func (r *wordRepo) Search(search repository.WordSearch, offset, limit int) ([]models.Word, int64, error) {
+	var m match
+	if r.dialect == dialect.Postgres {
+		m = trigramMatch(search)
+	} else {
+		m = ftsMatch(search)
+	}
+
+	for key, value := range search.Parts {
+		if r.dialect == dialect.Postgres {
+			m.where = append(m.where, "w.parts -> ? = to_jsonb(CAST(? AS TEXT))")
+			m.args = append(m.args, key, value)
+		} else {
+			m.where = append(m.where, "json_extract(w.parts, ?) = ?")
+			m.args = append(m.args, `$."`+key+`"`, value)
+		}
+	}
+
+	clause := m.from + whereClause(m.where)
+
+	var total int64
+	if err := r.q.QueryRow("SELECT COUNT(*) "+clause, m.args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := "(lower(w.japanese) = lower(?) OR lower(w.romaji) = lower(?) OR lower(w.english) = lower(?)) DESC"
+	whole := strings.Join(search.Terms, " ")
+	orderArgs := []any{whole, whole, whole}
+	if m.rank != "" {
+		order += ", " + m.rank
+		orderArgs = append(orderArgs, m.rankArgs...)
+	}
+	order += ", w.id"
+
+	queryArgs := append(append(append([]any{}, m.args...), orderArgs...), limit, offset)
+	rows, err := r.q.Query(
+		"SELECT "+wordColumns+" "+clause+" ORDER BY "+order+" LIMIT ? OFFSET ?",
+		queryArgs...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	words, err := scanWords(rows)
+	return words, total, err
+}
+
+// ftsMatch matches terms of at least minTrigramLength characters through
+// the SQLite words_fts trigram index and shorter ones with LIKE, ranking by
+// bm25
+func ftsMatch(search repository.WordSearch) match {
+	m := match{from: "FROM words w"}
+
+	var ftsTerms []string
+	for _, term := range search.Terms {
+		if utf8.RuneCountInString(term) >= minTrigramLength {
+			ftsTerms = append(ftsTerms, ftsPhrase(term))
+			continue
+		}
+
+		pattern := "%" + escapeLike(term) + "%"
+		m.where = append(m.where, `(w.japanese LIKE ? ESCAPE '\' OR w.romaji LIKE ? ESCAPE '\' OR w.english LIKE ? ESCAPE '\')`)
+		m.args = append(m.args, pattern, pattern, pattern)
+	}
+
+	if len(ftsTerms) > 0 {
+		m.from += " JOIN words_fts ON words_fts.rowid = w.id"
+		m.where = append([]string{"words_fts MATCH ?"}, m.where...)
+		m.args = append([]any{strings.Join(ftsTerms, " AND ")}, m.args...)
+		m.rank = "bm25(words_fts)"
+	}
+	return m
+}
+
+// trigramMatch matches every term with ILIKE, which the Postgres pg_trgm
+// indexes serve for terms of at least minTrigramLength characters, ranking
+// by trigram similarity to the whole query
+func trigramMatch(search repository.WordSearch) match {
+	m := match{from: "FROM words w"}
+
+	for _, term := range search.Terms {
+		pattern := "%" + escapeLike(term) + "%"
+		m.where = append(m.where, `(w.japanese ILIKE ? ESCAPE '\' OR w.romaji ILIKE ? ESCAPE '\' OR w.english ILIKE ? ESCAPE '\')`)
+		m.args = append(m.args, pattern, pattern, pattern)
+	}
+
+	if len(search.Terms) > 0 {
+		whole := strings.Join(search.Terms, " ")
+		m.rank = "GREATEST(similarity(w.japanese, ?), similarity(w.romaji, ?), similarity(w.english, ?)) DESC"
+		m.rankArgs = []any{whole, whole, whole}
+	}
+	return m
+}
+
+func (r *wordRepo) Missing(ids []int64) ([]int64, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders, args := inClause(ids)
+	rows, err := r.q.Query("SELECT id FROM words WHERE id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[int64]bool, len(ids))
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []int64
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+func (r *wordRepo) Create(word *models.Word) error {
+	partsJSON, err := json.Marshal(word.Parts)
+	if err != nil {
+		return err
+	}
+
+	id, err := insert(r.q,
+		"INSERT INTO words (japanese, romaji, english, parts) VALUES (?, ?, ?, ?)",
+		word.Japanese, word.Romaji, word.English, string(partsJSON),
+	)
+	if err != nil {
+		return err
+	}
+
+	word.ID = id
+	return nil
+}
+
+func (r *wordRepo) Update(word *models.Word) error {
+	partsJSON, err := json.Marshal(word.Parts)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.q.Exec(
+		"UPDATE words SET japanese = ?, romaji = ?, english = ?, parts = ? WHERE id = ?",
+		word.Japanese, word.Romaji, word.English, string(partsJSON), word.ID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+// Delete relies on ON DELETE CASCADE for memberships, reviews and schedule
+func (r *wordRepo) Delete(id int64) error {
+	result, err := r.q.Exec("DELETE FROM words WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+// ftsPhrase quotes a term as an FTS5 string so operators and punctuation in
+// user input are matched literally
+func ftsPhrase(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+}
+
+// escapeLike escapes LIKE and ILIKE wildcards in a term
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
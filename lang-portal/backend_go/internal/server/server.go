@@ -0,0 +1,124 @@
+// Package server runs the HTTP API and owns its lifecycle: it serves until
+// its context is cancelled, drains in-flight requests and stops background
+// workers, then checkpoints and closes the database.
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/erans/lang-portal/internal/database"
+)
+
+// Server serves an HTTP handler backed by a database
+type Server struct {
+	http            *http.Server
+	db              *sql.DB
+	shutdownTimeout time.Duration
+	workers         []func(ctx context.Context)
+}
+
+// New creates a Server listening on addr. The server takes ownership of db
+// and closes it when it stops.
+func New(addr string, handler http.Handler, db *sql.DB, shutdownTimeout time.Duration) *Server {
+	return &Server{
+		http: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		db:              db,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+// RegisterOnShutdown registers a function to call when shutdown begins,
+// e.g. to stop long-running requests
+func (s *Server) RegisterOnShutdown(f func()) {
+	s.http.RegisterOnShutdown(f)
+}
+
+// Background runs work alongside the server once it starts serving. The
+// context passed to work is cancelled when shutdown begins, and the
+// database is closed only after work returns.
+func (s *Server) Background(work func(ctx context.Context)) {
+	s.workers = append(s.workers, work)
+}
+
+// Run listens on the configured address and serves until ctx is cancelled
+// or the listener fails, then shuts down
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to listen: %w", err), s.closeDB())
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves on ln until ctx is cancelled or the listener fails. It then
+// stops accepting connections and background workers, waits up to the
+// shutdown timeout for in-flight requests, waits for the workers, and
+// checkpoints and closes the database.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	log.Printf("Server listening on %s", ln.Addr())
+
+	workCtx, stopWork := context.WithCancel(ctx)
+	defer stopWork()
+	var workers sync.WaitGroup
+	for _, work := range s.workers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			work(workCtx)
+		}()
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.http.Serve(ln)
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		// The listener failed before a shutdown was requested
+		err = fmt.Errorf("server stopped: %w", err)
+	case <-ctx.Done():
+		log.Printf("Shutting down, waiting up to %s for in-flight requests...", s.shutdownTimeout)
+		err = s.shutdown()
+	}
+
+	stopWork()
+	workers.Wait()
+	return errors.Join(err, s.closeDB())
+}
+
+// shutdown drains in-flight requests, forcibly closing the connections
+// that outlive the shutdown timeout
+func (s *Server) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	if err := s.http.Shutdown(ctx); err != nil {
+		s.http.Close()
+		return fmt.Errorf("in-flight requests did not finish within %s: %w", s.shutdownTimeout, err)
+	}
+	log.Println("All in-flight requests finished")
+	return nil
+}
+
+// closeDB checkpoints the write-ahead log and closes the database
+func (s *Server) closeDB() error {
+	if err := database.Shutdown(s.db); err != nil {
+		return err
+	}
+	log.Println("Database closed")
+	return nil
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+var (
+	// ErrAppNotFound is returned when an app is not in the catalog
+	ErrAppNotFound = NewNotFoundError("activity_app_not_found", "activity app not found")
+	// ErrAppNameTaken is returned when another app has the same name
+	ErrAppNameTaken = NewConflictError("activity_app_name_taken", "an activity app with this name already exists")
+)
+
+// LaunchPlaceholders are the {placeholders} a launch URL template may use
+var LaunchPlaceholders = []string{"group_id", "group_name", "session_id", "user_id", "activity_id"}
+
+// placeholder matches a {name} in a launch URL template
+var placeholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// ExpandLaunchURL fills in the placeholders of a launch URL template. Values
+// are escaped for use anywhere in a URL; a placeholder without a value is
+// an error.
+func ExpandLaunchURL(template string, values map[string]string) (string, error) {
+	var missing []string
+	expanded := placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		name := match[1 : len(match)-1]
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+			return match
+		}
+		return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("unknown launch URL placeholder {%s}", strings.Join(missing, "}, {"))
+	}
+	return expanded, nil
+}
+
+// LaunchResult is a launch with the session it started
+type LaunchResult struct {
+	models.ActivityLaunch
+	Session models.StudySession `json:"session"`
+}
+
+// ActivityAppService manages the activity catalog and launches its apps
+type ActivityAppService struct {
+	store repository.Store
+}
+
+// NewActivityAppService creates a new ActivityAppService
+func NewActivityAppService(store repository.Store) *ActivityAppService {
+	return &ActivityAppService{store: store}
+}
+
+// GetApp retrieves an app by ID
+func (s *ActivityAppService) GetApp(id int64) (*models.ActivityApp, error) {
+	app, err := s.store.Apps().Get(id)
+	if err != nil {
+		return nil, orNotFound(err, ErrAppNotFound)
+	}
+	return app, nil
+}
+
+// ListApps retrieves a page of the catalog
+func (s *ActivityAppService) ListApps(page pagination.Request) (*pagination.Page[models.ActivityApp], error) {
+	return s.store.Apps().List(page)
+}
+
+// CreateApp adds an app to the catalog
+func (s *ActivityAppService) CreateApp(app *models.ActivityApp) error {
+	app.CreatedAt = time.Now()
+	return appNameTaken(s.store.Apps().Create(app))
+}
+
+// UpdateApp updates an app in the catalog
+func (s *ActivityAppService) UpdateApp(app *models.ActivityApp) error {
+	return orNotFound(appNameTaken(s.store.Apps().Update(app)), ErrAppNotFound)
+}
+
+// DeleteApp removes an app and its launches. Activities opened from it
+// stay, without a link to the app.
+func (s *ActivityAppService) DeleteApp(id int64) error {
+	return orNotFound(s.store.Apps().Delete(id), ErrAppNotFound)
+}
+
+// appNameTaken replaces repository.ErrDuplicate with ErrAppNameTaken
+func appNameTaken(err error) error {
+	if errors.Is(err, repository.ErrDuplicate) {
+		return ErrAppNameTaken
+	}
+	return err
+}
+
+// Launch opens an app for the user on a group. It reuses the app's study
+// activity for the group, creating it on first launch, starts a session
+// and records the launch with the app's launch URL filled in.
+func (s *ActivityAppService) Launch(userID, appID, groupID int64) (*LaunchResult, error) {
+	var result LaunchResult
+	err := s.store.Atomic(func(store repository.Store) error {
+		app, err := store.Apps().Get(appID)
+		if err != nil {
+			return orNotFound(err, ErrAppNotFound)
+		}
+		group, err := store.Groups().Get(groupID)
+		if err != nil {
+			return orNotFound(err, ErrGroupNotFound)
+		}
+
+		now := time.Now()
+		activity, err := store.Activities().FindByApp(app.ID, group.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			activity = &models.StudyActivity{
+				GroupID:      group.ID,
+				ActivityType: app.ActivityType,
+				AppID:        &app.ID,
+				CreatedAt:    now,
+			}
+			err = store.Activities().Create(activity)
+		}
+		if err != nil {
+			return err
+		}
+
+		session := models.StudySession{
+			StudyActivityID: activity.ID,
+			UserID:          userID,
+			StartTime:       now,
+			Status:          models.SessionActive,
+		}
+		if err := store.Sessions().Create(&session); err != nil {
+			return err
+		}
+
+		launchURL, err := ExpandLaunchURL(app.LaunchURL, map[string]string{
+			"group_id":    strconv.FormatInt(group.ID, 10),
+			"group_name":  group.Name,
+			"session_id":  strconv.FormatInt(session.ID, 10),
+			"user_id":     strconv.FormatInt(userID, 10),
+			"activity_id": strconv.FormatInt(activity.ID, 10),
+		})
+		if err != nil {
+			return err
+		}
+
+		result = LaunchResult{
+			ActivityLaunch: models.ActivityLaunch{
+				AppID:      app.ID,
+				UserID:     userID,
+				GroupID:    group.ID,
+				SessionID:  session.ID,
+				LaunchURL:  launchURL,
+				LaunchedAt: now,
+			},
+			Session: session,
+		}
+		return store.Launches().Create(&result.ActivityLaunch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListLaunches retrieves a page of the user's launches
+func (s *ActivityAppService) ListLaunches(userID int64, page pagination.Request) (*pagination.Page[models.ActivityLaunch], error) {
+	return s.store.Launches().List(userID, page)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// tokenPrefix marks API token secrets so they are recognizable in logs and
+// secret scanners
+const tokenPrefix = "lp_"
+
+var (
+	// ErrAuthenticationRequired is returned when a request needs a token
+	// and has none
+	ErrAuthenticationRequired = NewUnauthenticatedError("authentication_required", "authentication required")
+	// ErrInvalidToken is returned for a token that is unknown, revoked or
+	// expired
+	ErrInvalidToken = NewUnauthenticatedError("invalid_token", "invalid or expired token")
+	// ErrForbidden is returned when the acting user's role does not allow
+	// an operation
+	ErrForbidden = NewForbiddenError("forbidden", "your role does not allow this operation")
+	// ErrTokenNotFound is returned when a user has no token with the ID
+	ErrTokenNotFound = NewNotFoundError("token_not_found", "token not found")
+)
+
+// IssuedToken is a newly issued API token with its secret. The secret is
+// not stored and cannot be shown again.
+type IssuedToken struct {
+	models.APIToken
+	Token string `json:"token"`
+}
+
+// AuthService issues API tokens and resolves them to users
+type AuthService struct {
+	store repository.Store
+}
+
+// NewAuthService creates a new AuthService
+func NewAuthService(store repository.Store) *AuthService {
+	return &AuthService{store: store}
+}
+
+// Authenticate returns the user a token secret belongs to. Unknown and
+// expired tokens fail with ErrInvalidToken.
+func (s *AuthService) Authenticate(secret string) (*models.User, error) {
+	token, err := s.store.Tokens().GetByHash(hashToken(secret))
+	if err != nil {
+		return nil, orNotFound(err, ErrInvalidToken)
+	}
+	if token.ExpiresAt != nil && !time.Now().Before(*token.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.store.Users().Get(token.UserID)
+	if err != nil {
+		return nil, orNotFound(err, ErrInvalidToken)
+	}
+	return user, nil
+}
+
+// Anonymous returns the user requests without a token act for: the
+// default user, with no more than learner rights whatever their role
+func (s *AuthService) Anonymous() (*models.User, error) {
+	user, err := s.store.Users().Get(models.DefaultUserID)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = models.RoleLearner
+	return user, nil
+}
+
+// IssueToken creates a token for a user. A nil expiresAt issues a token
+// that is valid until it is revoked.
+func (s *AuthService) IssueToken(userID int64, name string, expiresAt *time.Time) (*IssuedToken, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	token := models.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Hash:      hashToken(secret),
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	err = s.store.Atomic(func(store repository.Store) error {
+		if _, err := store.Users().Get(userID); err != nil {
+			return orNotFound(err, ErrUserNotFound)
+		}
+		return store.Tokens().Create(&token)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &IssuedToken{APIToken: token, Token: secret}, nil
+}
+
+// ListTokens retrieves a user's tokens without their secrets
+func (s *AuthService) ListTokens(userID int64) ([]models.APIToken, error) {
+	if _, err := s.store.Users().Get(userID); err != nil {
+		return nil, orNotFound(err, ErrUserNotFound)
+	}
+	return s.store.Tokens().List(userID)
+}
+
+// RevokeToken deletes one of a user's tokens
+func (s *AuthService) RevokeToken(userID, tokenID int64) error {
+	return orNotFound(s.store.Tokens().Delete(userID, tokenID), ErrTokenNotFound)
+}
+
+// newSecret returns a random token secret
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 digest a token secret is stored as
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
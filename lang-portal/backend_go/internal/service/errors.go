@@ -0,0 +1,101 @@
+package service
+
+import "errors"
+
+// Kind classifies a service error independently of its message
+type Kind int
+
+const (
+	// KindInternal is an unexpected failure, e.g. a database error
+	KindInternal Kind = iota
+	// KindNotFound means the requested resource does not exist
+	KindNotFound
+	// KindConflict means the change clashes with existing data
+	KindConflict
+	// KindValidation means the input is malformed or inconsistent
+	KindValidation
+	// KindPrecondition means the resource is not in a state that allows
+	// the operation
+	KindPrecondition
+	// KindUnauthenticated means the request does not prove who it acts for
+	KindUnauthenticated
+	// KindForbidden means the acting user's role does not allow the
+	// operation
+	KindForbidden
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation"
+	case KindPrecondition:
+		return "precondition_failed"
+	case KindUnauthenticated:
+		return "unauthenticated"
+	case KindForbidden:
+		return "forbidden"
+	default:
+		return "internal"
+	}
+}
+
+// Error is a domain error. Code is a stable, machine-readable identifier
+// such as "word_not_found"; Message is meant for humans.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewNotFoundError creates an error for a missing resource
+func NewNotFoundError(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+// NewConflictError creates an error for a change that clashes with
+// existing data
+func NewConflictError(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+// NewValidationError creates an error for invalid input
+func NewValidationError(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+// NewPreconditionError creates an error for an operation the resource's
+// current state does not allow
+func NewPreconditionError(code, message string) *Error {
+	return &Error{Kind: KindPrecondition, Code: code, Message: message}
+}
+
+// NewUnauthenticatedError creates an error for a request that does not
+// prove who it acts for
+func NewUnauthenticatedError(code, message string) *Error {
+	return &Error{Kind: KindUnauthenticated, Code: code, Message: message}
+}
+
+// NewForbiddenError creates an error for an operation the acting user may
+// not perform
+func NewForbiddenError(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+// KindOf returns the kind of the first service error in err's chain, or
+// KindInternal if there is none
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"time"
+
+	"github.com/erans/lang-portal/internal/export"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// exportPageSize is how many words are read at a time when exporting
+const exportPageSize = 500
+
+// ExportService gathers vocabulary into decks for export
+type ExportService struct {
+	store repository.Store
+}
+
+// NewExportService creates a new ExportService
+func NewExportService(store repository.Store) *ExportService {
+	return &ExportService{store: store}
+}
+
+// Deck returns the words of a group, or every word when groupID is nil, as
+// one consistent snapshot
+func (s *ExportService) Deck(groupID *int64) (*export.Deck, error) {
+	deck := &export.Deck{ExportedAt: time.Now()}
+	err := s.store.Atomic(func(store repository.Store) error {
+		var filter repository.WordFilter
+		if groupID != nil {
+			group, err := store.Groups().Get(*groupID)
+			if err != nil {
+				return orNotFound(err, ErrGroupNotFound)
+			}
+			deck.Group = group
+			filter.GroupID = groupID
+		}
+
+		page := pagination.Request{Sort: repository.WordListing.Default, Limit: exportPageSize}
+		for {
+			words, err := store.Words().List(filter, page)
+			if err != nil {
+				return err
+			}
+			deck.Words = append(deck.Words, words.Items...)
+			if words.Next == nil {
+				return nil
+			}
+			page.After = words.Next
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if deck.Words == nil {
+		deck.Words = []models.Word{}
+	}
+	return deck, nil
+}
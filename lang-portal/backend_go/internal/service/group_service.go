@@ -1,189 +1,247 @@
 package service
 
 import (
-	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
 )
 
+var (
+	// ErrGroupNotFound is returned when a group does not exist
+	ErrGroupNotFound = NewNotFoundError("group_not_found", "group not found")
+	// ErrWordAlreadyInGroup is returned when adding a word that is already
+	// a member of the group
+	ErrWordAlreadyInGroup = NewConflictError("word_already_in_group", "word is already in group")
+	// ErrWordNotInGroup is returned when removing a word that is not a
+	// member of the group
+	ErrWordNotInGroup = NewNotFoundError("word_not_in_group", "word is not in group")
+	// ErrSameGroup is returned when moving words into the group they
+	// are moved from
+	ErrSameGroup = NewValidationError("same_group", "source and target group must differ")
+	// ErrGroupNameTaken is returned when another group already has the name
+	ErrGroupNameTaken = NewConflictError("group_name_taken", "a group with this name already exists")
+)
+
+// MembershipError reports the words that caused a membership change to fail
+type MembershipError struct {
+	Err     error
+	WordIDs []int64
+}
+
+func (e *MembershipError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Err, e.WordIDs)
+}
+
+func (e *MembershipError) Unwrap() error {
+	return e.Err
+}
+
+// Details returns the offending word IDs for the error response
+func (e *MembershipError) Details() map[string]any {
+	return map[string]any{"word_ids": e.WordIDs}
+}
+
 // GroupService handles business logic for groups
 type GroupService struct {
-	db *sql.DB
+	store repository.Store
 }
 
 // NewGroupService creates a new GroupService
-func NewGroupService(db *sql.DB) *GroupService {
-	return &GroupService{db: db}
+func NewGroupService(store repository.Store) *GroupService {
+	return &GroupService{store: store}
 }
 
 // GetGroup retrieves a group by ID
 func (s *GroupService) GetGroup(id int64) (*models.Group, error) {
-	var group models.Group
-	err := s.db.QueryRow(
-		"SELECT id, name, description FROM groups WHERE id = ?",
-		id,
-	).Scan(&group.ID, &group.Name, &group.Description)
-
+	group, err := s.store.Groups().Get(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("group not found")
-		}
-		return nil, err
+		return nil, orNotFound(err, ErrGroupNotFound)
 	}
-
-	return &group, nil
+	return group, nil
 }
 
 // ListGroups retrieves a paginated list of groups
-func (s *GroupService) ListGroups(offset, limit int) (*models.ListResult, error) {
-	// Get total count first
-	var totalItems int64
-	err := s.db.QueryRow("SELECT COUNT(*) FROM groups").Scan(&totalItems)
-	if err != nil {
+func (s *GroupService) ListGroups(page pagination.Request) (*pagination.Page[models.Group], error) {
+	return s.store.Groups().List(page)
+}
+
+// CreateGroup creates a new group
+func (s *GroupService) CreateGroup(group *models.Group) error {
+	return nameTaken(s.store.Groups().Create(group))
+}
+
+// UpdateGroup updates an existing group
+func (s *GroupService) UpdateGroup(group *models.Group) error {
+	return orNotFound(nameTaken(s.store.Groups().Update(group)), ErrGroupNotFound)
+}
+
+// nameTaken replaces repository.ErrDuplicate with ErrGroupNameTaken
+func nameTaken(err error) error {
+	if errors.Is(err, repository.ErrDuplicate) {
+		return ErrGroupNameTaken
+	}
+	return err
+}
+
+// DeleteGroup deletes a group by ID
+func (s *GroupService) DeleteGroup(id int64) error {
+	return orNotFound(s.store.Groups().Delete(id), ErrGroupNotFound)
+}
+
+// GetGroupWords retrieves a page of the words in a group
+func (s *GroupService) GetGroupWords(groupID int64, page pagination.Request) (*pagination.Page[models.Word], error) {
+	if _, err := s.GetGroup(groupID); err != nil {
 		return nil, err
 	}
+	return s.store.Words().List(repository.WordFilter{GroupID: &groupID}, page)
+}
 
-	// Get paginated groups
-	rows, err := s.db.Query(
-		"SELECT id, name, description FROM groups LIMIT ? OFFSET ?",
-		limit, offset,
-	)
-	if err != nil {
+// GetGroupStudySessions retrieves a page of the user's study sessions for
+// a group
+func (s *GroupService) GetGroupStudySessions(userID, groupID int64, page pagination.Request) (*pagination.Page[models.StudySession], error) {
+	if _, err := s.GetGroup(groupID); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return s.store.Sessions().List(userID, repository.SessionFilter{GroupID: &groupID}, page)
+}
 
-	var groups []models.Group
-	for rows.Next() {
-		var group models.Group
-		if err := rows.Scan(&group.ID, &group.Name, &group.Description); err != nil {
-			return nil, err
+// AddWordsToGroup adds words to a group. The change is all-or-nothing: if
+// any word does not exist or is already a member, nothing is added.
+func (s *GroupService) AddWordsToGroup(groupID int64, wordIDs []int64) (*models.GroupMembership, error) {
+	var membership *models.GroupMembership
+	err := s.store.Atomic(func(store repository.Store) error {
+		if err := addWords(store, groupID, wordIDs); err != nil {
+			return err
 		}
-		groups = append(groups, group)
-	}
 
-	return &models.ListResult{
-		Items:      groups,
-		TotalItems: totalItems,
-	}, nil
+		var err error
+		membership, err = groupMembership(store, groupID, wordIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return membership, nil
 }
 
-// CreateGroup creates a new group
-func (s *GroupService) CreateGroup(group *models.Group) error {
-	result, err := s.db.Exec(
-		"INSERT INTO groups (name, description) VALUES (?, ?)",
-		group.Name, group.Description,
-	)
-	if err != nil {
+// RemoveWordsFromGroup removes words from a group. The change is
+// all-or-nothing: if any word is not a member, nothing is removed.
+func (s *GroupService) RemoveWordsFromGroup(groupID int64, wordIDs []int64) (*models.GroupMembership, error) {
+	var membership *models.GroupMembership
+	err := s.store.Atomic(func(store repository.Store) error {
+		if err := removeWords(store, groupID, wordIDs); err != nil {
+			return err
+		}
+
+		var err error
+		membership, err = groupMembership(store, groupID, wordIDs)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return membership, nil
+}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return err
+// MoveWords moves words from one group to another as a single change
+func (s *GroupService) MoveWords(fromGroupID, toGroupID int64, wordIDs []int64) (*models.GroupMembership, *models.GroupMembership, error) {
+	if fromGroupID == toGroupID {
+		return nil, nil, ErrSameGroup
 	}
 
-	group.ID = id
-	return nil
-}
+	var source, target *models.GroupMembership
+	err := s.store.Atomic(func(store repository.Store) error {
+		if err := removeWords(store, fromGroupID, wordIDs); err != nil {
+			return err
+		}
+		if err := addWords(store, toGroupID, wordIDs); err != nil {
+			return err
+		}
 
-// UpdateGroup updates an existing group
-func (s *GroupService) UpdateGroup(group *models.Group) error {
-	result, err := s.db.Exec(
-		"UPDATE groups SET name = ?, description = ? WHERE id = ?",
-		group.Name, group.Description, group.ID,
-	)
+		var err error
+		if source, err = groupMembership(store, fromGroupID, wordIDs); err != nil {
+			return err
+		}
+		target, err = groupMembership(store, toGroupID, wordIDs)
+		return err
+	})
 	if err != nil {
+		return nil, nil, err
+	}
+	return source, target, nil
+}
+
+// addWords adds memberships, failing if the group or any word is missing
+// or if any word is already in the group
+func addWords(store repository.Store, groupID int64, wordIDs []int64) error {
+	if err := ensureGroupExists(store, groupID); err != nil {
 		return err
 	}
 
-	rows, err := result.RowsAffected()
+	missing, err := store.Words().Missing(wordIDs)
 	if err != nil {
 		return err
 	}
+	if len(missing) > 0 {
+		return &MembershipError{Err: ErrWordNotFound, WordIDs: missing}
+	}
 
-	if rows == 0 {
-		return errors.New("group not found")
+	// Keep going after a duplicate so every one of them is reported
+	var conflicts []int64
+	for _, wordID := range wordIDs {
+		err := store.Groups().AddWord(groupID, wordID)
+		if errors.Is(err, repository.ErrDuplicate) {
+			conflicts = append(conflicts, wordID)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return &MembershipError{Err: ErrWordAlreadyInGroup, WordIDs: conflicts}
 	}
 
 	return nil
 }
 
-// DeleteGroup deletes a group by ID
-func (s *GroupService) DeleteGroup(id int64) error {
-	result, err := s.db.Exec("DELETE FROM groups WHERE id = ?", id)
-	if err != nil {
+// removeWords deletes memberships, failing if the group is missing or any
+// word is not a member
+func removeWords(store repository.Store, groupID int64, wordIDs []int64) error {
+	if err := ensureGroupExists(store, groupID); err != nil {
 		return err
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
+	var notMembers []int64
+	for _, wordID := range wordIDs {
+		err := store.Groups().RemoveWord(groupID, wordID)
+		if errors.Is(err, repository.ErrNotFound) {
+			notMembers = append(notMembers, wordID)
+		} else if err != nil {
+			return err
+		}
 	}
 
-	if rows == 0 {
-		return errors.New("group not found")
+	if len(notMembers) > 0 {
+		return &MembershipError{Err: ErrWordNotInGroup, WordIDs: notMembers}
 	}
 
 	return nil
 }
 
-// GetGroupWords retrieves all words in a group
-func (s *GroupService) GetGroupWords(groupID int64) ([]models.Word, error) {
-	rows, err := s.db.Query(`
-		SELECT w.id, w.japanese, w.romaji, w.english, w.parts
-		FROM words w
-		JOIN word_groups wg ON wg.word_id = w.id
-		WHERE wg.group_id = ?
-	`, groupID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var words []models.Word
-	for rows.Next() {
-		var word models.Word
-		var partsJSON string
-		if err := rows.Scan(&word.ID, &word.Japanese, &word.Romaji, &word.English, &partsJSON); err != nil {
-			return nil, err
-		}
-		words = append(words, word)
-	}
-
-	return words, nil
+// ensureGroupExists returns ErrGroupNotFound if the group does not exist
+func ensureGroupExists(store repository.Store, groupID int64) error {
+	_, err := store.Groups().Get(groupID)
+	return orNotFound(err, ErrGroupNotFound)
 }
 
-// GetGroupStudySessions retrieves study sessions for a group
-func (s *GroupService) GetGroupStudySessions(groupID int64) ([]models.StudySession, error) {
-	rows, err := s.db.Query(`
-		SELECT ss.id, ss.start_time, ss.end_time, ss.score, ss.status, ss.study_activity_id
-		FROM study_sessions ss
-		JOIN study_activities sa ON sa.session_id = ss.id
-		WHERE sa.group_id = ?
-		ORDER BY ss.start_time DESC
-	`, groupID)
+// groupMembership summarizes a group after a membership change
+func groupMembership(store repository.Store, groupID int64, wordIDs []int64) (*models.GroupMembership, error) {
+	count, err := store.Groups().WordCount(groupID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var sessions []models.StudySession
-	for rows.Next() {
-		var session models.StudySession
-		if err := rows.Scan(
-			&session.ID,
-			&session.StartTime,
-			&session.EndTime,
-			&session.Score,
-			&session.Status,
-			&session.StudyActivityID,
-		); err != nil {
-			return nil, err
-		}
-		sessions = append(sessions, session)
-	}
-
-	return sessions, nil
+	return &models.GroupMembership{GroupID: groupID, WordIDs: wordIDs, WordCount: count}, nil
 }
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/erans/lang-portal/internal/importer"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+var (
+	// ErrInvalidImportFile is returned when an import file cannot be read
+	// at all
+	ErrInvalidImportFile = NewValidationError("invalid_import_file", "import file cannot be read")
+	// ErrImportGroupRequired is returned when an import names no group to
+	// add its words to
+	ErrImportGroupRequired = NewValidationError("import_group_required", "a target group is required")
+	// ErrImportFailed is returned when rows of an import are invalid.
+	// Nothing is imported.
+	ErrImportFailed = NewValidationError("import_failed", "rows of the import are invalid")
+)
+
+// Import row statuses
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportErrored = "error"
+)
+
+// errDryRun rolls back a dry run once its report is complete
+var errDryRun = errors.New("dry run")
+
+// StructValidator checks a struct against its `binding` rules
+type StructValidator interface {
+	Struct(obj any) error
+}
+
+// ImportOptions controls an import
+type ImportOptions struct {
+	importer.Options
+	// GroupID is the existing group words are added to
+	GroupID *int64
+	// GroupName names the group words are added to when GroupID is nil; it
+	// is created when missing. JSON seed files default to their own group.
+	GroupName string
+	// DryRun reports what the import would do without committing it
+	DryRun bool
+}
+
+// ImportRow reports what happened to one row of an import file
+type ImportRow struct {
+	Row      int    `json:"row"`
+	Status   string `json:"status"`
+	WordID   int64  `json:"word_id,omitempty"`
+	Japanese string `json:"japanese"`
+	English  string `json:"english"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ImportReport summarizes an import. Committed is false for dry runs and
+// failed imports, whose counts say what would have happened.
+type ImportReport struct {
+	DryRun       bool        `json:"dry_run"`
+	Committed    bool        `json:"committed"`
+	GroupID      int64       `json:"group_id"`
+	Group        string      `json:"group"`
+	GroupCreated bool        `json:"group_created"`
+	Created      int         `json:"created"`
+	Updated      int         `json:"updated"`
+	Skipped      int         `json:"skipped"`
+	Errored      int         `json:"errored"`
+	Linked       int         `json:"linked"`
+	Rows         []ImportRow `json:"rows"`
+}
+
+// ImportError reports an import that was rolled back because some of its
+// rows are invalid
+type ImportError struct {
+	Report *ImportReport
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("%d of %d rows could not be imported", e.Report.Errored, len(e.Report.Rows))
+}
+
+func (e *ImportError) Unwrap() error {
+	return ErrImportFailed
+}
+
+// Details returns the report, whose error rows say what to fix
+func (e *ImportError) Details() map[string]any {
+	return map[string]any{"report": e.Report}
+}
+
+// ImportService imports vocabulary files into a group
+type ImportService struct {
+	store     repository.Store
+	validator StructValidator
+}
+
+// NewImportService creates a new ImportService that checks imported words
+// with validator
+func NewImportService(store repository.Store, validator StructValidator) *ImportService {
+	return &ImportService{store: store, validator: validator}
+}
+
+// Import reads a file and adds its words to the target group in one
+// transaction. Words are matched on their japanese and english text: new
+// words are created, and existing ones are updated when their romaji or
+// parts differ and skipped otherwise. When any row is invalid nothing is
+// imported and an *ImportError reports every row.
+func (s *ImportService) Import(r io.Reader, format importer.Format, opts ImportOptions) (*ImportReport, error) {
+	file, err := importer.Read(r, format, opts.Options)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+
+	target := models.Group{Name: opts.GroupName}
+	if file.Group != nil && (target.Name == "" || target.Name == file.Group.Name) {
+		target.Name = file.Group.Name
+		target.Description = file.Group.Description
+	}
+	if opts.GroupID == nil && target.Name == "" {
+		return nil, ErrImportGroupRequired
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, Rows: []ImportRow{}}
+	err = s.store.Atomic(func(store repository.Store) error {
+		group, created, err := s.importGroup(store, opts.GroupID, target)
+		if err != nil {
+			return err
+		}
+		report.GroupID, report.Group, report.GroupCreated = group.ID, group.Name, created
+
+		for _, row := range file.Rows {
+			if err := s.importRow(store, group.ID, row, report); err != nil {
+				return err
+			}
+		}
+
+		if report.Errored > 0 {
+			return &ImportError{Report: report}
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// importGroup returns the group an import adds its words to, creating a
+// named group that does not exist yet
+func (s *ImportService) importGroup(store repository.Store, id *int64, target models.Group) (*models.Group, bool, error) {
+	if id != nil {
+		group, err := store.Groups().Get(*id)
+		return group, false, orNotFound(err, ErrGroupNotFound)
+	}
+
+	group, err := store.Groups().GetByName(target.Name)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return group, false, err
+	}
+	if err := s.validator.Struct(target); err != nil {
+		return nil, false, err
+	}
+	if err := nameTaken(store.Groups().Create(&target)); err != nil {
+		return nil, false, err
+	}
+	return &target, true, nil
+}
+
+// importRow creates or updates the word of a row and adds it to the group,
+// recording the outcome in report. Invalid rows are recorded as errors;
+// only store failures are returned.
+func (s *ImportService) importRow(store repository.Store, groupID int64, row importer.Row, report *ImportReport) error {
+	word := row.Word
+	result := ImportRow{Row: row.Number, Japanese: word.Japanese, English: word.English}
+	record := func(status string) {
+		result.Status = status
+		report.Rows = append(report.Rows, result)
+		switch status {
+		case ImportCreated:
+			report.Created++
+		case ImportUpdated:
+			report.Updated++
+		case ImportSkipped:
+			report.Skipped++
+		default:
+			report.Errored++
+		}
+	}
+
+	err := row.Err
+	if err == nil {
+		err = s.validator.Struct(word)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		record(ImportErrored)
+		return nil
+	}
+
+	status, linked, err := UpsertWord(store, groupID, &word)
+	if err != nil {
+		return err
+	}
+	result.WordID = word.ID
+	if linked {
+		report.Linked++
+	}
+
+	record(status)
+	return nil
+}
+
+// UpsertWord adds a word to a group, the way imports and the seed files
+// are applied. It matches the word on its japanese and english text: a new
+// word is created, and an existing one is updated when its romaji or parts
+// differ and left alone otherwise. The status is ImportCreated,
+// ImportUpdated or ImportSkipped, and linked reports whether the word was
+// not yet in the group. word is set to the stored word.
+func UpsertWord(store repository.Store, groupID int64, word *models.Word) (status string, linked bool, err error) {
+	status = ImportCreated
+	existing, err := store.Words().FindByText(word.Japanese, word.English)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		if err := store.Words().Create(word); err != nil {
+			return "", false, err
+		}
+	case err != nil:
+		return "", false, err
+	case existing.Romaji == word.Romaji && samePartsJSON(existing.Parts, word.Parts):
+		*word = *existing
+		status = ImportSkipped
+	default:
+		word.ID = existing.ID
+		if err := store.Words().Update(word); err != nil {
+			return "", false, err
+		}
+		status = ImportUpdated
+	}
+
+	inGroup, err := store.Groups().HasWord(groupID, word.ID)
+	if err != nil {
+		return "", false, err
+	}
+	if inGroup {
+		return status, false, nil
+	}
+	if err := store.Groups().AddWord(groupID, word.ID); err != nil {
+		return "", false, err
+	}
+	return status, true, nil
+}
+
+// samePartsJSON reports whether two parts maps are stored the same way
+func samePartsJSON(a, b map[string]any) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(aJSON) == string(bJSON)
+}
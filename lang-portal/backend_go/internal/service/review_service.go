@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// SM-2 scheduling parameters
+const (
+	defaultEaseFactor = 2.5
+	minEaseFactor     = 1.3
+	firstIntervalDays = 1
+	secondInterval    = 6
+
+	// Reviews only record right or wrong, so they are graded on SM-2's
+	// 0-5 quality scale as "correct with hesitation" or "incorrect but
+	// remembered on seeing the answer"
+	correctQuality   = 4
+	incorrectQuality = 1
+)
+
+// ReviewService schedules words for review using the SM-2 algorithm
+type ReviewService struct {
+	store repository.Store
+}
+
+// NewReviewService creates a new ReviewService
+func NewReviewService(store repository.Store) *ReviewService {
+	return &ReviewService{store: store}
+}
+
+// NextSchedule applies one SM-2 step to a word's schedule after it was
+// answered correctly or not at the given time
+func NextSchedule(prev models.ReviewSchedule, correct bool, now time.Time) models.ReviewSchedule {
+	next := prev
+	if next.EaseFactor == 0 {
+		next.EaseFactor = defaultEaseFactor
+	}
+
+	quality := incorrectQuality
+	if correct {
+		quality = correctQuality
+	}
+
+	if quality >= 3 {
+		switch next.Repetitions {
+		case 0:
+			next.IntervalDays = firstIntervalDays
+		case 1:
+			next.IntervalDays = secondInterval
+		default:
+			next.IntervalDays = int(math.Round(float64(next.IntervalDays) * next.EaseFactor))
+		}
+		next.Repetitions++
+	} else {
+		// A lapse restarts the learning steps
+		next.Repetitions = 0
+		next.IntervalDays = firstIntervalDays
+	}
+
+	q := float64(5 - quality)
+	next.EaseFactor += 0.1 - q*(0.08+q*0.02)
+	if next.EaseFactor < minEaseFactor {
+		next.EaseFactor = minEaseFactor
+	}
+
+	next.LastReviewedAt = now
+	next.DueAt = now.AddDate(0, 0, next.IntervalDays)
+	return next
+}
+
+// scheduleTime normalizes timestamps so stored schedule times compare
+// correctly as text
+func scheduleTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// updateReviewSchedule advances a user's schedule for a word in the unit of
+// work that records their review
+func updateReviewSchedule(reviews repository.ReviewRepository, userID, wordID int64, correct bool, now time.Time) error {
+	prev := models.ReviewSchedule{UserID: userID, WordID: wordID}
+	stored, err := reviews.Schedule(userID, wordID)
+	if err == nil {
+		prev = *stored
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	return reviews.SaveSchedule(NextSchedule(prev, correct, scheduleTime(now)))
+}
+
+// GetSchedule retrieves the user's review schedule for a word
+func (s *ReviewService) GetSchedule(userID, wordID int64) (*models.ReviewSchedule, error) {
+	schedule, err := s.store.Reviews().Schedule(userID, wordID)
+	if err != nil {
+		return nil, orNotFound(err, ErrWordNotFound)
+	}
+	return schedule, nil
+}
+
+// GetDueWords returns up to limit words that are due for the user to review
+// at now, optionally restricted to a group. Overdue words come first, most
+// overdue at the top, followed by words the user has never reviewed.
+func (s *ReviewService) GetDueWords(userID int64, groupID *int64, limit int, now time.Time) ([]models.DueWord, error) {
+	if groupID != nil {
+		if _, err := s.store.Groups().Get(*groupID); err != nil {
+			return nil, orNotFound(err, ErrGroupNotFound)
+		}
+	}
+
+	words, err := s.store.Reviews().Due(userID, groupID, scheduleTime(now), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range words {
+		if words[i].IsNew {
+			words[i].EaseFactor = defaultEaseFactor
+		}
+	}
+	if words == nil {
+		words = []models.DueWord{}
+	}
+	return words, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+// ScoringStrategy weighs the answers of a completed session. The session's
+// score is the points its answers earned as a percentage of the most they
+// could have earned.
+type ScoringStrategy interface {
+	// Name identifies the strategy in score breakdowns
+	Name() string
+	// Weigh returns the points an answer earned and the most it could
+	// have earned
+	Weigh(answer models.ScoredAnswer) (points, maxPoints float64)
+}
+
+// AccuracyScoring gives each correct answer one point, so the score is the
+// percentage of correct answers
+type AccuracyScoring struct{}
+
+// Name returns "accuracy"
+func (AccuracyScoring) Name() string { return "accuracy" }
+
+// Weigh gives a correct answer its point
+func (AccuracyScoring) Weigh(answer models.ScoredAnswer) (float64, float64) {
+	if answer.Correct {
+		return 1, 1
+	}
+	return 0, 1
+}
+
+// DifficultyScoring makes hard words count for more: an answer is worth
+// between one point, for a word the learner never misses, and two, for one
+// they always miss
+type DifficultyScoring struct{}
+
+// Name returns "difficulty"
+func (DifficultyScoring) Name() string { return "difficulty" }
+
+// Weigh gives a correct answer every point its word is worth
+func (DifficultyScoring) Weigh(answer models.ScoredAnswer) (float64, float64) {
+	worth := 1 + answer.Difficulty
+	if answer.Correct {
+		return worth, worth
+	}
+	return 0, worth
+}
+
+// SpeedScoring gives a correct answer its full point when it came within
+// Target and a share of it, down to half, when it was slower
+type SpeedScoring struct {
+	Target time.Duration
+}
+
+// Name returns "speed"
+func (SpeedScoring) Name() string { return "speed" }
+
+// Weigh scales a correct answer's point down by how far it overran Target
+func (s SpeedScoring) Weigh(answer models.ScoredAnswer) (float64, float64) {
+	if !answer.Correct {
+		return 0, 1
+	}
+	target := s.Target.Seconds()
+	if answer.ResponseSeconds <= target {
+		return 1, 1
+	}
+	return max(0.5, target/answer.ResponseSeconds), 1
+}
+
+// DefaultScoringStrategies returns the strategy each activity type is
+// scored with. Types without one are scored with AccuracyScoring.
+func DefaultScoringStrategies() map[string]ScoringStrategy {
+	return map[string]ScoringStrategy{
+		"flashcard": AccuracyScoring{},
+		"matching":  AccuracyScoring{},
+		"quiz":      DifficultyScoring{},
+		"typing":    SpeedScoring{Target: 10 * time.Second},
+	}
+}
+
+// scoreSession scores a session's answers with strategy and returns the
+// score with its breakdown
+func scoreSession(store repository.Store, session *models.StudySession, strategy ScoringStrategy) (float64, *models.ScoreBreakdown, error) {
+	items, err := store.Reviews().ListBySession(session.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// A word's difficulty comes from the learner's answers outside this
+	// session
+	var wordIDs []int64
+	inSession := make(map[int64]repository.ReviewCounts)
+	for _, item := range items {
+		c, seen := inSession[item.WordID]
+		if !seen {
+			wordIDs = append(wordIDs, item.WordID)
+		}
+		if item.IsCorrect {
+			c.Correct++
+		} else {
+			c.Wrong++
+		}
+		inSession[item.WordID] = c
+	}
+	counts, err := store.Reviews().Counts(session.UserID, wordIDs)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	breakdown := &models.ScoreBreakdown{
+		Strategy: strategy.Name(),
+		Answers:  len(items),
+		Items:    make([]models.ScoredAnswer, 0, len(items)),
+	}
+	previous := session.StartTime
+	for _, item := range items {
+		correct := counts[item.WordID].Correct - inSession[item.WordID].Correct
+		wrong := counts[item.WordID].Wrong - inSession[item.WordID].Wrong
+		answer := models.ScoredAnswer{
+			ReviewItemID:    item.ID,
+			WordID:          item.WordID,
+			Correct:         item.IsCorrect,
+			Difficulty:      float64(wrong+1) / float64(correct+wrong+2),
+			ResponseSeconds: max(0, item.ReviewedAt.Sub(previous).Seconds()),
+		}
+		previous = item.ReviewedAt
+
+		answer.Points, answer.MaxPoints = strategy.Weigh(answer)
+		breakdown.Points += answer.Points
+		breakdown.MaxPoints += answer.MaxPoints
+		if item.IsCorrect {
+			breakdown.Correct++
+		}
+		breakdown.Items = append(breakdown.Items, answer)
+	}
+
+	if breakdown.Answers > 0 {
+		breakdown.Accuracy = float64(breakdown.Correct) * 100 / float64(breakdown.Answers)
+	}
+	if breakdown.MaxPoints == 0 {
+		return 0, breakdown, nil
+	}
+	return breakdown.Points * 100 / breakdown.MaxPoints, breakdown, nil
+}
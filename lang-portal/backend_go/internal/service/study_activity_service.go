@@ -1,177 +1,61 @@
 package service
 
 import (
-	"database/sql"
-	"errors"
 	"time"
 
 	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
 )
 
+// ErrActivityNotFound is returned when a study activity does not exist
+var ErrActivityNotFound = NewNotFoundError("study_activity_not_found", "study activity not found")
+
 // StudyActivityService handles business logic for study activities
 type StudyActivityService struct {
-	db *sql.DB
+	store repository.Store
 }
 
 // NewStudyActivityService creates a new StudyActivityService
-func NewStudyActivityService(db *sql.DB) *StudyActivityService {
-	return &StudyActivityService{db: db}
+func NewStudyActivityService(store repository.Store) *StudyActivityService {
+	return &StudyActivityService{store: store}
 }
 
 // GetActivity retrieves a study activity by ID
 func (s *StudyActivityService) GetActivity(id int64) (*models.StudyActivity, error) {
-	var activity models.StudyActivity
-	err := s.db.QueryRow(`
-		SELECT id, group_id, activity_type, created_at
-		FROM study_activities 
-		WHERE id = ?`,
-		id,
-	).Scan(
-		&activity.ID,
-		&activity.GroupID,
-		&activity.ActivityType,
-		&activity.CreatedAt,
-	)
-
+	activity, err := s.store.Activities().Get(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("study activity not found")
-		}
-		return nil, err
+		return nil, orNotFound(err, ErrActivityNotFound)
 	}
-
-	return &activity, nil
+	return activity, nil
 }
 
 // ListActivities retrieves a paginated list of study activities
-func (s *StudyActivityService) ListActivities(offset, limit int) ([]models.StudyActivity, error) {
-	rows, err := s.db.Query(`
-		SELECT id, group_id, activity_type, created_at
-		FROM study_activities 
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?`,
-		limit, offset,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var activities []models.StudyActivity
-	for rows.Next() {
-		var activity models.StudyActivity
-		if err := rows.Scan(
-			&activity.ID,
-			&activity.GroupID,
-			&activity.ActivityType,
-			&activity.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		activities = append(activities, activity)
-	}
-
-	return activities, nil
+func (s *StudyActivityService) ListActivities(filter repository.ActivityFilter, page pagination.Request) (*pagination.Page[models.StudyActivity], error) {
+	return s.store.Activities().List(filter, page)
 }
 
 // CreateActivity creates a new study activity
 func (s *StudyActivityService) CreateActivity(activity *models.StudyActivity) error {
 	activity.CreatedAt = time.Now()
-
-	result, err := s.db.Exec(`
-		INSERT INTO study_activities (group_id, activity_type, created_at)
-		VALUES (?, ?, ?)`,
-		activity.GroupID,
-		activity.ActivityType,
-		activity.CreatedAt,
-	)
-	if err != nil {
-		return err
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return err
-	}
-
-	activity.ID = id
-	return nil
+	return s.store.Activities().Create(activity)
 }
 
 // UpdateActivity updates an existing study activity
 func (s *StudyActivityService) UpdateActivity(activity *models.StudyActivity) error {
-	result, err := s.db.Exec(`
-		UPDATE study_activities 
-		SET group_id = ?, activity_type = ?
-		WHERE id = ?`,
-		activity.GroupID,
-		activity.ActivityType,
-		activity.ID,
-	)
-	if err != nil {
-		return err
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rows == 0 {
-		return errors.New("study activity not found")
-	}
-
-	return nil
+	return orNotFound(s.store.Activities().Update(activity), ErrActivityNotFound)
 }
 
 // DeleteActivity deletes a study activity by ID
 func (s *StudyActivityService) DeleteActivity(id int64) error {
-	result, err := s.db.Exec("DELETE FROM study_activities WHERE id = ?", id)
-	if err != nil {
-		return err
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rows == 0 {
-		return errors.New("study activity not found")
-	}
-
-	return nil
+	return orNotFound(s.store.Activities().Delete(id), ErrActivityNotFound)
 }
 
-// GetActivitySessions retrieves all study sessions for an activity
-func (s *StudyActivityService) GetActivitySessions(activityID int64) ([]models.StudySession, error) {
-	rows, err := s.db.Query(`
-		SELECT id, start_time, end_time, score, status, study_activity_id
-		FROM study_sessions
-		WHERE study_activity_id = ?
-		ORDER BY start_time DESC`,
-		activityID,
-	)
-	if err != nil {
+// GetActivitySessions retrieves a page of the user's study sessions for an
+// activity
+func (s *StudyActivityService) GetActivitySessions(userID, activityID int64, page pagination.Request) (*pagination.Page[models.StudySession], error) {
+	if _, err := s.GetActivity(activityID); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var sessions []models.StudySession
-	for rows.Next() {
-		var session models.StudySession
-		if err := rows.Scan(
-			&session.ID,
-			&session.StartTime,
-			&session.EndTime,
-			&session.Score,
-			&session.Status,
-			&session.StudyActivityID,
-		); err != nil {
-			return nil, err
-		}
-		sessions = append(sessions, session)
-	}
-
-	return sessions, nil
+	return s.store.Sessions().List(userID, repository.SessionFilter{ActivityID: &activityID}, page)
 }
@@ -1,200 +1,279 @@
 package service
 
 import (
-	"database/sql"
 	"errors"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
 )
 
-// StudySessionService handles business logic for study sessions
+var (
+	// ErrSessionNotFound is returned when a study session does not exist
+	ErrSessionNotFound = NewNotFoundError("study_session_not_found", "study session not found")
+	// ErrSessionNotActive is returned when recording into a closed session
+	ErrSessionNotActive = NewPreconditionError("study_session_not_active", "study session is not active")
+	// ErrWordNotInSessionGroup is returned when a reviewed word is not part
+	// of the group being studied
+	ErrWordNotInSessionGroup = NewValidationError("word_not_in_session_group", "word does not belong to the session's group")
+)
+
+// ErrIllegalTransition is returned when a session cannot move to the
+// requested status
+var ErrIllegalTransition = NewConflictError("illegal_session_transition", "study session cannot move to this status")
+
+// sessionTransitions lists the statuses each status may move to. Closed
+// sessions stay closed.
+var sessionTransitions = map[string][]string{
+	models.SessionActive: {models.SessionCompleted, models.SessionAbandoned},
+}
+
+// TransitionError reports a status change the state machine forbids
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("study session cannot move from %s to %s", e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrIllegalTransition
+}
+
+// Details returns both statuses for the error response
+func (e *TransitionError) Details() map[string]any {
+	return map[string]any{"from": e.From, "to": e.To}
+}
+
+// StudySessionService handles business logic for study sessions. Every
+// method acts for one user, and sessions of other users are reported as
+// not found.
 type StudySessionService struct {
-	db *sql.DB
+	store   repository.Store
+	scoring map[string]ScoringStrategy
 }
 
-// NewStudySessionService creates a new StudySessionService
-func NewStudySessionService(db *sql.DB) *StudySessionService {
-	return &StudySessionService{db: db}
+// NewStudySessionService creates a new StudySessionService that scores
+// sessions with DefaultScoringStrategies
+func NewStudySessionService(store repository.Store) *StudySessionService {
+	return &StudySessionService{store: store, scoring: DefaultScoringStrategies()}
 }
 
-// GetSession retrieves a study session by ID
-func (s *StudySessionService) GetSession(id int64) (*models.StudySession, error) {
-	var session models.StudySession
-	err := s.db.QueryRow(`
-		SELECT id, start_time, end_time, score, status, study_activity_id
-		FROM study_sessions 
-		WHERE id = ?`,
-		id,
-	).Scan(
-		&session.ID,
-		&session.StartTime,
-		&session.EndTime,
-		&session.Score,
-		&session.Status,
-		&session.StudyActivityID,
-	)
+// SetScoringStrategy scores the sessions of an activity type with strategy
+// from now on. It must not be called while requests are being served.
+func (s *StudySessionService) SetScoringStrategy(activityType string, strategy ScoringStrategy) {
+	s.scoring[activityType] = strategy
+}
 
+// scoringStrategy returns the strategy an activity type is scored with
+func (s *StudySessionService) scoringStrategy(activityType string) ScoringStrategy {
+	if strategy, ok := s.scoring[activityType]; ok {
+		return strategy
+	}
+	return AccuracyScoring{}
+}
+
+// ownSession returns a session of the user
+func ownSession(store repository.Store, userID, id int64) (*models.StudySession, error) {
+	session, err := store.Sessions().Get(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("study session not found")
-		}
-		return nil, err
+		return nil, orNotFound(err, ErrSessionNotFound)
 	}
+	if session.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// GetSession retrieves a study session by ID
+func (s *StudySessionService) GetSession(userID, id int64) (*models.StudySession, error) {
+	return ownSession(s.store, userID, id)
+}
 
-	return &session, nil
+// ListSessions retrieves a page of the user's study sessions
+func (s *StudySessionService) ListSessions(userID int64, filter repository.SessionFilter, page pagination.Request) (*pagination.Page[models.StudySession], error) {
+	return s.store.Sessions().List(userID, filter, page)
 }
 
-// ListSessions retrieves a paginated list of study sessions
-func (s *StudySessionService) ListSessions(offset, limit int) (*models.ListResult, error) {
-	// Get total count first
-	var totalItems int64
-	err := s.db.QueryRow("SELECT COUNT(*) FROM study_sessions").Scan(&totalItems)
+// CreateSession starts an active session for the user. The times, score
+// and status in session are replaced.
+func (s *StudySessionService) CreateSession(userID int64, session *models.StudySession) error {
+	session.UserID = userID
+	session.StartTime = time.Now()
+	session.EndTime = nil
+	session.Score = nil
+	session.ScoreBreakdown = nil
+	session.Status = models.SessionActive
+
+	return s.store.Sessions().Create(session)
+}
+
+// UpdateSession moves one of the user's sessions to a new status. Only an
+// active session can move, and only to completed or abandoned.
+func (s *StudySessionService) UpdateSession(userID, id int64, status string) (*models.StudySession, error) {
+	var session *models.StudySession
+	err := s.store.Atomic(func(store repository.Store) error {
+		var err error
+		session, err = s.closeSession(store, userID, id, status)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return session, nil
+}
+
+// EndSession completes one of the user's active sessions and scores it
+func (s *StudySessionService) EndSession(userID, id int64) (*models.StudySession, error) {
+	return s.UpdateSession(userID, id, models.SessionCompleted)
+}
 
-	// Get paginated sessions
-	rows, err := s.db.Query(`
-		SELECT id, start_time, end_time, score, status, study_activity_id
-		FROM study_sessions 
-		ORDER BY start_time DESC
-		LIMIT ? OFFSET ?`,
-		limit, offset,
-	)
+// closeSession moves an active session to status, stamping its end time.
+// Completed sessions are scored from their review answers by the strategy
+// of their activity's type.
+func (s *StudySessionService) closeSession(store repository.Store, userID, id int64, status string) (*models.StudySession, error) {
+	session, err := ownSession(store, userID, id)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var sessions []models.StudySession
-	for rows.Next() {
-		var session models.StudySession
-		if err := rows.Scan(
-			&session.ID,
-			&session.StartTime,
-			&session.EndTime,
-			&session.Score,
-			&session.Status,
-			&session.StudyActivityID,
-		); err != nil {
+	if !slices.Contains(sessionTransitions[session.Status], status) {
+		return nil, &TransitionError{From: session.Status, To: status}
+	}
+
+	if status == models.SessionCompleted {
+		activity, err := store.Activities().Get(session.StudyActivityID)
+		if err != nil {
 			return nil, err
 		}
-		sessions = append(sessions, session)
+		score, breakdown, err := scoreSession(store, session, s.scoringStrategy(activity.ActivityType))
+		if err != nil {
+			return nil, err
+		}
+		session.Score = &score
+		session.ScoreBreakdown = breakdown
 	}
+	now := time.Now()
+	session.EndTime = &now
+	from := session.Status
+	session.Status = status
 
-	return &models.ListResult{
-		Items:      sessions,
-		TotalItems: totalItems,
-	}, nil
+	if err := store.Sessions().Close(session); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// Closed by a concurrent request since it was read
+			return nil, &TransitionError{From: from, To: status}
+		}
+		return nil, err
+	}
+	return session, nil
 }
 
-// CreateSession creates a new study session
-func (s *StudySessionService) CreateSession(session *models.StudySession) error {
-	session.StartTime = time.Now()
-	session.Status = "in_progress"
-
-	result, err := s.db.Exec(`
-		INSERT INTO study_sessions (start_time, status, study_activity_id)
-		VALUES (?, ?, ?)`,
-		session.StartTime,
-		session.Status,
-		session.StudyActivityID,
-	)
+// AbandonIdle abandons every active session that has seen no answer for at
+// least timeout before now. An abandoned session ends at its last answer,
+// or at its start when it has none. It returns the sessions it abandoned.
+func (s *StudySessionService) AbandonIdle(now time.Time, timeout time.Duration) ([]models.StudySession, error) {
+	active, err := s.store.Sessions().ListActive()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return err
-	}
+	var abandoned []models.StudySession
+	for _, session := range active {
+		err := s.store.Atomic(func(store repository.Store) error {
+			items, err := store.Reviews().ListBySession(session.ID)
+			if err != nil {
+				return err
+			}
+			lastActive := session.StartTime
+			for _, item := range items {
+				if item.ReviewedAt.After(lastActive) {
+					lastActive = item.ReviewedAt
+				}
+			}
+			if now.Sub(lastActive) < timeout {
+				return nil
+			}
 
-	session.ID = id
-	return nil
-}
-
-// UpdateSession updates an existing study session
-func (s *StudySessionService) UpdateSession(session *models.StudySession) error {
-	result, err := s.db.Exec(`
-		UPDATE study_sessions 
-		SET end_time = ?, score = ?, status = ?
-		WHERE id = ?`,
-		session.EndTime,
-		session.Score,
-		session.Status,
-		session.ID,
-	)
-	if err != nil {
-		return err
+			session.EndTime = &lastActive
+			session.Status = models.SessionAbandoned
+			if err := store.Sessions().Close(&session); err != nil {
+				return err
+			}
+			abandoned = append(abandoned, session)
+			return nil
+		})
+		// A session closed since it was listed is left as it is
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return abandoned, err
+		}
 	}
+	return abandoned, nil
+}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
+// ListSessionReviewItems retrieves a page of the word review items of a
+// session
+func (s *StudySessionService) ListSessionReviewItems(userID, sessionID int64, filter repository.ReviewFilter, page pagination.Request) (*pagination.Page[models.WordReviewItem], error) {
+	if _, err := ownSession(s.store, userID, sessionID); err != nil {
+		return nil, err
 	}
+	return s.store.Reviews().List(sessionID, filter, page)
+}
 
-	if rows == 0 {
-		return errors.New("study session not found")
-	}
+// RecordReview records a learner's answer for a word within one of their
+// active sessions and advances their review schedule for the word. The
+// word must belong to the group of the session's activity.
+func (s *StudySessionService) RecordReview(userID, sessionID, wordID int64, isCorrect bool, response string) (*models.WordReviewItem, error) {
+	var item *models.WordReviewItem
+	err := s.store.Atomic(func(store repository.Store) error {
+		session, err := ownSession(store, userID, sessionID)
+		if err != nil {
+			return err
+		}
 
-	return nil
-}
+		if session.Status != models.SessionActive {
+			return ErrSessionNotActive
+		}
 
-// EndSession ends a study session and calculates the final score
-func (s *StudySessionService) EndSession(id int64, score float64) error {
-	result, err := s.db.Exec(`
-		UPDATE study_sessions 
-		SET end_time = ?, score = ?, status = 'completed'
-		WHERE id = ?`,
-		time.Now(),
-		score,
-		id,
-	)
-	if err != nil {
-		return err
-	}
+		activity, err := store.Activities().Get(session.StudyActivityID)
+		if err != nil {
+			return err
+		}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+		missing, err := store.Words().Missing([]int64{wordID})
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return ErrWordNotFound
+		}
 
-	if rows == 0 {
-		return errors.New("study session not found")
-	}
+		inGroup, err := store.Groups().HasWord(activity.GroupID, wordID)
+		if err != nil {
+			return err
+		}
+		if !inGroup {
+			return ErrWordNotInSessionGroup
+		}
 
-	return nil
-}
+		item = &models.WordReviewItem{
+			SessionID:  sessionID,
+			WordID:     wordID,
+			IsCorrect:  isCorrect,
+			Response:   response,
+			ReviewedAt: time.Now(),
+		}
+		if err := store.Reviews().Create(item); err != nil {
+			return err
+		}
 
-// GetSessionReviewItems retrieves all word review items for a session
-func (s *StudySessionService) GetSessionReviewItems(sessionID int64) ([]models.WordReviewItem, error) {
-	rows, err := s.db.Query(`
-		SELECT id, word_id, session_id, is_correct, reviewed_at
-		FROM word_review_items
-		WHERE session_id = ?
-		ORDER BY reviewed_at ASC`,
-		sessionID,
-	)
+		return updateReviewSchedule(store.Reviews(), userID, wordID, isCorrect, item.ReviewedAt)
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var items []models.WordReviewItem
-	for rows.Next() {
-		var item models.WordReviewItem
-		if err := rows.Scan(
-			&item.ID,
-			&item.WordID,
-			&item.SessionID,
-			&item.IsCorrect,
-			&item.ReviewedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, item)
-	}
-
-	return items, nil
+	return item, nil
 }
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+)
+
+var (
+	// ErrUserNotFound is returned when a user does not exist
+	ErrUserNotFound = NewNotFoundError("user_not_found", "user not found")
+	// ErrUserNameTaken is returned when another user already has the name
+	ErrUserNameTaken = NewConflictError("user_name_taken", "a user with this name already exists")
+	// ErrLastAdmin is returned when a change would leave no admin to manage
+	// users
+	ErrLastAdmin = NewConflictError("last_admin", "the last admin cannot give up the role")
+)
+
+// UserService handles business logic for users and their roles
+type UserService struct {
+	store repository.Store
+}
+
+// NewUserService creates a new UserService
+func NewUserService(store repository.Store) *UserService {
+	return &UserService{store: store}
+}
+
+// GetUser retrieves a user by ID
+func (s *UserService) GetUser(id int64) (*models.User, error) {
+	user, err := s.store.Users().Get(id)
+	if err != nil {
+		return nil, orNotFound(err, ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// GetUserByName retrieves a user by name
+func (s *UserService) GetUserByName(name string) (*models.User, error) {
+	user, err := s.store.Users().GetByName(name)
+	if err != nil {
+		return nil, orNotFound(err, ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// ListUsers retrieves a page of users
+func (s *UserService) ListUsers(page pagination.Request) (*pagination.Page[models.User], error) {
+	return s.store.Users().List(page)
+}
+
+// CreateUser creates a new user, a learner unless a role is given
+func (s *UserService) CreateUser(user *models.User) error {
+	if user.Role == "" {
+		user.Role = models.RoleLearner
+	}
+	user.CreatedAt = time.Now()
+	err := s.store.Users().Create(user)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return ErrUserNameTaken
+	}
+	return err
+}
+
+// SetRole changes a user's role. The last admin keeps theirs, so someone
+// can always manage users.
+func (s *UserService) SetRole(id int64, role string) (*models.User, error) {
+	var user *models.User
+	err := s.store.Atomic(func(store repository.Store) error {
+		var err error
+		user, err = store.Users().Get(id)
+		if err != nil {
+			return orNotFound(err, ErrUserNotFound)
+		}
+
+		if user.Role == models.RoleAdmin && role != models.RoleAdmin {
+			admins, err := store.Users().CountRole(models.RoleAdmin)
+			if err != nil {
+				return err
+			}
+			if admins == 1 {
+				return ErrLastAdmin
+			}
+		}
+
+		if err := store.Users().SetRole(id, role); err != nil {
+			return err
+		}
+		user.Role = role
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -1,155 +1,173 @@
 package service
 
 import (
-	"database/sql"
-	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
 
 	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
 )
 
+var (
+	// ErrWordNotFound is returned when a word does not exist
+	ErrWordNotFound = NewNotFoundError("word_not_found", "word not found")
+	// ErrInvalidSearch is returned when search parameters are unusable
+	ErrInvalidSearch = NewValidationError("invalid_search", "invalid search")
+)
+
+// partsKeyPattern restricts parts filter keys to safe JSON path segments
+var partsKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
 // WordService handles business logic for words
 type WordService struct {
-	db *sql.DB
+	store repository.Store
 }
 
 // NewWordService creates a new WordService
-func NewWordService(db *sql.DB) *WordService {
-	return &WordService{db: db}
+func NewWordService(store repository.Store) *WordService {
+	return &WordService{store: store}
 }
 
 // GetWord retrieves a word by ID
 func (s *WordService) GetWord(id int64) (*models.Word, error) {
-	var word models.Word
-	var partsJSON string
+	word, err := s.store.Words().Get(id)
+	if err != nil {
+		return nil, orNotFound(err, ErrWordNotFound)
+	}
+	return word, nil
+}
 
-	err := s.db.QueryRow(
-		"SELECT id, japanese, romaji, english, parts FROM words WHERE id = ?",
-		id,
-	).Scan(&word.ID, &word.Japanese, &word.Romaji, &word.English, &partsJSON)
+// GetWordWithStats retrieves a word by ID with the user's review
+// statistics and the names of the groups it belongs to
+func (s *WordService) GetWordWithStats(userID, id int64) (*models.WordWithStats, error) {
+	word, err := s.GetWord(id)
+	if err != nil {
+		return nil, err
+	}
 
+	words, err := s.withStats(userID, []models.Word{*word})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("word not found")
-		}
 		return nil, err
 	}
+	return &words[0], nil
+}
 
-	// Parse JSON parts
-	if err := json.Unmarshal([]byte(partsJSON), &word.Parts); err != nil {
+// ListWords retrieves a paginated list of words with the user's review
+// statistics. Statistics and group names for the page are loaded with one
+// query each.
+func (s *WordService) ListWords(userID int64, filter repository.WordFilter, page pagination.Request) (*pagination.Page[models.WordWithStats], error) {
+	words, err := s.store.Words().List(filter, page)
+	if err != nil {
 		return nil, err
 	}
 
-	return &word, nil
+	return pagination.Map(words, func(words []models.Word) ([]models.WordWithStats, error) {
+		return s.withStats(userID, words)
+	})
 }
 
-// ListWords retrieves a paginated list of words
-func (s *WordService) ListWords(offset, limit int) (*models.ListResult, error) {
-	// Get total count first
-	var totalItems int64
-	err := s.db.QueryRow("SELECT COUNT(*) FROM words").Scan(&totalItems)
+// withStats adds the user's review statistics and group names to words
+func (s *WordService) withStats(userID int64, words []models.Word) ([]models.WordWithStats, error) {
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, len(words))
+	for i, word := range words {
+		ids[i] = word.ID
+	}
+
+	counts, err := s.store.Reviews().Counts(userID, ids)
 	if err != nil {
 		return nil, err
 	}
-
-	// Get paginated words
-	rows, err := s.db.Query(
-		"SELECT id, japanese, romaji, english, parts FROM words LIMIT ? OFFSET ?",
-		limit, offset,
-	)
+	groups, err := s.store.Groups().Names(ids)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var words []models.Word
-	for rows.Next() {
-		var word models.Word
-		var partsJSON string
-
-		if err := rows.Scan(&word.ID, &word.Japanese, &word.Romaji, &word.English, &partsJSON); err != nil {
-			return nil, err
+	result := make([]models.WordWithStats, len(words))
+	for i, word := range words {
+		c := counts[word.ID]
+		result[i] = models.WordWithStats{
+			Word: word,
+			WordStats: models.WordStats{
+				CorrectCount: c.Correct,
+				WrongCount:   c.Wrong,
+				SuccessRate:  successRate(c.Correct, c.Wrong),
+				Groups:       groups[word.ID],
+			},
 		}
-
-		if err := json.Unmarshal([]byte(partsJSON), &word.Parts); err != nil {
-			return nil, err
+		if result[i].Groups == nil {
+			result[i].Groups = []string{}
 		}
-
-		words = append(words, word)
 	}
+	return result, nil
+}
 
-	return &models.ListResult{
-		Items:      words,
-		TotalItems: totalItems,
-	}, nil
+// successRate returns the percentage of correct answers, rounded to one decimal
+func successRate(correct, wrong int64) float64 {
+	total := correct + wrong
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(correct)*1000/float64(total)) / 10
 }
 
 // CreateWord creates a new word
 func (s *WordService) CreateWord(word *models.Word) error {
-	partsJSON, err := json.Marshal(word.Parts)
-	if err != nil {
-		return err
-	}
-
-	result, err := s.db.Exec(
-		"INSERT INTO words (japanese, romaji, english, parts) VALUES (?, ?, ?, ?)",
-		word.Japanese, word.Romaji, word.English, string(partsJSON),
-	)
-	if err != nil {
-		return err
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return err
-	}
-
-	word.ID = id
-	return nil
+	return s.store.Words().Create(word)
 }
 
 // UpdateWord updates an existing word
 func (s *WordService) UpdateWord(word *models.Word) error {
-	partsJSON, err := json.Marshal(word.Parts)
-	if err != nil {
-		return err
-	}
+	return orNotFound(s.store.Words().Update(word), ErrWordNotFound)
+}
 
-	result, err := s.db.Exec(
-		"UPDATE words SET japanese = ?, romaji = ?, english = ?, parts = ? WHERE id = ?",
-		word.Japanese, word.Romaji, word.English, string(partsJSON), word.ID,
-	)
-	if err != nil {
-		return err
-	}
+// DeleteWord deletes a word by ID
+func (s *WordService) DeleteWord(id int64) error {
+	return orNotFound(s.store.Words().Delete(id), ErrWordNotFound)
+}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
+// SearchWords finds words whose japanese, romaji or english text contains
+// every term of query, optionally restricted to words whose parts match all
+// filters (e.g. {"type": "verb"}). Exact matches rank first, followed by the
+// relevance of the match.
+func (s *WordService) SearchWords(query string, filters map[string]string, offset, limit int) (*models.ListResult, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 && len(filters) == 0 {
+		return nil, fmt.Errorf("%w: a search query or parts filter is required", ErrInvalidSearch)
 	}
 
-	if rows == 0 {
-		return errors.New("word not found")
+	for key := range filters {
+		if !partsKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("%w: invalid parts filter key %q", ErrInvalidSearch, key)
+		}
 	}
 
-	return nil
-}
-
-// DeleteWord deletes a word by ID
-func (s *WordService) DeleteWord(id int64) error {
-	result, err := s.db.Exec("DELETE FROM words WHERE id = ?", id)
+	words, totalItems, err := s.store.Words().Search(repository.WordSearch{Terms: terms, Parts: filters}, offset, limit)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
+	if words == nil {
+		words = []models.Word{}
 	}
 
-	if rows == 0 {
-		return errors.New("word not found")
-	}
+	return &models.ListResult{
+		Items:      words,
+		TotalItems: totalItems,
+	}, nil
+}
 
-	return nil
+// orNotFound replaces repository.ErrNotFound with the service error for the
+// missing resource
+func orNotFound(err error, notFound error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFound
+	}
+	return err
 }
@@ -0,0 +1,344 @@
+package testutil
+
+import "net/http"
+
+// activityCases covers /api/activities
+func activityCases() []Case {
+	return []Case{
+		{
+			Name:       "list study activities",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/activities",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(3),
+		},
+		{
+			Name:       "get study activity",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/activities/2",
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"activity_type": "quiz", "group_id": 1}),
+		},
+		{
+			Name:       "get study activity launched from an app",
+			Fixtures:   []string{"apps"},
+			Method:     http.MethodGet,
+			Path:       "/api/activities/2",
+			WantStatus: http.StatusOK,
+			Check: JSONFields(map[string]any{
+				"app_id":        1,
+				"name":          "Kana Quiz",
+				"thumbnail_url": "https://apps.example.com/kana.png",
+			}),
+		},
+		{
+			Name:       "get missing study activity",
+			Method:     http.MethodGet,
+			Path:       "/api/activities/99",
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("study_activity_not_found"),
+		},
+		{
+			Name:       "create study activity",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/activities",
+			Header:     admin,
+			Body:       map[string]any{"group_id": 2, "activity_type": "quiz"},
+			WantStatus: http.StatusCreated,
+			Check:      RowCount("SELECT COUNT(*) FROM study_activities WHERE group_id = 2", 1),
+		},
+		{
+			Name:     "create study activity when the group lookup fails",
+			Fixtures: []string{"groups"},
+			Setup: func(h *Harness) error {
+				_, err := h.DB.Exec("ALTER TABLE groups RENAME TO groups_moved")
+				return err
+			},
+			Method:     http.MethodPost,
+			Path:       "/api/activities",
+			Header:     admin,
+			Body:       map[string]any{"group_id": 1, "activity_type": "quiz"},
+			WantStatus: http.StatusInternalServerError,
+			Check:      ErrorCode("internal_error"),
+		},
+		{
+			Name:       "create study activity with malformed body",
+			Method:     http.MethodPost,
+			Path:       "/api/activities",
+			Header:     admin,
+			Body:       "{",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_body"),
+		},
+		{
+			Name:       "create study activity with unknown type and group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/activities",
+			Header:     admin,
+			Body:       map[string]any{"group_id": 99, "activity_type": "dance"},
+			WantStatus: http.StatusBadRequest,
+			Check: All(
+				InvalidFields("group_id", "activity_type"),
+				RowCount("SELECT COUNT(*) FROM study_activities", 0),
+			),
+		},
+		{
+			Name:       "update study activity",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodPut,
+			Path:       "/api/activities/3",
+			Header:     admin,
+			Body:       map[string]any{"group_id": 2, "activity_type": "quiz"},
+			WantStatus: http.StatusOK,
+			Check:      RowCount("SELECT COUNT(*) FROM study_activities WHERE id = 3 AND activity_type = 'quiz'", 1),
+		},
+		{
+			Name:       "update missing study activity",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPut,
+			Path:       "/api/activities/99",
+			Header:     admin,
+			Body:       map[string]any{"group_id": 1, "activity_type": "quiz"},
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("study_activity_not_found"),
+		},
+		{
+			Name:     "delete study activity",
+			Fixtures: []string{"groups"},
+			Setup: func(h *Harness) error {
+				_, err := h.DB.Exec("INSERT INTO study_activities (id, group_id, activity_type) VALUES (9, 1, 'quiz')")
+				return err
+			},
+			Method:     http.MethodDelete,
+			Path:       "/api/activities/9",
+			Header:     admin,
+			WantStatus: http.StatusNoContent,
+			Check:      RowCount("SELECT COUNT(*) FROM study_activities", 0),
+		},
+		{
+			Name:       "delete study activity with invalid id",
+			Method:     http.MethodDelete,
+			Path:       "/api/activities/abc",
+			Header:     admin,
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list study activity sessions",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/activities/1/sessions",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(1),
+		},
+	}
+}
+
+// appCases covers the activity catalog under /api/apps and launches
+func appCases() []Case {
+	kanaQuiz := map[string]any{
+		"name":          "Kana Quiz",
+		"activity_type": "quiz",
+		"launch_url":    "https://apps.example.com/kana?group={group_id}",
+	}
+	return []Case{
+		{
+			Name:       "list apps",
+			Fixtures:   []string{"apps"},
+			Method:     http.MethodGet,
+			Path:       "/api/apps",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(1),
+		},
+		{
+			Name:       "list apps of an empty catalog",
+			Method:     http.MethodGet,
+			Path:       "/api/apps",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(0),
+		},
+		{
+			Name:       "get app",
+			Fixtures:   []string{"apps"},
+			Method:     http.MethodGet,
+			Path:       "/api/apps/1",
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"name": "Kana Quiz", "activity_type": "quiz"}),
+		},
+		{
+			Name:       "get missing app",
+			Method:     http.MethodGet,
+			Path:       "/api/apps/99",
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("activity_app_not_found"),
+		},
+		{
+			Name:       "create app",
+			Method:     http.MethodPost,
+			Path:       "/api/apps",
+			Header:     admin,
+			Body:       kanaQuiz,
+			WantStatus: http.StatusCreated,
+			Check: All(
+				JSONFields(map[string]any{"name": "Kana Quiz", "thumbnail_url": ""}),
+				RowCount("SELECT COUNT(*) FROM activity_apps WHERE name = 'Kana Quiz'", 1),
+			),
+		},
+		{
+			Name:   "create app with unknown placeholder and relative thumbnail",
+			Method: http.MethodPost,
+			Path:   "/api/apps",
+			Header: admin,
+			Body: map[string]any{
+				"name":          "Kana Quiz",
+				"activity_type": "quiz",
+				"thumbnail_url": "kana.png",
+				"launch_url":    "https://apps.example.com/kana?deck={deck}",
+			},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("thumbnail_url", "launch_url"),
+		},
+		{
+			Name:       "create app with a launch URL that is not http",
+			Method:     http.MethodPost,
+			Path:       "/api/apps",
+			Header:     admin,
+			Body:       map[string]any{"name": "Kana Quiz", "activity_type": "quiz", "launch_url": "javascript:alert({group_id})"},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("launch_url"),
+		},
+		{
+			Name:       "create app with taken name",
+			Fixtures:   []string{"apps"},
+			Method:     http.MethodPost,
+			Path:       "/api/apps",
+			Header:     admin,
+			Body:       kanaQuiz,
+			WantStatus: http.StatusConflict,
+			Check:      ErrorCode("activity_app_name_taken"),
+		},
+		{
+			Name:       "create app anonymously",
+			Method:     http.MethodPost,
+			Path:       "/api/apps",
+			Body:       kanaQuiz,
+			WantStatus: http.StatusUnauthorized,
+			Check:      ErrorCode("authentication_required"),
+		},
+		{
+			Name:       "update app",
+			Fixtures:   []string{"apps"},
+			Method:     http.MethodPut,
+			Path:       "/api/apps/1",
+			Header:     admin,
+			Body:       map[string]any{"name": "Kana Drill", "activity_type": "typing", "launch_url": "https://drill.example.com/{session_id}"},
+			WantStatus: http.StatusOK,
+			Check:      RowCount("SELECT COUNT(*) FROM activity_apps WHERE name = 'Kana Drill' AND thumbnail_url = ''", 1),
+		},
+		{
+			Name:       "update missing app",
+			Method:     http.MethodPut,
+			Path:       "/api/apps/99",
+			Header:     admin,
+			Body:       kanaQuiz,
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("activity_app_not_found"),
+		},
+		{
+			Name:       "delete app keeps its activities",
+			Fixtures:   []string{"apps"},
+			Method:     http.MethodDelete,
+			Path:       "/api/apps/1",
+			Header:     admin,
+			WantStatus: http.StatusNoContent,
+			Check: All(
+				RowCount("SELECT COUNT(*) FROM activity_launches", 0),
+				RowCount("SELECT COUNT(*) FROM study_activities WHERE app_id IS NULL", 3),
+				RowCount("SELECT COUNT(*) FROM study_sessions", 3),
+			),
+		},
+		{
+			Name:       "launch app on a new group",
+			Fixtures:   []string{"apps"},
+			Method:     http.MethodPost,
+			Path:       "/api/apps/1/launch",
+			Body:       map[string]any{"group_id": 2},
+			WantStatus: http.StatusCreated,
+			Check: All(
+				JSONFields(map[string]any{
+					"launch_url": "https://apps.example.com/kana?group=2&title=Common%20Animals&session=4",
+					"session_id": 4,
+					"user_id":    1,
+				}),
+				RowCount("SELECT COUNT(*) FROM study_activities WHERE app_id = 1 AND group_id = 2 AND activity_type = 'quiz'", 1),
+				RowCount("SELECT COUNT(*) FROM study_sessions WHERE id = 4 AND status = 'active'", 1),
+			),
+		},
+		{
+			Name:       "launch app reuses its activity for the group",
+			Fixtures:   []string{"apps"},
+			Method:     http.MethodPost,
+			Path:       "/api/apps/1/launch",
+			Header:     admin,
+			Body:       map[string]any{"group_id": 1},
+			WantStatus: http.StatusCreated,
+			Check: All(
+				RowCount("SELECT COUNT(*) FROM study_activities", 3),
+				RowCount("SELECT COUNT(*) FROM study_sessions WHERE study_activity_id = 2", 2),
+				RowCount("SELECT COUNT(*) FROM activity_launches WHERE group_id = 1", 2),
+			),
+		},
+		{
+			Name:       "launch app as a learner",
+			Fixtures:   []string{"apps", "learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/apps/1/launch",
+			Header:     hana,
+			Body:       map[string]any{"group_id": 1},
+			WantStatus: http.StatusCreated,
+			Check:      RowCount("SELECT COUNT(*) FROM activity_launches l JOIN study_sessions ss ON ss.id = l.session_id WHERE l.user_id = 2 AND ss.user_id = 2", 1),
+		},
+		{
+			Name:       "launch app on missing group",
+			Fixtures:   []string{"apps"},
+			Method:     http.MethodPost,
+			Path:       "/api/apps/1/launch",
+			Body:       map[string]any{"group_id": 99},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("group_id"),
+		},
+		{
+			Name:       "launch missing app",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/apps/99/launch",
+			Body:       map[string]any{"group_id": 1},
+			WantStatus: http.StatusNotFound,
+			Check: All(
+				ErrorCode("activity_app_not_found"),
+				RowCount("SELECT COUNT(*) FROM study_sessions", 0),
+			),
+		},
+		{
+			Name:       "list launches",
+			Fixtures:   []string{"apps"},
+			Method:     http.MethodGet,
+			Path:       "/api/launches",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(1),
+		},
+		{
+			Name:       "list launches of another user",
+			Fixtures:   []string{"apps", "learners"},
+			Method:     http.MethodGet,
+			Path:       "/api/launches",
+			Header:     hana,
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(0),
+		},
+	}
+}
@@ -0,0 +1,247 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/erans/lang-portal/internal/pagination"
+)
+
+// paginationCases covers sorting, filtering and cursors across listings
+func paginationCases() []Case {
+	afterWord2 := (&pagination.Cursor{Sort: pagination.Sort{Field: "id"}, Key: int64(2), ID: 2}).Encode()
+
+	return []Case{
+		{
+			Name:       "list users by name descending following cursors",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodGet,
+			Path:       "/api/users?sort=-name&per_page=2",
+			Header:     admin,
+			WantStatus: http.StatusOK,
+			Check:      PageIDsAs(admin, "/api/users?sort=-name&per_page=2", 3, 2, 1),
+		},
+		{
+			Name:     "list apps by name following cursors",
+			Fixtures: []string{"apps"},
+			Setup: func(h *Harness) error {
+				_, err := h.DB.Exec(`INSERT INTO activity_apps (id, name, activity_type, launch_url) VALUES
+					(2, 'Audio Drill', 'typing', 'https://apps.example.com/audio'),
+					(3, 'Matching Pairs', 'matching', 'https://apps.example.com/pairs')`)
+				return err
+			},
+			Method:     http.MethodGet,
+			Path:       "/api/apps?per_page=2",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/apps?per_page=2", 2, 1, 3),
+		},
+		{
+			Name:     "list launches latest first following cursors",
+			Fixtures: []string{"apps"},
+			Setup: func(h *Harness) error {
+				_, err := h.DB.Exec(`INSERT INTO activity_launches (id, app_id, user_id, group_id, session_id, launch_url, launched_at) VALUES
+					(2, 1, 1, 1, 2, 'https://apps.example.com/kana?group=1', datetime(CURRENT_TIMESTAMP, '-1 day')),
+					(3, 1, 1, 1, 2, 'https://apps.example.com/kana?group=1', datetime(CURRENT_TIMESTAMP, '+1 minute'))`)
+				return err
+			},
+			Method:     http.MethodGet,
+			Path:       "/api/launches?per_page=1",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/launches?per_page=1", 3, 1, 2),
+		},
+		{
+			Name:       "list apps by an unknown field",
+			Method:     http.MethodGet,
+			Path:       "/api/apps?sort=launch_url",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list words by english following cursors",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words?sort=english&per_page=2",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/words?sort=english&per_page=2", 5, 3, 1, 2, 4),
+		},
+		{
+			Name:       "list words by japanese descending",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words?sort=-japanese&per_page=3",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/words?sort=-japanese&per_page=3", 4, 3, 5, 1, 2),
+		},
+		{
+			Name:       "list words after a cursor",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words?per_page=2&cursor=" + afterWord2,
+			WantStatus: http.StatusOK,
+			Check: All(
+				PageIDs("/api/words?per_page=2", 3, 4, 5),
+				func(_ *Harness, w *httptest.ResponseRecorder) error {
+					var body struct {
+						Pagination map[string]any `json:"pagination"`
+					}
+					if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+						return err
+					}
+					if _, ok := body.Pagination["current_page"]; ok {
+						return fmt.Errorf("cursor page has a current_page")
+					}
+					if body.Pagination["total_items"] != float64(5) {
+						return fmt.Errorf("total_items = %v, want 5", body.Pagination["total_items"])
+					}
+					return nil
+				},
+			),
+		},
+		{
+			Name:       "list words with an unknown sort",
+			Method:     http.MethodGet,
+			Path:       "/api/words?sort=parts",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list words with a malformed cursor",
+			Method:     http.MethodGet,
+			Path:       "/api/words?cursor=not-a-cursor",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list words with a cursor and a page",
+			Method:     http.MethodGet,
+			Path:       "/api/words?page=2&cursor=" + afterWord2,
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list words with a cursor of another sort",
+			Method:     http.MethodGet,
+			Path:       "/api/words?sort=english&cursor=" + afterWord2,
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list words in a group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/words?group_id=1",
+			WantStatus: http.StatusOK,
+			Check:      All(ItemCount(2), JSONFields(map[string]any{"pagination": map[string]any{"current_page": 1, "items_per_page": 100, "total_items": 2, "total_pages": 1}})),
+		},
+		{
+			Name:       "list groups by name following cursors",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/groups?sort=-name&per_page=2",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/groups?sort=-name&per_page=2", 3, 2, 1),
+		},
+		{
+			Name:       "list group words following cursors",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/groups/1/words?per_page=1",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/groups/1/words?per_page=1", 1, 2),
+		},
+		{
+			Name:       "list words of missing group",
+			Method:     http.MethodGet,
+			Path:       "/api/groups/99/words",
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("group_not_found"),
+		},
+		{
+			Name:       "list group study sessions of missing group",
+			Method:     http.MethodGet,
+			Path:       "/api/groups/99/study-sessions",
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("group_not_found"),
+		},
+		{
+			Name:       "list study sessions following cursors",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions?per_page=1",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/study-sessions?per_page=1", 2, 1, 3),
+		},
+		{
+			Name:       "list study sessions oldest first",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions?sort=start_time&per_page=2",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/study-sessions?sort=start_time&per_page=2", 3, 1, 2),
+		},
+		{
+			Name:       "list study sessions by status and group",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions?status=completed&group_id=1",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/study-sessions?status=completed&group_id=1", 1),
+		},
+		{
+			Name:       "list study sessions with an unknown status",
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions?status=paused",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list activities created together following cursors",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/activities?per_page=1",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/activities?per_page=1", 3, 2, 1),
+		},
+		{
+			Name:       "list activities of a type in a group",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/activities?group_id=1&type=flashcard",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/activities?group_id=1&type=flashcard", 1),
+		},
+		{
+			Name:       "list activity sessions",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/activities/1/sessions",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/activities/1/sessions", 1),
+		},
+		{
+			Name:       "list sessions of missing activity",
+			Method:     http.MethodGet,
+			Path:       "/api/activities/99/sessions",
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("study_activity_not_found"),
+		},
+		{
+			Name:       "list correct session words following cursors",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions/1/words?correct=true&per_page=1",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/study-sessions/1/words?correct=true&per_page=1", 1, 2),
+		},
+		{
+			Name:       "list wrong session words",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions/1/words?correct=false",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(0),
+		},
+	}
+}
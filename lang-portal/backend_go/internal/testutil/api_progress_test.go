@@ -0,0 +1,107 @@
+package testutil
+
+import "net/http"
+
+// reviewCases covers /api/review
+func reviewCases() []Case {
+	return []Case{
+		{
+			Name:       "list due words",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/review/due",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(5),
+		},
+		{
+			Name:       "list due words in group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/review/due?group_id=1&limit=1",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(1),
+		},
+		{
+			Name:       "list due words with invalid limit",
+			Method:     http.MethodGet,
+			Path:       "/api/review/due?limit=abc",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list due words with invalid group",
+			Method:     http.MethodGet,
+			Path:       "/api/review/due?group_id=abc",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list due words in missing group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/review/due?group_id=99",
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("group_not_found"),
+		},
+	}
+}
+
+// dashboardCases covers /api/dashboard
+func dashboardCases() []Case {
+	return []Case{
+		{
+			Name:       "dashboard last session",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodGet,
+			Path:       "/api/dashboard/last_session",
+			WantStatus: http.StatusOK,
+			Check: JSONFields(map[string]any{
+				"session_id":      2,
+				"group_name":      "Basic Greetings",
+				"words_reviewed":  1,
+				"correct_answers": 0,
+			}),
+		},
+		{
+			Name:       "dashboard last session on empty database",
+			Method:     http.MethodGet,
+			Path:       "/api/dashboard/last_session",
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"message": "No study sessions found"}),
+		},
+		{
+			Name:       "dashboard stats",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodGet,
+			Path:       "/api/dashboard/stats",
+			WantStatus: http.StatusOK,
+			Check: JSONFields(map[string]any{
+				"sessions_completed":   2,
+				"total_words_reviewed": 4,
+				"success_rate":         75,
+				"study_streak_days":    2,
+			}),
+		},
+		{
+			Name:       "dashboard stats of another user",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodGet,
+			Path:       "/api/dashboard/stats",
+			Header:     hana,
+			WantStatus: http.StatusOK,
+			Check: JSONFields(map[string]any{
+				"sessions_completed":   0,
+				"total_words_reviewed": 1,
+				"success_rate":         0,
+				"study_streak_days":    1,
+			}),
+		},
+		{
+			Name:       "dashboard progress",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodGet,
+			Path:       "/api/dashboard/progress",
+			WantStatus: http.StatusOK,
+		},
+	}
+}
@@ -0,0 +1,261 @@
+package testutil
+
+import "net/http"
+
+// sessionCases covers /api/study-sessions
+func sessionCases() []Case {
+	return []Case{
+		{
+			Name:       "list study sessions",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(3),
+		},
+		{
+			Name:       "list study sessions of one user",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions",
+			Header:     hana,
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(1),
+		},
+		{
+			Name:       "get study session",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions/2",
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"status": "active", "study_activity_id": 2}),
+		},
+		{
+			Name:       "get missing study session",
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions/99",
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("study_session_not_found"),
+		},
+		{
+			Name:       "get another user's study session",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions/4",
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("study_session_not_found"),
+		},
+		{
+			Name:       "get study session with invalid id",
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions/abc",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "create study session",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodPost,
+			Path:       "/api/study-sessions",
+			Body:       map[string]any{"study_activity_id": 1, "status": "completed", "score": 100},
+			WantStatus: http.StatusCreated,
+			Check: All(
+				JSONFields(map[string]any{"id": 4, "status": "active", "user_id": 1}),
+				RowCount("SELECT COUNT(*) FROM study_sessions WHERE id = 4 AND status = 'active' AND end_time IS NULL AND score IS NULL", 1),
+			),
+		},
+		{
+			Name:       "create study session with malformed body",
+			Method:     http.MethodPost,
+			Path:       "/api/study-sessions",
+			Body:       "{",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_body"),
+		},
+		{
+			Name:       "create study session for missing activity",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodPost,
+			Path:       "/api/study-sessions",
+			Body:       map[string]any{"study_activity_id": 99},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("study_activity_id"),
+		},
+		{
+			Name:     "end study session scores it with its activity type's strategy",
+			Fixtures: []string{"sessions"},
+			Setup: func(h *Harness) error {
+				_, err := h.DB.Exec(`INSERT INTO word_review_items (session_id, word_id, is_correct) VALUES
+					(2, 1, 1), (2, 2, 1), (2, 1, 1), (2, 2, 0)`)
+				return err
+			},
+			Method:     http.MethodPut,
+			Path:       "/api/study-sessions/2/end",
+			Body:       map[string]any{"score": 100},
+			WantStatus: http.StatusOK,
+			Check: All(
+				JSONFields(map[string]any{"status": "completed", "score": 75}),
+				RowCount("SELECT COUNT(*) FROM study_sessions WHERE id = 2 AND status = 'completed' AND score = 75 AND end_time IS NOT NULL", 1),
+				RowCount(`SELECT COUNT(*) FROM study_sessions WHERE id = 2
+					AND json_extract(score_breakdown, '$.strategy') = 'difficulty'
+					AND json_extract(score_breakdown, '$.accuracy') = 75
+					AND json_array_length(score_breakdown, '$.items') = 4`, 1),
+			),
+		},
+		{
+			Name:       "end completed study session",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodPut,
+			Path:       "/api/study-sessions/1/end",
+			WantStatus: http.StatusConflict,
+			Check: All(
+				ErrorCode("illegal_session_transition"),
+				ErrorDetail("from", "completed"),
+				RowCount("SELECT COUNT(*) FROM study_sessions WHERE id = 1 AND score = 85", 1),
+			),
+		},
+		{
+			Name:       "abandon study session",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodPut,
+			Path:       "/api/study-sessions/2",
+			Body:       map[string]any{"status": "abandoned"},
+			WantStatus: http.StatusOK,
+			Check:      RowCount("SELECT COUNT(*) FROM study_sessions WHERE id = 2 AND status = 'abandoned' AND end_time IS NOT NULL AND score IS NULL", 1),
+		},
+		{
+			Name:       "reopen completed study session",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodPut,
+			Path:       "/api/study-sessions/1",
+			Body:       map[string]any{"status": "active"},
+			WantStatus: http.StatusConflict,
+			Check: All(
+				ErrorCode("illegal_session_transition"),
+				ErrorDetail("to", "active"),
+				RowCount("SELECT COUNT(*) FROM study_sessions WHERE id = 1 AND status = 'completed'", 1),
+			),
+		},
+		{
+			Name:       "update study session with unknown status",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodPut,
+			Path:       "/api/study-sessions/2",
+			Body:       map[string]any{"status": "in_progress"},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("status"),
+		},
+		{
+			Name:       "update another user's study session",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPut,
+			Path:       "/api/study-sessions/4",
+			Body:       map[string]any{"status": "abandoned"},
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("study_session_not_found"),
+		},
+		{
+			Name:       "list study session words",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions/1/words",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(2),
+		},
+		{
+			Name:       "list study session review items",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions/1/review-items",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(2),
+		},
+		{
+			Name:       "list study session review items page by page",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodGet,
+			Path:       "/api/study-sessions/1/review-items?per_page=1",
+			WantStatus: http.StatusOK,
+			Check:      PageIDs("/api/study-sessions/1/review-items?per_page=1", 1, 2),
+		},
+		{
+			Name:       "record review",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodPost,
+			Path:       "/api/study-sessions/2/words/1/review",
+			Body:       map[string]any{"correct": true, "response": "hello"},
+			WantStatus: http.StatusCreated,
+			Check: All(
+				JSONFields(map[string]any{"word_id": 1, "is_correct": true, "response": "hello"}),
+				RowCount("SELECT COUNT(*) FROM word_review_schedules WHERE word_id = 1", 1),
+			),
+		},
+		{
+			Name:       "record review without answer",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodPost,
+			Path:       "/api/study-sessions/2/words/1/review",
+			Body:       map[string]any{},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("correct"),
+		},
+		{
+			Name:       "record review in missing session",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodPost,
+			Path:       "/api/study-sessions/99/words/1/review",
+			Body:       map[string]any{"correct": true},
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("study_session_not_found"),
+		},
+		{
+			Name:       "record review in another user's session",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/study-sessions/2/words/1/review",
+			Body:       map[string]any{"correct": true},
+			Header:     hana,
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("study_session_not_found"),
+		},
+		{
+			Name:       "record review as another user",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/study-sessions/4/words/1/review",
+			Body:       map[string]any{"correct": true},
+			Header:     hana,
+			WantStatus: http.StatusCreated,
+			Check: RowCount(
+				"SELECT COUNT(*) FROM word_review_schedules WHERE word_id = 1 AND user_id = 2", 1,
+			),
+		},
+		{
+			Name:       "record review in completed session",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodPost,
+			Path:       "/api/study-sessions/1/words/1/review",
+			Body:       map[string]any{"correct": true},
+			WantStatus: http.StatusPreconditionFailed,
+			Check:      ErrorCode("study_session_not_active"),
+		},
+		{
+			Name:       "record review of word outside session group",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodPost,
+			Path:       "/api/study-sessions/2/words/3/review",
+			Body:       map[string]any{"correct": true},
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("word_not_in_session_group"),
+		},
+		{
+			Name:       "record review of missing word",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodPost,
+			Path:       "/api/study-sessions/2/words/99/review",
+			Body:       map[string]any{"correct": true},
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("word_not_found"),
+		},
+	}
+}
@@ -0,0 +1,190 @@
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erans/lang-portal/internal/api"
+)
+
+// TestAPI covers the success and error paths of every API handler. Error
+// paths assert both the status and the error code of the response envelope.
+func TestAPI(t *testing.T) {
+	var cases []Case
+	cases = append(cases, userCases()...)
+	cases = append(cases, wordCases()...)
+	cases = append(cases, groupCases()...)
+	cases = append(cases, membershipCases()...)
+	cases = append(cases, sessionCases()...)
+	cases = append(cases, activityCases()...)
+	cases = append(cases, paginationCases()...)
+	cases = append(cases, appCases()...)
+	cases = append(cases, reviewCases()...)
+	cases = append(cases, dashboardCases()...)
+	cases = append(cases, exportCases()...)
+	cases = append(cases, importCases(t)...)
+	cases = append(cases, systemCases()...)
+	cases = append(cases, Case{
+		Name:       "unknown route",
+		Method:     http.MethodGet,
+		Path:       "/api/unknown",
+		WantStatus: http.StatusNotFound,
+		Check:      ErrorCode("route_not_found"),
+	}, Case{
+		Name:       "unknown route without a token",
+		Options:    &defaultOptions,
+		Method:     http.MethodGet,
+		Path:       "/unknown",
+		WantStatus: http.StatusNotFound,
+		Check:      ErrorCode("route_not_found"),
+	}, Case{
+		Name:       "health check without a token",
+		Options:    &defaultOptions,
+		Method:     http.MethodGet,
+		Path:       "/health",
+		WantStatus: http.StatusOK,
+		Check:      JSONFields(map[string]any{"status": "healthy"}),
+	})
+	Run(t, cases)
+}
+
+// bearer returns the header that authenticates a request with a token
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+var (
+	// defaultOptions are the API options when nothing is configured; cases
+	// run with Options otherwise
+	defaultOptions = api.DefaultOptions()
+	// admin authenticates as the default user, an admin, in every harness
+	admin = bearer("lp_test_admin")
+	// hana authenticates as the learner of the learners fixture
+	hana = bearer("lp_test_hana")
+	// sensei authenticates as the teacher of the learners fixture
+	sensei = bearer("lp_test_sensei")
+)
+
+// expiredToken inserts a token for the default user that expired yesterday;
+// its secret is lp_test_expired
+func expiredToken(h *Harness) error {
+	_, err := h.DB.Exec(`
+		INSERT INTO api_tokens (user_id, name, token_hash, created_at, expires_at)
+		VALUES (1, 'expired', '53f7f9bc96f1bb95fe439a241cac2326728b073550f7bbd5b94589628956eca8',
+		        DATETIME('now', '-2 day'), DATETIME('now', '-1 day'))
+	`)
+	return err
+}
+
+// Challenged returns a check that expects the bearer challenge of a 401
+func Challenged() func(*Harness, *httptest.ResponseRecorder) error {
+	return func(_ *Harness, w *httptest.ResponseRecorder) error {
+		if got := w.Header().Get("WWW-Authenticate"); !strings.HasPrefix(got, "Bearer ") {
+			return fmt.Errorf("WWW-Authenticate = %q, want a Bearer challenge", got)
+		}
+		return nil
+	}
+}
+
+func systemCases() []Case {
+	backupPath := filepath.Join(os.TempDir(), "lang-portal-harness-backup.db")
+
+	return []Case{
+		{
+			Name:       "system stats",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodGet,
+			Path:       "/api/system/stats",
+			Header:     admin,
+			WantStatus: http.StatusOK,
+		},
+		{
+			Name:       "system health",
+			Method:     http.MethodGet,
+			Path:       "/api/system/health",
+			Header:     admin,
+			WantStatus: http.StatusOK,
+		},
+		{
+			Name:     "backup database",
+			Fixtures: []string{"words"},
+			Setup: func(h *Harness) error {
+				if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				return nil
+			},
+			Method:     http.MethodPost,
+			Path:       "/api/system/backup",
+			Header:     admin,
+			Body:       map[string]any{"backup_path": backupPath},
+			WantStatus: http.StatusOK,
+		},
+		{
+			Name:       "backup database without path",
+			Method:     http.MethodPost,
+			Path:       "/api/system/backup",
+			Header:     admin,
+			Body:       map[string]any{},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("backup_path"),
+		},
+		{
+			Name:       "database size",
+			Method:     http.MethodGet,
+			Path:       "/api/system/database/size",
+			Header:     admin,
+			WantStatus: http.StatusOK,
+		},
+		{
+			Name:       "last backup info",
+			Method:     http.MethodGet,
+			Path:       "/api/system/backup/last",
+			Header:     admin,
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("backup_not_found"),
+		},
+		{
+			Name:       "prune old data",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodPost,
+			Path:       "/api/system/prune",
+			Header:     admin,
+			Body:       map[string]any{"retention_days": 30},
+			WantStatus: http.StatusOK,
+			Check:      RowCount("SELECT COUNT(*) FROM study_sessions", 3),
+		},
+		{
+			Name:       "prune old data with invalid retention",
+			Method:     http.MethodPost,
+			Path:       "/api/system/prune",
+			Header:     admin,
+			Body:       map[string]any{"retention_days": 0},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("retention_days"),
+		},
+		{
+			Name:       "prune old data as a learner",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/system/prune",
+			Header:     hana,
+			Body:       map[string]any{"retention_days": 1},
+			WantStatus: http.StatusForbidden,
+			Check:      ErrorCode("forbidden"),
+		},
+		{
+			Name:       "backup database anonymously",
+			Method:     http.MethodPost,
+			Path:       "/api/system/backup",
+			Body:       map[string]any{"backup_path": "anonymous.db"},
+			WantStatus: http.StatusUnauthorized,
+			Check:      ErrorCode("authentication_required"),
+		},
+	}
+}
@@ -0,0 +1,358 @@
+package testutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erans/lang-portal/internal/export"
+	"github.com/erans/lang-portal/internal/models"
+)
+
+// systemCases covers /api/system
+// attachment returns a check that expects a download named filename
+func attachment(filename string) func(*Harness, *httptest.ResponseRecorder) error {
+	return func(_ *Harness, w *httptest.ResponseRecorder) error {
+		want := `attachment; filename="` + filename + `"`
+		if got := w.Header().Get("Content-Disposition"); got != want {
+			return fmt.Errorf("Content-Disposition = %q, want %q", got, want)
+		}
+		return nil
+	}
+}
+
+// apkgNotes returns the fields of every note in an Anki package, in order
+func apkgNotes(body []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("response is not a zip: %w", err)
+	}
+	entry, err := archive.Open("collection.anki2")
+	if err != nil {
+		return nil, err
+	}
+	defer entry.Close()
+
+	tmp, err := os.CreateTemp("", "lang-portal-harness-*.anki2")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, entry)
+	tmp.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var cards int
+	if err := db.QueryRow("SELECT COUNT(*) FROM cards JOIN notes ON notes.id = cards.nid").Scan(&cards); err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT flds FROM notes ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes [][]string
+	for rows.Next() {
+		var flds string
+		if err := rows.Scan(&flds); err != nil {
+			return nil, err
+		}
+		notes = append(notes, strings.Split(flds, "\x1f"))
+	}
+	if cards != len(notes) {
+		return nil, fmt.Errorf("%d cards for %d notes", cards, len(notes))
+	}
+	return notes, rows.Err()
+}
+
+func exportCases() []Case {
+	return []Case{
+		{
+			Name:       "export group as CSV",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/export?format=csv&group_id=1",
+			WantStatus: http.StatusOK,
+			Check: All(
+				attachment("basic-greetings.csv"),
+				func(_ *Harness, w *httptest.ResponseRecorder) error {
+					rows, err := csv.NewReader(w.Body).ReadAll()
+					if err != nil {
+						return err
+					}
+					want := [][]string{
+						{"id", "japanese", "romaji", "english", "parts.formality", "parts.type"},
+						{"1", "こんにちは", "konnichiwa", "hello", "neutral", "greeting"},
+						{"2", "ありがとう", "arigatou", "thank you", "neutral", "expression"},
+					}
+					if fmt.Sprint(rows) != fmt.Sprint(want) {
+						return fmt.Errorf("rows = %q, want %q", rows, want)
+					}
+					return nil
+				},
+			),
+		},
+		{
+			Name:       "export vocabulary as seed JSON",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/export?format=json",
+			WantStatus: http.StatusOK,
+			Check: All(
+				attachment("vocabulary.json"),
+				func(_ *Harness, w *httptest.ResponseRecorder) error {
+					var file map[string]json.RawMessage
+					if err := json.Unmarshal(w.Body.Bytes(), &file); err != nil {
+						return err
+					}
+					if _, ok := file["group"]; ok {
+						return fmt.Errorf("vocabulary export has a group block")
+					}
+					var words []map[string]any
+					if err := json.Unmarshal(file["words"], &words); err != nil {
+						return err
+					}
+					if len(words) != 5 || words[2]["japanese"] != "猫" || fmt.Sprint(words[2]["parts"]) != "map[category:animals type:noun]" {
+						return fmt.Errorf("words = %v, want the 5 fixture words in order", words)
+					}
+					return nil
+				},
+			),
+		},
+		{
+			Name:       "export group as Anki package",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/export?format=apkg&group_id=2",
+			WantStatus: http.StatusOK,
+			Check: All(
+				attachment("common-animals.apkg"),
+				func(_ *Harness, w *httptest.ResponseRecorder) error {
+					notes, err := apkgNotes(w.Body.Bytes())
+					if err != nil {
+						return err
+					}
+					want := [][]string{{"猫", "neko", "cat", "animals", "noun"}}
+					if fmt.Sprint(notes) != fmt.Sprint(want) {
+						return fmt.Errorf("notes = %q, want %q", notes, want)
+					}
+					return nil
+				},
+			),
+		},
+		{
+			Name:       "export in unknown format",
+			Method:     http.MethodGet,
+			Path:       "/api/export?format=xlsx",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "export unknown group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/export?format=apkg&group_id=99",
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("group_not_found"),
+		},
+		{
+			Name:     "export failing before the file starts is a JSON error",
+			Fixtures: []string{"groups"},
+			Setup: func(h *Harness) error {
+				// The package is built in a temporary file that cannot be created
+				h.t.Setenv("TMPDIR", filepath.Join(h.t.TempDir(), "missing"))
+				return nil
+			},
+			Method:     http.MethodGet,
+			Path:       "/api/export?format=apkg&group_id=1",
+			WantStatus: http.StatusInternalServerError,
+			Check: All(
+				ErrorCode("internal_error"),
+				func(_ *Harness, w *httptest.ResponseRecorder) error {
+					if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+						return fmt.Errorf("Content-Type = %q, want application/json", got)
+					}
+					if got := w.Header().Get("Content-Disposition"); got != "" {
+						return fmt.Errorf("Content-Disposition = %q, want none", got)
+					}
+					return nil
+				},
+			),
+		},
+		{
+			Name:       "export with an invalid group ID",
+			Method:     http.MethodGet,
+			Path:       "/api/export?group_id=abc",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+	}
+}
+
+func importCases(t *testing.T) []Case {
+	// An Anki package as the export writes it
+	var apkg bytes.Buffer
+	err := export.Write(&apkg, export.APKG, &export.Deck{
+		Group: &models.Group{Name: "Pets"},
+		Words: []models.Word{
+			{ID: 1, Japanese: "犬", Romaji: "inu", English: "dog", Parts: map[string]any{"type": "noun"}},
+			{ID: 2, Japanese: "猫", Romaji: "neko", English: "cat", Parts: map[string]any{"type": "noun", "category": "animals"}},
+		},
+		ExportedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("writing Anki package: %v", err)
+	}
+
+	return []Case{
+		{
+			Name:     "import CSV with mapped columns into a new group",
+			Fixtures: []string{"groups"},
+			Method:   http.MethodPost,
+			Path: "/api/import?format=csv&group=Starter" +
+				"&columns[japanese]=Kanji&columns[romaji]=Reading&columns[english]=Meaning&columns[parts.type]=Type",
+			Body:       "Kanji,Reading,Meaning,Type\n犬,inu,dog,noun\nこんにちは,konnichiwa,hello,greeting\n",
+			Header:     admin,
+			WantStatus: http.StatusOK,
+			Check: All(
+				JSONFields(map[string]any{"committed": true, "group_created": true, "created": 1, "updated": 1, "skipped": 0, "linked": 2}),
+				RowCount(`SELECT COUNT(*) FROM words WHERE japanese = '犬' AND json_extract(parts, '$.type') = 'noun'`, 1),
+				RowCount(`SELECT COUNT(*) FROM words WHERE id = 1 AND json_extract(parts, '$.formality') IS NULL`, 1),
+				RowCount(`SELECT COUNT(*) FROM word_groups wg JOIN groups g ON g.id = wg.group_id WHERE g.name = 'Starter'`, 2),
+			),
+		},
+		{
+			Name:     "import TSV without a header as a dry run",
+			Fixtures: []string{"groups"},
+			Method:   http.MethodPost,
+			Path: "/api/import?format=tsv&group=Pets&header=false&dry_run=true" +
+				"&columns[japanese]=1&columns[romaji]=2&columns[english]=3",
+			Body:       "犬\tinu\tdog\n",
+			Header:     admin,
+			WantStatus: http.StatusOK,
+			Check: All(
+				JSONFields(map[string]any{"dry_run": true, "committed": false, "group_created": true, "created": 1}),
+				RowCount("SELECT COUNT(*) FROM groups WHERE name = 'Pets'", 0),
+				RowCount("SELECT COUNT(*) FROM words", 5),
+			),
+		},
+		{
+			Name:       "import seed JSON into its own group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/import?format=json",
+			Body:       `{"group": {"name": "Basic Greetings"}, "words": [{"japanese": "こんにちは", "romaji": "konnichiwa", "english": "hello", "parts": {"type": "greeting", "formality": "neutral"}}]}`,
+			Header:     admin,
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"group_id": 1, "group_created": false, "skipped": 1, "linked": 0}),
+		},
+		{
+			Name:       "import Anki package into an existing group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/import?format=apkg&group_id=2",
+			Body:       apkg.Bytes(),
+			Header:     admin,
+			WantStatus: http.StatusOK,
+			Check: All(
+				JSONFields(map[string]any{"created": 1, "skipped": 1, "linked": 1}),
+				RowCount(`SELECT COUNT(*) FROM words w JOIN word_groups wg ON wg.word_id = w.id
+					WHERE wg.group_id = 2 AND w.japanese = '犬' AND json_extract(w.parts, '$.type') = 'noun'`, 1),
+			),
+		},
+		{
+			Name:       "import with invalid rows imports nothing",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/import?format=csv&group_id=1",
+			Body:       "japanese,romaji,english\n犬,inu,dog\nねこ,,cat\nうま,uma\n",
+			Header:     admin,
+			WantStatus: http.StatusBadRequest,
+			Check: All(
+				ErrorCode("import_failed"),
+				func(_ *Harness, w *httptest.ResponseRecorder) error {
+					var body struct {
+						Error struct {
+							Details struct {
+								Report struct {
+									Errored int `json:"errored"`
+									Rows    []struct {
+										Row    int    `json:"row"`
+										Status string `json:"status"`
+									} `json:"rows"`
+								} `json:"report"`
+							} `json:"details"`
+						} `json:"error"`
+					}
+					if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+						return err
+					}
+					report := body.Error.Details.Report
+					if report.Errored != 2 || len(report.Rows) != 3 || report.Rows[1].Row != 3 || report.Rows[1].Status != "error" {
+						return fmt.Errorf("report = %+v, want rows 3 and 4 in error", report)
+					}
+					return nil
+				},
+				RowCount("SELECT COUNT(*) FROM words", 5),
+			),
+		},
+		{
+			Name:       "import without a group",
+			Method:     http.MethodPost,
+			Path:       "/api/import?format=csv",
+			Body:       "japanese,romaji,english\n犬,inu,dog\n",
+			Header:     admin,
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("import_group_required"),
+		},
+		{
+			Name:       "import unreadable file",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/import?format=apkg&group_id=1",
+			Body:       "not a zip",
+			Header:     admin,
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_import_file"),
+		},
+		{
+			Name:       "import as a learner",
+			Fixtures:   []string{"learners", "groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/import?format=csv&group_id=1",
+			Body:       "japanese,romaji,english\n犬,inu,dog\n",
+			Header:     hana,
+			WantStatus: http.StatusForbidden,
+			Check:      ErrorCode("forbidden"),
+		},
+		{
+			Name:       "import with an invalid group ID",
+			Method:     http.MethodPost,
+			Path:       "/api/import?format=csv&group_id=abc",
+			Body:       "japanese,romaji,english\n犬,inu,dog\n",
+			Header:     admin,
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+	}
+}
@@ -0,0 +1,317 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/config"
+)
+
+// userCases covers /api/users, how requests are authenticated and which
+// roles may manage users and tokens
+func userCases() []Case {
+	return []Case{
+		{
+			Name:       "list users",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodGet,
+			Path:       "/api/users",
+			Header:     sensei,
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(3),
+		},
+		{
+			Name:       "list users as a learner",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodGet,
+			Path:       "/api/users",
+			Header:     hana,
+			WantStatus: http.StatusForbidden,
+			Check:      ErrorCode("forbidden"),
+		},
+		{
+			Name:       "list users anonymously",
+			Method:     http.MethodGet,
+			Path:       "/api/users",
+			WantStatus: http.StatusUnauthorized,
+			Check:      All(ErrorCode("authentication_required"), Challenged()),
+		},
+		{
+			Name:       "requests without a token need one by default",
+			Options:    &defaultOptions,
+			Method:     http.MethodGet,
+			Path:       "/api/words",
+			WantStatus: http.StatusUnauthorized,
+			Check: All(
+				ErrorCode("authentication_required"),
+				Challenged(),
+				func(*Harness, *httptest.ResponseRecorder) error {
+					if config.Default().Auth.Anonymous {
+						return fmt.Errorf("anonymous access is on in the default config")
+					}
+					return nil
+				},
+			),
+		},
+		{
+			Name:       "requests with a token are served by default",
+			Options:    &defaultOptions,
+			Method:     http.MethodGet,
+			Path:       "/api/users/me",
+			Header:     admin,
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"id": 1, "role": "admin"}),
+		},
+		{
+			Name:       "anonymous requests act for the default user as a learner",
+			Method:     http.MethodGet,
+			Path:       "/api/users/me",
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"id": 1, "name": "default", "role": "learner"}),
+		},
+		{
+			Name:       "requests act for the user of the token",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodGet,
+			Path:       "/api/users/me",
+			Header:     hana,
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"id": 2, "name": "hana", "role": "learner"}),
+		},
+		{
+			Name:       "the default user's token acts as an admin",
+			Method:     http.MethodGet,
+			Path:       "/api/users/me",
+			Header:     admin,
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"id": 1, "role": "admin"}),
+		},
+		{
+			Name:       "request with an unknown token",
+			Method:     http.MethodGet,
+			Path:       "/api/words",
+			Header:     bearer("lp_unknown"),
+			WantStatus: http.StatusUnauthorized,
+			Check:      All(ErrorCode("invalid_token"), Challenged()),
+		},
+		{
+			Name:       "request with an expired token",
+			Setup:      expiredToken,
+			Method:     http.MethodGet,
+			Path:       "/api/words",
+			Header:     bearer("lp_test_expired"),
+			WantStatus: http.StatusUnauthorized,
+			Check:      ErrorCode("invalid_token"),
+		},
+		{
+			Name:       "request with another authorization scheme",
+			Method:     http.MethodGet,
+			Path:       "/api/words",
+			Header:     http.Header{"Authorization": {"Basic ZGVmYXVsdDo="}},
+			WantStatus: http.StatusUnauthorized,
+			Check:      ErrorCode("invalid_token"),
+		},
+		{
+			Name:       "get user",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodGet,
+			Path:       "/api/users/2",
+			Header:     admin,
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"name": "hana", "role": "learner"}),
+		},
+		{
+			Name:       "get missing user",
+			Method:     http.MethodGet,
+			Path:       "/api/users/99",
+			Header:     admin,
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("user_not_found"),
+		},
+		{
+			Name:       "create user",
+			Method:     http.MethodPost,
+			Path:       "/api/users",
+			Header:     admin,
+			Body:       map[string]any{"name": "kenji"},
+			WantStatus: http.StatusCreated,
+			Check: All(
+				JSONFields(map[string]any{"id": 2, "name": "kenji", "role": "learner"}),
+				RowCount("SELECT COUNT(*) FROM users WHERE name = 'kenji' AND role = 'learner'", 1),
+			),
+		},
+		{
+			Name:       "create teacher",
+			Method:     http.MethodPost,
+			Path:       "/api/users",
+			Header:     admin,
+			Body:       map[string]any{"name": "kenji", "role": "teacher"},
+			WantStatus: http.StatusCreated,
+			Check:      JSONFields(map[string]any{"role": "teacher"}),
+		},
+		{
+			Name:       "create user as a teacher",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/users",
+			Header:     sensei,
+			Body:       map[string]any{"name": "kenji"},
+			WantStatus: http.StatusForbidden,
+			Check:      ErrorCode("forbidden"),
+		},
+		{
+			Name:       "create user with a taken name",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/users",
+			Header:     admin,
+			Body:       map[string]any{"name": "hana"},
+			WantStatus: http.StatusConflict,
+			Check:      ErrorCode("user_name_taken"),
+		},
+		{
+			Name:       "create user with blank name and unknown role",
+			Method:     http.MethodPost,
+			Path:       "/api/users",
+			Header:     admin,
+			Body:       map[string]any{"name": " ", "role": "principal"},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("name", "role"),
+		},
+		{
+			Name:       "change role",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPut,
+			Path:       "/api/users/2/role",
+			Header:     admin,
+			Body:       map[string]any{"role": "teacher"},
+			WantStatus: http.StatusOK,
+			Check: All(
+				JSONFields(map[string]any{"id": 2, "role": "teacher"}),
+				RowCount("SELECT COUNT(*) FROM users WHERE id = 2 AND role = 'teacher'", 1),
+			),
+		},
+		{
+			Name:       "demote the last admin",
+			Method:     http.MethodPut,
+			Path:       "/api/users/1/role",
+			Header:     admin,
+			Body:       map[string]any{"role": "teacher"},
+			WantStatus: http.StatusConflict,
+			Check:      ErrorCode("last_admin"),
+		},
+		{
+			Name:       "change role of missing user",
+			Method:     http.MethodPut,
+			Path:       "/api/users/99/role",
+			Header:     admin,
+			Body:       map[string]any{"role": "teacher"},
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("user_not_found"),
+		},
+		{
+			Name:       "list own tokens",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodGet,
+			Path:       "/api/users/2/tokens",
+			Header:     hana,
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(1),
+		},
+		{
+			Name:       "issue own token",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/users/2/tokens",
+			Header:     hana,
+			Body:       map[string]any{"name": "phone", "expires_in_days": 30},
+			WantStatus: http.StatusCreated,
+			Check: All(
+				JSONFields(map[string]any{"user_id": 2, "name": "phone"}),
+				issuedTokenAuthenticates("hana"),
+				RowCount("SELECT COUNT(*) FROM api_tokens WHERE user_id = 2 AND expires_at IS NOT NULL", 1),
+			),
+		},
+		{
+			Name:       "issue token for another user as an admin",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/users/3/tokens",
+			Header:     admin,
+			Body:       map[string]any{"name": "laptop"},
+			WantStatus: http.StatusCreated,
+			Check:      issuedTokenAuthenticates("sensei"),
+		},
+		{
+			Name:       "issue token for another user as a teacher",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/users/2/tokens",
+			Header:     sensei,
+			Body:       map[string]any{"name": "phone"},
+			WantStatus: http.StatusForbidden,
+			Check:      ErrorCode("forbidden"),
+		},
+		{
+			Name:       "issue token anonymously",
+			Method:     http.MethodPost,
+			Path:       "/api/users/1/tokens",
+			Body:       map[string]any{"name": "phone"},
+			WantStatus: http.StatusUnauthorized,
+			Check:      ErrorCode("authentication_required"),
+		},
+		{
+			Name:       "issue token without a name",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/users/2/tokens",
+			Header:     hana,
+			Body:       map[string]any{"expires_in_days": 0},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("name"),
+		},
+		{
+			Name:       "revoke own token",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodDelete,
+			Path:       "/api/users/2/tokens/2",
+			Header:     hana,
+			WantStatus: http.StatusNoContent,
+			Check:      RowCount("SELECT COUNT(*) FROM api_tokens WHERE user_id = 2", 0),
+		},
+		{
+			Name:       "revoke another user's token",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodDelete,
+			Path:       "/api/users/2/tokens/3",
+			Header:     hana,
+			WantStatus: http.StatusNotFound,
+			Check: All(
+				ErrorCode("token_not_found"),
+				RowCount("SELECT COUNT(*) FROM api_tokens WHERE id = 3", 1),
+			),
+		},
+	}
+}
+
+// issuedTokenAuthenticates returns a check that uses the secret of an
+// issued token and expects to act for the named user
+func issuedTokenAuthenticates(name string) func(*Harness, *httptest.ResponseRecorder) error {
+	return func(h *Harness, w *httptest.ResponseRecorder) error {
+		var issued struct {
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &issued); err != nil {
+			return fmt.Errorf("response is not a token: %w", err)
+		}
+		if !strings.HasPrefix(issued.Token, "lp_") {
+			return fmt.Errorf("token = %q, want an lp_ secret", issued.Token)
+		}
+
+		me := h.DoWithHeader(http.MethodGet, "/api/users/me", nil, bearer(issued.Token))
+		return JSONFields(map[string]any{"name": name})(h, me)
+	}
+}
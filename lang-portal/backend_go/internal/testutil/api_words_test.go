@@ -0,0 +1,581 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+)
+
+// wordCases covers /api/words
+func wordCases() []Case {
+	return []Case{
+		{
+			Name:       "list words",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodGet,
+			Path:       "/api/words",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(5),
+		},
+		{
+			Name:       "list words without reviews",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words",
+			WantStatus: http.StatusOK,
+			Check: func(_ *Harness, w *httptest.ResponseRecorder) error {
+				var resp struct {
+					Items []struct {
+						ID           int64 `json:"id"`
+						CorrectCount int64 `json:"correct_count"`
+						WrongCount   int64 `json:"wrong_count"`
+					} `json:"items"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					return err
+				}
+				for _, item := range resp.Items {
+					if item.CorrectCount != 0 || item.WrongCount != 0 {
+						return fmt.Errorf("word %d has %d correct and %d wrong reviews, want none",
+							item.ID, item.CorrectCount, item.WrongCount)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:       "list words with page size",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words?page=2&per_page=2",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(2),
+		},
+		{
+			Name:       "list words with page size above maximum",
+			Method:     http.MethodGet,
+			Path:       "/api/words?per_page=101",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list words on a page whose offset overflows",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words?page=9223372036854775807",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list words past the last page",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words?page=1000000",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(0),
+		},
+		{
+			Name:       "search words on a page whose offset overflows",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words/search?q=cat&page=9223372036854775807",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "list words on empty database",
+			Method:     http.MethodGet,
+			Path:       "/api/words",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(0),
+		},
+		{
+			Name:       "get word with stats",
+			Fixtures:   []string{"reviews"},
+			Method:     http.MethodGet,
+			Path:       "/api/words/2",
+			WantStatus: http.StatusOK,
+			Check: JSONFields(map[string]any{
+				"english":       "thank you",
+				"correct_count": 1,
+				"wrong_count":   1,
+				"success_rate":  50,
+				"groups":        []any{"Basic Greetings"},
+			}),
+		},
+		{
+			Name:       "get missing word",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words/99",
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("word_not_found"),
+		},
+		{
+			Name:       "get word with invalid id",
+			Method:     http.MethodGet,
+			Path:       "/api/words/abc",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:   "create word",
+			Method: http.MethodPost,
+			Path:   "/api/words",
+			Header: admin,
+			Body: map[string]any{
+				"japanese": "犬",
+				"romaji":   "inu",
+				"english":  "dog",
+				"parts":    map[string]any{"type": "noun"},
+			},
+			WantStatus: http.StatusCreated,
+			Check: All(
+				JSONFields(map[string]any{"id": 1, "english": "dog"}),
+				RowCount("SELECT COUNT(*) FROM words", 1),
+			),
+		},
+		{
+			Name:       "create word as a teacher",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/words",
+			Header:     sensei,
+			Body:       map[string]any{"japanese": "犬", "romaji": "inu", "english": "dog"},
+			WantStatus: http.StatusCreated,
+		},
+		{
+			Name:       "create word as a learner",
+			Fixtures:   []string{"learners"},
+			Method:     http.MethodPost,
+			Path:       "/api/words",
+			Header:     hana,
+			Body:       map[string]any{"japanese": "犬", "romaji": "inu", "english": "dog"},
+			WantStatus: http.StatusForbidden,
+			Check: All(
+				ErrorCode("forbidden"),
+				RowCount("SELECT COUNT(*) FROM words WHERE english = 'dog'", 0),
+			),
+		},
+		{
+			Name:       "create word anonymously",
+			Method:     http.MethodPost,
+			Path:       "/api/words",
+			Body:       map[string]any{"japanese": "犬", "romaji": "inu", "english": "dog"},
+			WantStatus: http.StatusUnauthorized,
+			Check:      ErrorCode("authentication_required"),
+		},
+		{
+			Name:       "create word with malformed body",
+			Method:     http.MethodPost,
+			Path:       "/api/words",
+			Header:     admin,
+			Body:       "{",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_body"),
+		},
+		{
+			Name:       "create word with blank fields",
+			Method:     http.MethodPost,
+			Path:       "/api/words",
+			Header:     admin,
+			Body:       map[string]any{"japanese": " ", "romaji": "", "english": ""},
+			WantStatus: http.StatusBadRequest,
+			Check: All(
+				InvalidFields("japanese", "romaji", "english"),
+				RowCount("SELECT COUNT(*) FROM words", 0),
+			),
+		},
+		{
+			Name:       "create word with non-romaji reading",
+			Method:     http.MethodPost,
+			Path:       "/api/words",
+			Header:     admin,
+			Body:       map[string]any{"japanese": "猫", "romaji": "ねこ", "english": "cat"},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("romaji"),
+		},
+		{
+			Name:       "create word whose romaji does not match its kana",
+			Method:     http.MethodPost,
+			Path:       "/api/words",
+			Header:     admin,
+			Body:       map[string]any{"japanese": "ねこ", "romaji": "inu", "english": "cat"},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("romaji"),
+		},
+		{
+			Name:       "create word with kunrei-shiki romaji",
+			Method:     http.MethodPost,
+			Path:       "/api/words",
+			Header:     admin,
+			Body:       map[string]any{"japanese": "しゃしん", "romaji": "syasin", "english": "photo"},
+			WantStatus: http.StatusCreated,
+		},
+		{
+			Name:     "update word",
+			Fixtures: []string{"words"},
+			Method:   http.MethodPut,
+			Path:     "/api/words/3",
+			Header:   admin,
+			Body: map[string]any{
+				"japanese": "猫",
+				"romaji":   "neko",
+				"english":  "kitty",
+				"parts":    map[string]any{"type": "noun"},
+			},
+			WantStatus: http.StatusOK,
+			Check:      RowCount("SELECT COUNT(*) FROM words WHERE english = 'kitty'", 1),
+		},
+		{
+			Name:       "update word with invalid id",
+			Method:     http.MethodPut,
+			Path:       "/api/words/abc",
+			Header:     admin,
+			Body:       map[string]any{},
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "delete word",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodDelete,
+			Path:       "/api/words/5",
+			Header:     admin,
+			WantStatus: http.StatusNoContent,
+			Check:      RowCount("SELECT COUNT(*) FROM words", 4),
+		},
+		{
+			Name:       "delete missing word",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodDelete,
+			Path:       "/api/words/99",
+			Header:     admin,
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("word_not_found"),
+		},
+		{
+			Name:       "search words by english",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words/search?q=thank",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(1),
+		},
+		{
+			Name:       "search words with short term",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words/search?q=猫",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(1),
+		},
+		{
+			Name:       "search words by part",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words/search?formality=neutral",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(2),
+		},
+		{
+			Name:       "search words with invalid filter key",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words/search?bad-key=x",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_search"),
+		},
+		{
+			Name:       "search words does not sort",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words/search?q=cat&sort=english",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "search words does not follow cursors",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodGet,
+			Path:       "/api/words/search?q=cat&cursor=abc",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+	}
+}
+
+// groupCases covers the group resource routes
+func groupCases() []Case {
+	return []Case{
+		{
+			Name:       "list groups",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/groups",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(3),
+		},
+		{
+			Name:       "get group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/groups/1",
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"name": "Basic Greetings", "word_count": 2}),
+		},
+		{
+			Name:       "get missing group",
+			Method:     http.MethodGet,
+			Path:       "/api/groups/99",
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("group_not_found"),
+		},
+		{
+			Name:       "get group with invalid id",
+			Method:     http.MethodGet,
+			Path:       "/api/groups/abc",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_parameter"),
+		},
+		{
+			Name:       "create group",
+			Method:     http.MethodPost,
+			Path:       "/api/groups",
+			Header:     admin,
+			Body:       map[string]any{"name": "Colors", "description": "Basic colors"},
+			WantStatus: http.StatusCreated,
+			Check:      JSONFields(map[string]any{"id": 1, "name": "Colors"}),
+		},
+		{
+			Name:       "create group with an existing name",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/groups",
+			Header:     admin,
+			Body:       map[string]any{"name": "Daily Verbs", "description": "Duplicate"},
+			WantStatus: http.StatusConflict,
+			Check:      ErrorCode("group_name_taken"),
+		},
+		{
+			Name:       "create group with malformed body",
+			Method:     http.MethodPost,
+			Path:       "/api/groups",
+			Header:     admin,
+			Body:       "{",
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("invalid_body"),
+		},
+		{
+			Name:       "create group with blank name",
+			Method:     http.MethodPost,
+			Path:       "/api/groups",
+			Header:     admin,
+			Body:       map[string]any{"name": "  ", "description": "Nameless"},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("name"),
+		},
+		{
+			Name:       "update group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPut,
+			Path:       "/api/groups/2",
+			Header:     admin,
+			Body:       map[string]any{"name": "Animals", "description": "Animal words"},
+			WantStatus: http.StatusOK,
+			Check:      RowCount("SELECT COUNT(*) FROM groups WHERE name = 'Animals'", 1),
+		},
+		{
+			Name:       "rename group to an existing name",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPut,
+			Path:       "/api/groups/2",
+			Header:     admin,
+			Body:       map[string]any{"name": "Basic Greetings", "description": "Duplicate"},
+			WantStatus: http.StatusConflict,
+			Check:      ErrorCode("group_name_taken"),
+		},
+		{
+			Name:       "update missing group",
+			Method:     http.MethodPut,
+			Path:       "/api/groups/99",
+			Header:     admin,
+			Body:       map[string]any{"name": "Colors", "description": "Basic colors"},
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("group_not_found"),
+		},
+		{
+			Name:       "delete group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodDelete,
+			Path:       "/api/groups/3",
+			Header:     admin,
+			WantStatus: http.StatusNoContent,
+			Check:      RowCount("SELECT COUNT(*) FROM groups", 2),
+		},
+		{
+			Name:       "delete missing group",
+			Method:     http.MethodDelete,
+			Path:       "/api/groups/99",
+			Header:     admin,
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("group_not_found"),
+		},
+		{
+			Name:       "list group words",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodGet,
+			Path:       "/api/groups/1/words",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(2),
+		},
+		{
+			Name:       "list group study sessions",
+			Fixtures:   []string{"sessions"},
+			Method:     http.MethodGet,
+			Path:       "/api/groups/1/study-sessions",
+			WantStatus: http.StatusOK,
+			Check:      ItemCount(2),
+		},
+	}
+}
+
+// membershipCases covers adding, removing and moving group words
+func membershipCases() []Case {
+	return []Case{
+		{
+			Name:       "add words to group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/groups/2/words",
+			Header:     admin,
+			Body:       map[string]any{"word_ids": []int64{5, 1}},
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"word_count": 3}),
+		},
+		{
+			Name:       "add word already in group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/groups/1/words",
+			Header:     admin,
+			Body:       map[string]any{"word_ids": []int64{1, 5}},
+			WantStatus: http.StatusConflict,
+			Check: All(
+				ErrorCode("word_already_in_group"),
+				ErrorDetail("word_ids", []any{1}),
+				RowCount("SELECT COUNT(*) FROM word_groups WHERE word_id = 5", 0),
+			),
+		},
+		{
+			Name:       "add missing word to group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/groups/1/words",
+			Header:     admin,
+			Body:       map[string]any{"word_ids": []int64{99}},
+			WantStatus: http.StatusNotFound,
+			Check: All(
+				ErrorCode("word_not_found"),
+				ErrorDetail("word_ids", []any{99}),
+			),
+		},
+		{
+			Name:       "add words to missing group",
+			Fixtures:   []string{"words"},
+			Method:     http.MethodPost,
+			Path:       "/api/groups/99/words",
+			Header:     admin,
+			Body:       map[string]any{"word_ids": []int64{1}},
+			WantStatus: http.StatusNotFound,
+			Check:      ErrorCode("group_not_found"),
+		},
+		{
+			Name:       "add words without ids",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/groups/1/words",
+			Header:     admin,
+			Body:       map[string]any{"word_ids": []int64{}},
+			WantStatus: http.StatusBadRequest,
+			Check:      InvalidFields("word_ids"),
+		},
+		{
+			Name:       "add single word to group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/groups/3/words/5",
+			Header:     admin,
+			WantStatus: http.StatusCreated,
+		},
+		{
+			Name:       "remove words from group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodDelete,
+			Path:       "/api/groups/1/words",
+			Header:     admin,
+			Body:       map[string]any{"word_ids": []int64{1, 2}},
+			WantStatus: http.StatusOK,
+			Check:      JSONFields(map[string]any{"word_count": 0}),
+		},
+		{
+			Name:       "remove word not in group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodDelete,
+			Path:       "/api/groups/1/words",
+			Header:     admin,
+			Body:       map[string]any{"word_ids": []int64{3}},
+			WantStatus: http.StatusNotFound,
+			Check: All(
+				ErrorCode("word_not_in_group"),
+				ErrorDetail("word_ids", []any{3}),
+			),
+		},
+		{
+			Name:       "remove single word from group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodDelete,
+			Path:       "/api/groups/2/words/3",
+			Header:     admin,
+			WantStatus: http.StatusNoContent,
+			Check:      RowCount("SELECT COUNT(*) FROM word_groups WHERE group_id = 2", 0),
+		},
+		{
+			Name:       "move words between groups",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/groups/1/words/move",
+			Header:     admin,
+			Body:       map[string]any{"word_ids": []int64{1}, "target_group_id": 2},
+			WantStatus: http.StatusOK,
+			Check: All(
+				RowCount("SELECT COUNT(*) FROM word_groups WHERE group_id = 1", 1),
+				RowCount("SELECT COUNT(*) FROM word_groups WHERE group_id = 2", 2),
+			),
+		},
+		{
+			Name:       "move words to the same group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/groups/1/words/move",
+			Header:     admin,
+			Body:       map[string]any{"word_ids": []int64{1}, "target_group_id": 1},
+			WantStatus: http.StatusBadRequest,
+			Check:      ErrorCode("same_group"),
+		},
+		{
+			Name:       "move words to missing group",
+			Fixtures:   []string{"groups"},
+			Method:     http.MethodPost,
+			Path:       "/api/groups/1/words/move",
+			Header:     admin,
+			Body:       map[string]any{"word_ids": []int64{1}, "target_group_id": 99},
+			WantStatus: http.StatusNotFound,
+			Check: All(
+				ErrorCode("group_not_found"),
+				RowCount("SELECT COUNT(*) FROM word_groups WHERE group_id = 1", 2),
+			),
+		},
+	}
+}
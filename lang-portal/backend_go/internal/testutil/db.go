@@ -0,0 +1,64 @@
+// Package testutil provides a harness for exercising the services and the
+// HTTP API against an in-memory database loaded with named fixtures. It is
+// imported only by tests; its own tests hold the API, store and shutdown
+// cases.
+package testutil
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/erans/lang-portal/internal/database"
+)
+
+// NewDB opens an in-memory database with every migration applied and the
+// named fixtures loaded
+func NewDB(fixtures ...string) (*sql.DB, error) {
+	root, err := ModuleRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := database.Open(database.Config{Mode: database.ModeMemory})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := database.Migrate(db, filepath.Join(root, database.MigrationsDir)); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := LoadFixtures(db, fixtures...); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// ModuleRoot returns the directory containing go.mod, searching upwards
+// from the working directory
+func ModuleRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("go.mod not found above working directory")
+		}
+		dir = parent
+	}
+}
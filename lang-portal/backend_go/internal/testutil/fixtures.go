@@ -0,0 +1,67 @@
+package testutil
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+)
+
+//go:embed fixtures/*.sql
+var fixtureFiles embed.FS
+
+// fixtureDeps lists the fixtures each fixture builds on
+var fixtureDeps = map[string][]string{
+	"tokens":   nil,
+	"words":    nil,
+	"groups":   {"words"},
+	"sessions": {"groups"},
+	"apps":     {"sessions"},
+	"reviews":  {"sessions"},
+	"learners": {"reviews"},
+}
+
+// Fixtures returns the names of the available fixtures
+func Fixtures() []string {
+	return []string{"tokens", "words", "groups", "sessions", "apps", "reviews", "learners"}
+}
+
+// LoadFixtures loads the named fixtures and everything they depend on,
+// each exactly once
+func LoadFixtures(db *sql.DB, names ...string) error {
+	loaded := make(map[string]bool)
+
+	var load func(name string) error
+	load = func(name string) error {
+		if loaded[name] {
+			return nil
+		}
+
+		deps, ok := fixtureDeps[name]
+		if !ok {
+			return fmt.Errorf("unknown fixture %q", name)
+		}
+		for _, dep := range deps {
+			if err := load(dep); err != nil {
+				return err
+			}
+		}
+
+		content, err := fixtureFiles.ReadFile("fixtures/" + name + ".sql")
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(string(content)); err != nil {
+			return fmt.Errorf("failed to load fixture %s: %w", name, err)
+		}
+
+		loaded[name] = true
+		return nil
+	}
+
+	for _, name := range names {
+		if err := load(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+-- An app in the catalog that the greetings quiz was launched from, once,
+-- opening the active session
+INSERT INTO activity_apps (id, name, activity_type, thumbnail_url, launch_url) VALUES
+(1, 'Kana Quiz', 'quiz', 'https://apps.example.com/kana.png', 'https://apps.example.com/kana?group={group_id}&title={group_name}&session={session_id}');
+
+UPDATE study_activities SET app_id = 1 WHERE id = 2;
+
+INSERT INTO activity_launches (id, app_id, user_id, group_id, session_id, launch_url) VALUES
+(1, 1, 1, 1, 2, 'https://apps.example.com/kana?group=1&title=Basic%20Greetings&session=2');
@@ -0,0 +1,11 @@
+-- Groups and their words; word 5 is in no group
+INSERT INTO groups (id, name, description) VALUES
+(1, 'Basic Greetings', 'Essential Japanese greetings and expressions'),
+(2, 'Common Animals', 'Basic animal vocabulary for beginners'),
+(3, 'Daily Verbs', 'Common everyday action words');
+
+INSERT INTO word_groups (word_id, group_id) VALUES
+(1, 1),
+(2, 1),
+(3, 2),
+(4, 3);
@@ -0,0 +1,16 @@
+-- A second learner with an active greetings session of their own and one
+-- answer in it, and a teacher; every other fixture row belongs to the
+-- default user. Their token secrets are lp_test_hana and lp_test_sensei.
+INSERT INTO users (id, name, role, created_at) VALUES
+(2, 'hana', 'learner', CURRENT_TIMESTAMP),
+(3, 'sensei', 'teacher', CURRENT_TIMESTAMP);
+
+INSERT INTO api_tokens (id, user_id, name, token_hash, created_at) VALUES
+(2, 2, 'laptop', 'fc0dcf2d8c355d38a31ef48f6da773e9f68e0b5cfc75ce06bb65e1e4fdeab884', CURRENT_TIMESTAMP),
+(3, 3, 'laptop', 'de72f84c94c1d6b0a6aef9ac47e8266951fae1358df68cc697f49883541d1b59', CURRENT_TIMESTAMP);
+
+INSERT INTO study_sessions (id, start_time, end_time, score, status, study_activity_id, user_id) VALUES
+(4, CURRENT_TIMESTAMP, NULL, NULL, 'active', 1, 2);
+
+INSERT INTO word_review_items (id, session_id, word_id, is_correct, response, reviewed_at) VALUES
+(5, 4, 1, 0, 'goodbye', CURRENT_TIMESTAMP);
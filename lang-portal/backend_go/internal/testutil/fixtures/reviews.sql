@@ -0,0 +1,6 @@
+-- Answers recorded in the fixture sessions
+INSERT INTO word_review_items (id, session_id, word_id, is_correct, response, reviewed_at) VALUES
+(1, 1, 1, 1, 'hello', datetime(CURRENT_TIMESTAMP, '-9 minutes')),
+(2, 1, 2, 1, 'thank you', datetime(CURRENT_TIMESTAMP, '-8 minutes')),
+(3, 2, 2, 0, 'thanks', CURRENT_TIMESTAMP),
+(4, 3, 3, 1, 'cat', datetime(CURRENT_TIMESTAMP, '-1 day', '+1 minute'));
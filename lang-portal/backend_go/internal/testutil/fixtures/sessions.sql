@@ -0,0 +1,11 @@
+-- Study activities with one completed and one active session for greetings,
+-- and a completed session for animals from the day before
+INSERT INTO study_activities (id, group_id, activity_type, created_at) VALUES
+(1, 1, 'flashcard', CURRENT_TIMESTAMP),
+(2, 1, 'quiz', CURRENT_TIMESTAMP),
+(3, 2, 'flashcard', CURRENT_TIMESTAMP);
+
+INSERT INTO study_sessions (id, start_time, end_time, score, status, study_activity_id) VALUES
+(1, datetime(CURRENT_TIMESTAMP, '-10 minutes'), datetime(CURRENT_TIMESTAMP, '-5 minutes'), 85.0, 'completed', 1),
+(2, CURRENT_TIMESTAMP, NULL, NULL, 'active', 2),
+(3, datetime(CURRENT_TIMESTAMP, '-1 day'), datetime(CURRENT_TIMESTAMP, '-1 day', '+4 minutes'), 95.0, 'completed', 3);
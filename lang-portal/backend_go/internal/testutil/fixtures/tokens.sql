@@ -0,0 +1,4 @@
+-- An API token for the default user, an admin. The secret is lp_test_admin;
+-- only its SHA-256 hash is stored.
+INSERT INTO api_tokens (id, user_id, name, token_hash, created_at) VALUES
+(1, 1, 'harness', '761804e6e91ed25c72633372bf3be3f80d62e4c3251962a319f87a4c5e7e532c', CURRENT_TIMESTAMP);
@@ -0,0 +1,7 @@
+-- Vocabulary used across fixtures
+INSERT INTO words (id, japanese, romaji, english, parts) VALUES
+(1, 'こんにちは', 'konnichiwa', 'hello', '{"type": "greeting", "formality": "neutral"}'),
+(2, 'ありがとう', 'arigatou', 'thank you', '{"type": "expression", "formality": "neutral"}'),
+(3, '猫', 'neko', 'cat', '{"type": "noun", "category": "animals"}'),
+(4, '食べる', 'taberu', 'to eat', '{"type": "verb", "conjugation": "ichidan"}'),
+(5, '大きい', 'ookii', 'big', '{"type": "adjective", "category": "size"}');
@@ -0,0 +1,159 @@
+package testutil
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/erans/lang-portal/internal/database"
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// EnvPostgresURL names the Postgres server the store tests run against. Without
+// it, StartPostgres starts a throwaway server with initdb and pg_ctl.
+const EnvPostgresURL = "LANG_PORTAL_TEST_POSTGRES_URL"
+
+// ErrNoPostgres is returned by StartPostgres when no server is configured
+// and initdb and pg_ctl are not on PATH
+var ErrNoPostgres = errors.New("no postgres server: set " + EnvPostgresURL + " or put initdb and pg_ctl on PATH")
+
+// Postgres is a server the store tests create throwaway schemas on, so runs
+// never touch existing tables
+type Postgres struct {
+	url   string
+	admin *sql.DB
+	stop  func() error
+	// schemas numbers the schemas created by NewDB
+	schemas atomic.Int64
+}
+
+// StartPostgres connects to the server named by $LANG_PORTAL_TEST_POSTGRES_URL
+// or, when it is unset, starts a server in a temporary directory that
+// Close removes again
+func StartPostgres() (*Postgres, error) {
+	p := &Postgres{url: os.Getenv(EnvPostgresURL), stop: func() error { return nil }}
+	if p.url == "" {
+		var err error
+		if p.url, p.stop, err = startCluster(); err != nil {
+			return nil, err
+		}
+	}
+
+	admin, err := sql.Open(dialect.Postgres.DriverName(), p.url)
+	if err == nil {
+		err = admin.Ping()
+	}
+	if err == nil {
+		// Every test schema finds the extension through its search path, so
+		// the migrations do not install a copy per schema
+		_, err = admin.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public")
+	}
+	if err != nil {
+		if admin != nil {
+			admin.Close()
+		}
+		p.stop()
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+
+	p.admin = admin
+	return p, nil
+}
+
+// startCluster initializes and starts a server listening only on a Unix
+// socket in a temporary directory. Durability is turned off; the data is
+// thrown away.
+func startCluster() (string, func() error, error) {
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		return "", nil, ErrNoPostgres
+	}
+	pgCtl, err := exec.LookPath("pg_ctl")
+	if err != nil {
+		return "", nil, ErrNoPostgres
+	}
+
+	dir, err := os.MkdirTemp("", "lang-portal-pg-")
+	if err != nil {
+		return "", nil, err
+	}
+	data := filepath.Join(dir, "data")
+
+	run := func(name string, args ...string) error {
+		if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %w\n%s", filepath.Base(name), err, out)
+		}
+		return nil
+	}
+
+	if err := run(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync"); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	options := "-F -c listen_addresses= -k " + dir
+	if err := run(pgCtl, "-D", data, "-l", filepath.Join(dir, "server.log"), "-o", options, "-w", "start"); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+
+	stop := func() error {
+		err := run(pgCtl, "-D", data, "-m", "immediate", "-w", "stop")
+		os.RemoveAll(dir)
+		return err
+	}
+	return fmt.Sprintf("host=%s user=postgres dbname=postgres", dir), stop, nil
+}
+
+// NewDB creates an empty schema and returns a connection whose search path
+// starts with it, with every migration applied, and the function that
+// drops the schema again
+func (p *Postgres) NewDB() (*sql.DB, func(), error) {
+	root, err := ModuleRoot()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg, err := pgx.ParseConfig(p.url)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schema := fmt.Sprintf("lang_portal_test_%d_%d", os.Getpid(), p.schemas.Add(1))
+	if _, err := p.admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		return nil, nil, err
+	}
+	cfg.RuntimeParams["search_path"] = schema + ", public"
+
+	db := stdlib.OpenDB(*cfg)
+	release := func() {
+		db.Close()
+		p.admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+	}
+
+	if _, err := database.Migrate(db, filepath.Join(root, database.MigrationsDir)); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return db, release, nil
+}
+
+// Store is a StoreFactory for a sqlstore on a fresh schema
+func (p *Postgres) Store() (*StoreServices, func(), error) {
+	db, release, err := p.NewDB()
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewSQLServices(db), release, nil
+}
+
+// Close disconnects and stops the server if StartPostgres started it
+func (p *Postgres) Close() error {
+	p.admin.Close()
+	return p.stop()
+}
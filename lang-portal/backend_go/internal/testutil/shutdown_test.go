@@ -0,0 +1,178 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erans/lang-portal/internal/api"
+	"github.com/erans/lang-portal/internal/database"
+	"github.com/erans/lang-portal/internal/server"
+	"github.com/gin-gonic/gin"
+)
+
+// shutdownWait bounds every wait in TestShutdown so a broken shutdown
+// fails instead of hanging
+const shutdownWait = 10 * time.Second
+
+// TestShutdown serves the API from a database file in WAL mode, records a
+// review that is still in flight when shutdown begins, and checks that the
+// review completes and is persisted, a background worker is stopped while
+// the database is still open, the server stops cleanly, and the write-ahead
+// log is checkpointed into the database file
+func TestShutdown(t *testing.T) {
+	root, err := ModuleRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := database.Config{Path: filepath.Join(t.TempDir(), "words.db"), Mode: database.ModePersistent}
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The server closes the database on shutdown; this covers the paths
+	// that fail before it does
+	t.Cleanup(func() { db.Close() })
+	if _, err := database.Migrate(db, filepath.Join(root, database.MigrationsDir)); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFixtures(db, "tokens", "reviews"); err != nil {
+		t.Fatal(err)
+	}
+
+	const countReviews = "SELECT COUNT(*) FROM word_review_items"
+	var before int
+	if err := db.QueryRow(countReviews).Scan(&before); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the review until shutdown has begun, then give the server a
+	// moment to notice the request is still running before finishing it
+	reviewStarted := make(chan struct{})
+	shutdownStarted := make(chan struct{})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if c.Request.Method == http.MethodPost && strings.HasSuffix(c.Request.URL.Path, "/review") {
+			close(reviewStarted)
+			<-shutdownStarted
+			time.Sleep(200 * time.Millisecond)
+		}
+		c.Next()
+	})
+	api.RegisterRoutes(router, api.NewServices(db, api.DefaultOptions()))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.New(ln.Addr().String(), router, db, shutdownWait)
+	srv.RegisterOnShutdown(func() { close(shutdownStarted) })
+
+	// The worker's last query runs after it is told to stop and must still
+	// find the database open
+	workerStopped := make(chan error, 1)
+	srv.Background(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(100 * time.Millisecond)
+		workerStopped <- db.Ping()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(ctx, ln)
+	}()
+
+	type result struct {
+		status int
+		err    error
+	}
+	reviewed := make(chan result, 1)
+	go func() {
+		url := fmt.Sprintf("http://%s/api/study-sessions/2/words/1/review", ln.Addr())
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"correct": true}`))
+		if err != nil {
+			reviewed <- result{err: err}
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer lp_test_admin")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			reviewed <- result{err: err}
+			return
+		}
+		resp.Body.Close()
+		reviewed <- result{status: resp.StatusCode}
+	}()
+
+	select {
+	case <-reviewStarted:
+	case <-time.After(shutdownWait):
+		t.Fatal("review request never reached the server")
+	}
+	cancel()
+
+	select {
+	case r := <-reviewed:
+		if r.err != nil {
+			t.Fatalf("in-flight review failed during shutdown: %v", r.err)
+		}
+		if r.status != http.StatusCreated {
+			t.Fatalf("in-flight review returned %d, want %d", r.status, http.StatusCreated)
+		}
+	case <-time.After(shutdownWait):
+		t.Fatal("in-flight review did not complete")
+	}
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("server did not stop cleanly: %v", err)
+		}
+	case <-time.After(shutdownWait):
+		t.Fatal("server did not stop")
+	}
+
+	select {
+	case err := <-workerStopped:
+		if err != nil {
+			t.Fatalf("database closed before the background worker stopped: %v", err)
+		}
+	default:
+		t.Fatal("server stopped without waiting for the background worker")
+	}
+
+	if err := db.Ping(); err == nil {
+		t.Fatal("database is still open after shutdown")
+	}
+
+	if info, err := os.Stat(cfg.Path + "-wal"); err == nil && info.Size() > 0 {
+		t.Fatalf("write-ahead log still holds %d bytes after shutdown", info.Size())
+	}
+
+	// Reopen the file to check the review reached the database
+	reopened, err := sql.Open("sqlite3", cfg.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	var after int
+	if err := reopened.QueryRow(countReviews).Scan(&after); err != nil {
+		t.Fatal(err)
+	}
+	if after != before+1 {
+		t.Fatalf("found %d review items after shutdown, want %d", after, before+1)
+	}
+}
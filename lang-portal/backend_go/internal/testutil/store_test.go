@@ -0,0 +1,1333 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erans/lang-portal/internal/database"
+	"github.com/erans/lang-portal/internal/export"
+	"github.com/erans/lang-portal/internal/importer"
+	"github.com/erans/lang-portal/internal/jobs"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/pagination"
+	"github.com/erans/lang-portal/internal/repository"
+	"github.com/erans/lang-portal/internal/service"
+)
+
+// storeData is what seedStore creates: three words, of which the first two
+// are in the greetings group, and an active session of a greetings activity
+// belonging to the default user
+type storeData struct {
+	user      int64
+	words     []models.Word
+	greetings models.Group
+	animals   models.Group
+	activity  models.StudyActivity
+	session   models.StudySession
+}
+
+// seedStore creates the data most cases start from
+func seedStore(t *testing.T, s *StoreServices) *storeData {
+	t.Helper()
+	d := &storeData{
+		words: []models.Word{
+			{Japanese: "こんにちは", Romaji: "konnichiwa", English: "hello", Parts: map[string]any{"type": "greeting"}},
+			{Japanese: "ありがとう", Romaji: "arigatou", English: "thank you", Parts: map[string]any{"type": "greeting"}},
+			{Japanese: "ねこ", Romaji: "neko", English: "cat", Parts: map[string]any{"type": "noun"}},
+		},
+		user:      models.DefaultUserID,
+		greetings: models.Group{Name: "Greetings", Description: "Everyday greetings"},
+		animals:   models.Group{Name: "Animals", Description: "Animals"},
+	}
+
+	for i := range d.words {
+		if err := s.Words.CreateWord(&d.words[i]); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	if err := s.Groups.CreateGroup(&d.greetings); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if err := s.Groups.CreateGroup(&d.animals); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if _, err := s.Groups.AddWordsToGroup(d.greetings.ID, []int64{d.words[0].ID, d.words[1].ID}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	d.activity = models.StudyActivity{GroupID: d.greetings.ID, ActivityType: "flashcard"}
+	if err := s.Activities.CreateActivity(&d.activity); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	d.session = models.StudySession{StudyActivityID: d.activity.ID}
+	if err := s.Sessions.CreateSession(d.user, &d.session); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	return d
+}
+
+// wrongAnswersScoring is a ScoringStrategy that rewards wrong answers, so
+// cases can tell it apart from the built-in ones
+type wrongAnswersScoring struct{}
+
+func (wrongAnswersScoring) Name() string { return "wrong-answers" }
+
+func (wrongAnswersScoring) Weigh(answer models.ScoredAnswer) (float64, float64) {
+	if answer.Correct {
+		return 0, 1
+	}
+	return 1, 1
+}
+
+// seeded wraps a case that starts from seedStore
+func seeded(run func(t *testing.T, s *StoreServices, d *storeData)) func(t *testing.T, s *StoreServices) {
+	return func(t *testing.T, s *StoreServices) {
+		run(t, s, seedStore(t, s))
+	}
+}
+
+// firstPage requests the first page of a listing in its default order
+func firstPage(spec pagination.Spec, limit int) pagination.Request {
+	return pagination.Request{Sort: spec.Default, Limit: limit}
+}
+
+// wantErr checks that err matches target
+func wantErr(t *testing.T, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("error = %v, want %v", err, target)
+	}
+}
+
+// wantIDs checks that a MembershipError lists exactly the given word IDs
+func wantIDs(t *testing.T, err error, ids ...int64) {
+	t.Helper()
+	var membershipErr *service.MembershipError
+	if !errors.As(err, &membershipErr) {
+		t.Fatalf("error = %v, want a membership error", err)
+	}
+	if !slices.Equal(membershipErr.WordIDs, ids) {
+		t.Fatalf("word IDs = %v, want %v", membershipErr.WordIDs, ids)
+	}
+}
+
+// wordCount checks how many words a group has
+func wordCount(t *testing.T, s *StoreServices, groupID, want int64) {
+	t.Helper()
+	group, err := s.Groups.GetGroup(groupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.WordCount != want {
+		t.Fatalf("group %d has %d words, want %d", groupID, group.WordCount, want)
+	}
+}
+
+// TestStores runs the store cases against SQLite, Postgres and the
+// in-memory fake, so all three behave the same. Postgres is the server in
+// $LANG_PORTAL_TEST_POSTGRES_URL or a throwaway one started with initdb and
+// pg_ctl, and is skipped when neither is available.
+func TestStores(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) { RunStores(t, SQLStore, storeCases()) })
+	t.Run("memstore", func(t *testing.T) { RunStores(t, MemStore, storeCases()) })
+	t.Run("postgres", func(t *testing.T) {
+		pg, err := StartPostgres()
+		if errors.Is(err, ErrNoPostgres) {
+			t.Skip(err)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pg.Close()
+		RunStores(t, pg.Store, storeCases())
+	})
+}
+
+// storeCases cover the business logic the services implement on top of
+// the repositories, then the SQL the tools run directly
+func storeCases() []StoreCase {
+	return append([]StoreCase{
+		{
+			Name: "create, update and delete a word",
+			Run: func(t *testing.T, s *StoreServices) {
+				word := models.Word{Japanese: "いぬ", Romaji: "inu", English: "dog", Parts: map[string]any{"type": "noun"}}
+				if err := s.Words.CreateWord(&word); err != nil {
+					t.Fatal(err)
+				}
+				got, err := s.Words.GetWord(word.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.English != "dog" || got.Parts["type"] != "noun" {
+					t.Fatalf("got %+v", got)
+				}
+
+				word.English = "hound"
+				if err := s.Words.UpdateWord(&word); err != nil {
+					t.Fatal(err)
+				}
+				if got, err := s.Words.GetWord(word.ID); err != nil || got.English != "hound" {
+					t.Fatalf("after update got %+v, %v", got, err)
+				}
+
+				if err := s.Words.DeleteWord(word.ID); err != nil {
+					t.Fatal(err)
+				}
+				_, err = s.Words.GetWord(word.ID)
+				wantErr(t, err, service.ErrWordNotFound)
+				missing := models.Word{ID: word.ID, Japanese: "いぬ", Romaji: "inu", English: "dog"}
+				wantErr(t, s.Words.UpdateWord(&missing), service.ErrWordNotFound)
+				wantErr(t, s.Words.DeleteWord(word.ID), service.ErrWordNotFound)
+			},
+		},
+		{
+			Name: "list words with review statistics and groups",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				for _, correct := range []bool{true, true, false} {
+					if _, err := s.Sessions.RecordReview(d.user, d.session.ID, d.words[0].ID, correct, ""); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if _, err := s.Groups.AddWordsToGroup(d.animals.ID, []int64{d.words[0].ID}); err != nil {
+					t.Fatal(err)
+				}
+
+				result, err := s.Words.ListWords(models.DefaultUserID, repository.WordFilter{}, firstPage(repository.WordListing, 2))
+				if err != nil {
+					t.Fatal(err)
+				}
+				words := result.Items
+				if result.Total != 3 || len(words) != 2 {
+					t.Fatalf("got %d of %d words, want 2 of 3", len(words), result.Total)
+				}
+				first := words[0].WordStats
+				if first.CorrectCount != 2 || first.WrongCount != 1 || first.SuccessRate != 66.7 {
+					t.Fatalf("stats = %+v, want 2 correct, 1 wrong, 66.7%%", first)
+				}
+				if !slices.Equal(first.Groups, []string{"Animals", "Greetings"}) {
+					t.Fatalf("groups = %v, want [Animals Greetings]", first.Groups)
+				}
+				if words[1].WrongCount != 0 || !slices.Equal(words[1].Groups, []string{"Greetings"}) {
+					t.Fatalf("second word = %+v", words[1].WordStats)
+				}
+			}),
+		},
+		{
+			Name: "listings follow cursors and filter",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				page := pagination.Request{Sort: pagination.Sort{Field: "english"}, Limit: 1}
+				var ids []int64
+				for range len(d.words) + 1 {
+					result, err := s.Words.ListWords(d.user, repository.WordFilter{}, page)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, word := range result.Items {
+						ids = append(ids, word.ID)
+					}
+					if result.Next == nil {
+						break
+					}
+					page.After = result.Next
+				}
+				if want := []int64{d.words[2].ID, d.words[0].ID, d.words[1].ID}; !slices.Equal(ids, want) {
+					t.Fatalf("words by english = %v, want %v", ids, want)
+				}
+
+				grouped, err := s.Words.ListWords(d.user, repository.WordFilter{GroupID: &d.greetings.ID}, firstPage(repository.WordListing, 10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if grouped.Total != 2 || len(grouped.Items) != 2 {
+					t.Fatalf("got %d of %d greetings, want 2 of 2", len(grouped.Items), grouped.Total)
+				}
+
+				for _, correct := range []bool{true, false, true} {
+					if _, err := s.Sessions.RecordReview(d.user, d.session.ID, d.words[0].ID, correct, ""); err != nil {
+						t.Fatal(err)
+					}
+				}
+				wrong := false
+				reviews, err := s.Sessions.ListSessionReviewItems(d.user, d.session.ID, repository.ReviewFilter{Correct: &wrong}, firstPage(repository.ReviewListing, 10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if reviews.Total != 1 || len(reviews.Items) != 1 || reviews.Items[0].IsCorrect {
+					t.Fatalf("wrong reviews = %+v, want one", reviews.Items)
+				}
+
+				if _, err := s.Sessions.EndSession(d.user, d.session.ID); err != nil {
+					t.Fatal(err)
+				}
+				active := models.StudySession{StudyActivityID: d.activity.ID}
+				if err := s.Sessions.CreateSession(d.user, &active); err != nil {
+					t.Fatal(err)
+				}
+				sessions, err := s.Sessions.ListSessions(d.user, repository.SessionFilter{Status: models.SessionActive, GroupID: &d.greetings.ID}, firstPage(repository.SessionListing, 10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sessions.Total != 1 || len(sessions.Items) != 1 || sessions.Items[0].ID != active.ID {
+					t.Fatalf("active sessions = %+v, want only session %d", sessions.Items, active.ID)
+				}
+			}),
+		},
+		{
+			Name: "search words",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				result, err := s.Words.SearchWords("THANK", nil, 0, 10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if words := result.Items.([]models.Word); len(words) != 1 || words[0].ID != d.words[1].ID {
+					t.Fatalf("search for THANK = %+v", words)
+				}
+
+				result, err = s.Words.SearchWords("", map[string]string{"type": "greeting"}, 0, 10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.TotalItems != 2 {
+					t.Fatalf("parts filter matched %d words, want 2", result.TotalItems)
+				}
+
+				_, err = s.Words.SearchWords("", nil, 0, 10)
+				wantErr(t, err, service.ErrInvalidSearch)
+				_, err = s.Words.SearchWords("cat", map[string]string{"bad key": "x"}, 0, 10)
+				wantErr(t, err, service.ErrInvalidSearch)
+			}),
+		},
+		{
+			Name: "search ranks exact matches first",
+			Run: func(t *testing.T, s *StoreServices) {
+				for _, w := range []models.Word{
+					{Japanese: "ねこじた", Romaji: "nekojita", English: "cat tongue"},
+					{Japanese: "ねこ", Romaji: "neko", English: "cat"},
+				} {
+					if err := s.Words.CreateWord(&w); err != nil {
+						t.Fatal(err)
+					}
+				}
+				result, err := s.Words.SearchWords("cat", nil, 0, 10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				words := result.Items.([]models.Word)
+				if len(words) != 2 || words[0].English != "cat" {
+					t.Fatalf("search for cat = %+v, want the exact match first", words)
+				}
+			},
+		},
+		{
+			Name: "group names are unique",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				duplicate := models.Group{Name: "Greetings"}
+				wantErr(t, s.Groups.CreateGroup(&duplicate), service.ErrGroupNameTaken)
+				renamed := d.animals
+				renamed.Name = "Greetings"
+				wantErr(t, s.Groups.UpdateGroup(&renamed), service.ErrGroupNameTaken)
+				missing := models.Group{ID: 99, Name: "Missing"}
+				wantErr(t, s.Groups.UpdateGroup(&missing), service.ErrGroupNotFound)
+			}),
+		},
+		{
+			Name: "adding words is all-or-nothing",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				_, err := s.Groups.AddWordsToGroup(d.animals.ID, []int64{d.words[2].ID, 98, 99})
+				wantIDs(t, err, 98, 99)
+				wordCount(t, s, d.animals.ID, 0)
+
+				_, err = s.Groups.AddWordsToGroup(d.greetings.ID, []int64{d.words[2].ID, d.words[0].ID, d.words[1].ID})
+				wantErr(t, err, service.ErrWordAlreadyInGroup)
+				wantIDs(t, err, d.words[0].ID, d.words[1].ID)
+				wordCount(t, s, d.greetings.ID, 2)
+
+				_, err = s.Groups.AddWordsToGroup(99, []int64{d.words[2].ID})
+				wantErr(t, err, service.ErrGroupNotFound)
+			}),
+		},
+		{
+			Name: "moving words is all-or-nothing",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				_, _, err := s.Groups.MoveWords(d.greetings.ID, d.animals.ID, []int64{d.words[0].ID, d.words[2].ID})
+				wantErr(t, err, service.ErrWordNotInGroup)
+				wordCount(t, s, d.greetings.ID, 2)
+				wordCount(t, s, d.animals.ID, 0)
+
+				source, target, err := s.Groups.MoveWords(d.greetings.ID, d.animals.ID, []int64{d.words[0].ID})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if source.WordCount != 1 || target.WordCount != 1 {
+					t.Fatalf("after move source has %d and target %d words, want 1 and 1", source.WordCount, target.WordCount)
+				}
+
+				_, _, err = s.Groups.MoveWords(d.animals.ID, d.animals.ID, []int64{d.words[0].ID})
+				wantErr(t, err, service.ErrSameGroup)
+			}),
+		},
+		{
+			Name: "recording a review checks the session and advances the schedule",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				_, err := s.Sessions.RecordReview(d.user, 99, d.words[0].ID, true, "")
+				wantErr(t, err, service.ErrSessionNotFound)
+				_, err = s.Sessions.RecordReview(d.user, d.session.ID, 99, true, "")
+				wantErr(t, err, service.ErrWordNotFound)
+				_, err = s.Sessions.RecordReview(d.user, d.session.ID, d.words[2].ID, true, "")
+				wantErr(t, err, service.ErrWordNotInSessionGroup)
+
+				item, err := s.Sessions.RecordReview(d.user, d.session.ID, d.words[0].ID, true, "konnichiwa")
+				if err != nil {
+					t.Fatal(err)
+				}
+				items, err := s.Sessions.ListSessionReviewItems(d.user, d.session.ID, repository.ReviewFilter{}, firstPage(repository.ReviewListing, 10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(items.Items) != 1 || items.Items[0].ID != item.ID || items.Items[0].Response != "konnichiwa" {
+					t.Fatalf("review items = %+v", items.Items)
+				}
+
+				schedule, err := s.Reviews.GetSchedule(d.user, d.words[0].ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if schedule.Repetitions != 1 || schedule.IntervalDays != 1 {
+					t.Fatalf("schedule = %+v, want 1 repetition due in 1 day", schedule)
+				}
+				_, err = s.Reviews.GetSchedule(d.user, d.words[1].ID)
+				wantErr(t, err, service.ErrWordNotFound)
+
+				if _, err := s.Sessions.EndSession(d.user, d.session.ID); err != nil {
+					t.Fatal(err)
+				}
+				_, err = s.Sessions.RecordReview(d.user, d.session.ID, d.words[0].ID, true, "")
+				wantErr(t, err, service.ErrSessionNotActive)
+			}),
+		},
+		{
+			Name: "sessions close once, completed with a score or abandoned",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				for i, correct := range []bool{true, true, false, true} {
+					if _, err := s.Sessions.RecordReview(d.user, d.session.ID, d.words[i%2].ID, correct, ""); err != nil {
+						t.Fatal(err)
+					}
+				}
+				_, err := s.Sessions.UpdateSession(d.user, d.session.ID, models.SessionActive)
+				wantErr(t, err, service.ErrIllegalTransition)
+
+				ended, err := s.Sessions.EndSession(d.user, d.session.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				session, err := s.Sessions.GetSession(d.user, d.session.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if session.Status != models.SessionCompleted || session.EndTime == nil || session.Score == nil || *session.Score != 75 {
+					t.Fatalf("session = %+v, want completed with score 75", session)
+				}
+				if !session.EndTime.Equal(*ended.EndTime) {
+					t.Fatalf("stored end time %v, returned %v", session.EndTime, ended.EndTime)
+				}
+
+				_, err = s.Sessions.UpdateSession(d.user, d.session.ID, models.SessionAbandoned)
+				var transitionErr *service.TransitionError
+				if !errors.As(err, &transitionErr) || transitionErr.From != models.SessionCompleted || transitionErr.To != models.SessionAbandoned {
+					t.Fatalf("error = %v, want a transition error from completed to abandoned", err)
+				}
+				_, err = s.Sessions.EndSession(d.user, d.session.ID)
+				wantErr(t, err, service.ErrIllegalTransition)
+				if again, err := s.Sessions.GetSession(d.user, d.session.ID); err != nil || !again.EndTime.Equal(*session.EndTime) {
+					t.Fatalf("session after rejected transitions = %+v, %v, want the first end time", again, err)
+				}
+
+				abandoned := models.StudySession{StudyActivityID: d.activity.ID, Status: models.SessionCompleted}
+				if err := s.Sessions.CreateSession(d.user, &abandoned); err != nil {
+					t.Fatal(err)
+				}
+				if abandoned.Status != models.SessionActive {
+					t.Fatalf("new session has status %q, want active", abandoned.Status)
+				}
+				closed, err := s.Sessions.UpdateSession(d.user, abandoned.ID, models.SessionAbandoned)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if closed.Status != models.SessionAbandoned || closed.EndTime == nil || closed.Score != nil {
+					t.Fatalf("session = %+v, want abandoned without a score", closed)
+				}
+				_, err = s.Sessions.EndSession(d.user, 99)
+				wantErr(t, err, service.ErrSessionNotFound)
+			}),
+		},
+		{
+			Name: "sessions are scored by the strategy of their activity type",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				// Earlier answers make the first word harder than the second,
+				// which the learner has not seen
+				for _, correct := range []bool{false, false, true} {
+					if _, err := s.Sessions.RecordReview(d.user, d.session.ID, d.words[0].ID, correct, ""); err != nil {
+						t.Fatal(err)
+					}
+				}
+				flashcard, err := s.Sessions.EndSession(d.user, d.session.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if b := flashcard.ScoreBreakdown; b == nil || b.Strategy != "accuracy" || b.Answers != 3 || b.Correct != 1 || b.Points != 1 || b.MaxPoints != 3 {
+					t.Fatalf("flashcard breakdown = %+v, want accuracy with 1 of 3 points", b)
+				}
+
+				quiz := models.StudyActivity{GroupID: d.greetings.ID, ActivityType: "quiz"}
+				if err := s.Activities.CreateActivity(&quiz); err != nil {
+					t.Fatal(err)
+				}
+				session := models.StudySession{StudyActivityID: quiz.ID}
+				if err := s.Sessions.CreateSession(d.user, &session); err != nil {
+					t.Fatal(err)
+				}
+				for i, correct := range []bool{true, false} {
+					if _, err := s.Sessions.RecordReview(d.user, session.ID, d.words[i].ID, correct, ""); err != nil {
+						t.Fatal(err)
+					}
+				}
+				ended, err := s.Sessions.EndSession(d.user, session.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b := ended.ScoreBreakdown
+				if b == nil || b.Strategy != "difficulty" || len(b.Items) != 2 || b.Accuracy != 50 {
+					t.Fatalf("quiz breakdown = %+v, want difficulty over 2 answers", b)
+				}
+				if b.Items[0].Difficulty != 0.6 || b.Items[1].Difficulty != 0.5 || b.Items[1].Points != 0 {
+					t.Fatalf("quiz answers = %+v, want difficulties 0.6 and 0.5", b.Items)
+				}
+				if want := 1.6 * 100 / 3.1; math.Abs(*ended.Score-want) > 1e-9 {
+					t.Fatalf("quiz score = %v, want %v", *ended.Score, want)
+				}
+				stored, err := s.Sessions.GetSession(d.user, session.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(stored.ScoreBreakdown, ended.ScoreBreakdown) {
+					t.Fatalf("stored breakdown %+v, returned %+v", stored.ScoreBreakdown, ended.ScoreBreakdown)
+				}
+
+				s.Sessions.SetScoringStrategy("quiz", wrongAnswersScoring{})
+				session = models.StudySession{StudyActivityID: quiz.ID}
+				if err := s.Sessions.CreateSession(d.user, &session); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := s.Sessions.RecordReview(d.user, session.ID, d.words[1].ID, false, ""); err != nil {
+					t.Fatal(err)
+				}
+				ended, err = s.Sessions.EndSession(d.user, session.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ended.ScoreBreakdown.Strategy != "wrong-answers" || *ended.Score != 100 {
+					t.Fatalf("session = %+v, want 100 from the plugged-in strategy", ended)
+				}
+			}),
+		},
+		{
+			Name: "typing sessions are scored by how fast each answer came",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				typing := models.StudyActivity{GroupID: d.greetings.ID, ActivityType: "typing"}
+				if err := s.Activities.CreateActivity(&typing); err != nil {
+					t.Fatal(err)
+				}
+				// Answers are timed from the previous answer, or the start
+				start := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+				session := models.StudySession{StudyActivityID: typing.ID, UserID: d.user, Status: models.SessionActive, StartTime: start}
+				if err := s.Store.Sessions().Create(&session); err != nil {
+					t.Fatal(err)
+				}
+				for _, answer := range []struct {
+					word    int
+					correct bool
+					at      time.Duration
+				}{
+					{0, true, 4 * time.Second},   // under the 10s target
+					{1, true, 20 * time.Second},  // 16s, over the target
+					{0, true, 80 * time.Second},  // 60s, no less than half a point
+					{1, true, 80 * time.Second},  // recorded with the previous answer, so untimed
+					{0, false, 90 * time.Second}, // wrong, however fast
+				} {
+					item := models.WordReviewItem{SessionID: session.ID, WordID: d.words[answer.word].ID, IsCorrect: answer.correct, ReviewedAt: start.Add(answer.at)}
+					if err := s.Store.Reviews().Create(&item); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				ended, err := s.Sessions.EndSession(d.user, session.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b := ended.ScoreBreakdown
+				if b == nil || b.Strategy != "speed" || len(b.Items) != 5 {
+					t.Fatalf("typing breakdown = %+v, want speed over 5 answers", b)
+				}
+				for i, want := range []struct{ seconds, points float64 }{{4, 1}, {16, 0.625}, {60, 0.5}, {0, 1}, {10, 0}} {
+					if got := b.Items[i]; got.ResponseSeconds != want.seconds || got.Points != want.points || got.MaxPoints != 1 {
+						t.Fatalf("typing answer %d = %+v, want %v points after %vs", i, got, want.points, want.seconds)
+					}
+				}
+				if *ended.Score != 62.5 {
+					t.Fatalf("typing score = %v, want 62.5", *ended.Score)
+				}
+			}),
+		},
+		{
+			Name: "the reaper abandons sessions idle past the timeout",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				item, err := s.Sessions.RecordReview(d.user, d.session.ID, d.words[0].ID, true, "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				untouched := models.StudySession{StudyActivityID: d.activity.ID}
+				if err := s.Sessions.CreateSession(d.user, &untouched); err != nil {
+					t.Fatal(err)
+				}
+				completed := models.StudySession{StudyActivityID: d.activity.ID}
+				if err := s.Sessions.CreateSession(d.user, &completed); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := s.Sessions.EndSession(d.user, completed.ID); err != nil {
+					t.Fatal(err)
+				}
+
+				clock := NewClock(time.Now())
+				reaper := jobs.SessionReaper(s.Sessions, time.Hour, time.Minute, clock)
+				clock.Advance(59 * time.Minute)
+				if err := reaper.Run(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				for _, id := range []int64{d.session.ID, untouched.ID} {
+					session, err := s.Sessions.GetSession(d.user, id)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if session.Status != models.SessionActive {
+						t.Fatalf("session %d = %+v before the timeout, want active", id, session)
+					}
+				}
+
+				clock.Advance(time.Hour)
+				if err := reaper.Run(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				for id, end := range map[int64]time.Time{d.session.ID: item.ReviewedAt, untouched.ID: untouched.StartTime} {
+					session, err := s.Sessions.GetSession(d.user, id)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if session.Status != models.SessionAbandoned || session.EndTime == nil || !session.EndTime.Equal(end) || session.Score != nil {
+						t.Fatalf("session %d = %+v, want abandoned at %v without a score", id, session, end)
+					}
+				}
+				session, err := s.Sessions.GetSession(d.user, completed.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if session.Status != models.SessionCompleted {
+					t.Fatalf("completed session became %q", session.Status)
+				}
+
+				abandoned, err := s.Sessions.AbandonIdle(clock.Now(), time.Hour)
+				if err != nil || len(abandoned) != 0 {
+					t.Fatalf("second pass abandoned %+v, %v, want nothing", abandoned, err)
+				}
+			}),
+		},
+		{
+			Name: "due words put overdue words before new ones",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				if _, err := s.Sessions.RecordReview(d.user, d.session.ID, d.words[1].ID, true, ""); err != nil {
+					t.Fatal(err)
+				}
+
+				due, err := s.Reviews.GetDueWords(d.user, &d.greetings.ID, 10, time.Now())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(due) != 1 || due[0].ID != d.words[0].ID || !due[0].IsNew || due[0].EaseFactor != 2.5 {
+					t.Fatalf("due now = %+v, want only the new word", due)
+				}
+
+				due, err = s.Reviews.GetDueWords(d.user, nil, 10, time.Now().AddDate(0, 0, 2))
+				if err != nil {
+					t.Fatal(err)
+				}
+				var ids []int64
+				for _, word := range due {
+					ids = append(ids, word.ID)
+				}
+				want := []int64{d.words[1].ID, d.words[0].ID, d.words[2].ID}
+				if !slices.Equal(ids, want) || due[0].IsNew || due[0].Repetitions != 1 {
+					t.Fatalf("due in two days = %v, want %v with the reviewed word first", ids, want)
+				}
+
+				missing := int64(99)
+				_, err = s.Reviews.GetDueWords(d.user, &missing, 10, time.Now())
+				wantErr(t, err, service.ErrGroupNotFound)
+			}),
+		},
+		{
+			Name: "deleting a word removes its memberships and reviews",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				if _, err := s.Sessions.RecordReview(d.user, d.session.ID, d.words[0].ID, true, ""); err != nil {
+					t.Fatal(err)
+				}
+				if err := s.Words.DeleteWord(d.words[0].ID); err != nil {
+					t.Fatal(err)
+				}
+
+				wordCount(t, s, d.greetings.ID, 1)
+				items, err := s.Sessions.ListSessionReviewItems(d.user, d.session.ID, repository.ReviewFilter{}, firstPage(repository.ReviewListing, 10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if items.Total != 0 {
+					t.Fatalf("session still has %d review items", items.Total)
+				}
+				_, err = s.Reviews.GetSchedule(d.user, d.words[0].ID)
+				wantErr(t, err, service.ErrWordNotFound)
+			}),
+		},
+		{
+			Name: "deleting a group removes its activities and sessions",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				sessions, err := s.Groups.GetGroupStudySessions(d.user, d.greetings.ID, firstPage(repository.SessionListing, 10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(sessions.Items) != 1 {
+					t.Fatalf("group has %d sessions before delete, want 1", len(sessions.Items))
+				}
+
+				if err := s.Groups.DeleteGroup(d.greetings.ID); err != nil {
+					t.Fatal(err)
+				}
+				_, err = s.Activities.GetActivity(d.activity.ID)
+				wantErr(t, err, service.ErrActivityNotFound)
+				_, err = s.Sessions.GetSession(d.user, d.session.ID)
+				wantErr(t, err, service.ErrSessionNotFound)
+				if _, err := s.Words.GetWord(d.words[0].ID); err != nil {
+					t.Fatalf("word was deleted with its group: %v", err)
+				}
+				wantErr(t, s.Groups.DeleteGroup(d.greetings.ID), service.ErrGroupNotFound)
+			}),
+		},
+		{
+			Name: "launching an app reuses its activity for the group",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				app := models.ActivityApp{
+					Name:         "Kana Quiz",
+					ActivityType: "quiz",
+					ThumbnailURL: "https://apps.example.com/kana.png",
+					LaunchURL:    "https://apps.example.com/kana?title={group_name}&session={session_id}",
+				}
+				if err := s.Apps.CreateApp(&app); err != nil {
+					t.Fatal(err)
+				}
+				wantErr(t, s.Apps.CreateApp(&models.ActivityApp{Name: "Kana Quiz"}), service.ErrAppNameTaken)
+
+				first, err := s.Apps.Launch(d.user, app.ID, d.greetings.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				second, err := s.Apps.Launch(d.user, app.ID, d.greetings.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if first.Session.StudyActivityID != second.Session.StudyActivityID || first.SessionID == second.SessionID {
+					t.Fatalf("launches opened sessions %+v and %+v, want two sessions of one activity", first.Session, second.Session)
+				}
+				want := fmt.Sprintf("https://apps.example.com/kana?title=Greetings&session=%d", second.SessionID)
+				if second.LaunchURL != want {
+					t.Fatalf("launch URL = %q, want %q", second.LaunchURL, want)
+				}
+
+				activity, err := s.Activities.GetActivity(first.Session.StudyActivityID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if activity.AppID == nil || *activity.AppID != app.ID || activity.Name != app.Name || activity.ActivityType != "quiz" {
+					t.Fatalf("launched activity = %+v, want one linked to the app", activity)
+				}
+				launches, err := s.Apps.ListLaunches(d.user, firstPage(repository.LaunchListing, 10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if launches.Total != 2 {
+					t.Fatalf("user has %d launches, want 2", launches.Total)
+				}
+
+				_, err = s.Apps.Launch(d.user, app.ID, 99)
+				wantErr(t, err, service.ErrGroupNotFound)
+				_, err = s.Apps.Launch(d.user, 99, d.greetings.ID)
+				wantErr(t, err, service.ErrAppNotFound)
+				sessions, err := s.Groups.GetGroupStudySessions(d.user, d.greetings.ID, firstPage(repository.SessionListing, 10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(sessions.Items) != 3 {
+					t.Fatalf("group has %d sessions after failed launches, want 3", len(sessions.Items))
+				}
+			}),
+		},
+		{
+			Name: "deleting an app unlinks its activities",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				app := models.ActivityApp{Name: "Kana Quiz", ActivityType: "quiz", LaunchURL: "https://apps.example.com/kana"}
+				if err := s.Apps.CreateApp(&app); err != nil {
+					t.Fatal(err)
+				}
+				launch, err := s.Apps.Launch(d.user, app.ID, d.animals.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := s.Apps.DeleteApp(app.ID); err != nil {
+					t.Fatal(err)
+				}
+				activity, err := s.Activities.GetActivity(launch.Session.StudyActivityID)
+				if err != nil {
+					t.Fatalf("activity was deleted with its app: %v", err)
+				}
+				if activity.AppID != nil || activity.Name != "" {
+					t.Fatalf("activity = %+v, want it unlinked", activity)
+				}
+				if _, err := s.Sessions.GetSession(d.user, launch.SessionID); err != nil {
+					t.Fatalf("session was deleted with its app: %v", err)
+				}
+				launches, err := s.Apps.ListLaunches(d.user, firstPage(repository.LaunchListing, 10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if launches.Total != 0 {
+					t.Fatalf("user has %d launches after delete, want 0", launches.Total)
+				}
+				wantErr(t, s.Apps.DeleteApp(app.ID), service.ErrAppNotFound)
+			}),
+		},
+		{
+			Name: "study history is kept per user",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				if _, err := s.Sessions.RecordReview(d.user, d.session.ID, d.words[0].ID, true, ""); err != nil {
+					t.Fatal(err)
+				}
+
+				other := models.User{Name: "hana"}
+				if err := s.Users.CreateUser(&other); err != nil {
+					t.Fatal(err)
+				}
+				wantErr(t, s.Users.CreateUser(&models.User{Name: "hana"}), service.ErrUserNameTaken)
+
+				_, err := s.Sessions.GetSession(other.ID, d.session.ID)
+				wantErr(t, err, service.ErrSessionNotFound)
+				_, err = s.Sessions.RecordReview(other.ID, d.session.ID, d.words[0].ID, true, "")
+				wantErr(t, err, service.ErrSessionNotFound)
+				_, err = s.Sessions.EndSession(other.ID, d.session.ID)
+				wantErr(t, err, service.ErrSessionNotFound)
+				sessions, err := s.Groups.GetGroupStudySessions(other.ID, d.greetings.ID, firstPage(repository.SessionListing, 10))
+				if err != nil || len(sessions.Items) != 0 {
+					t.Fatalf("another user's group sessions = %+v, %v, want none", sessions, err)
+				}
+
+				session := models.StudySession{StudyActivityID: d.activity.ID}
+				if err := s.Sessions.CreateSession(other.ID, &session); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := s.Sessions.RecordReview(other.ID, session.ID, d.words[0].ID, false, ""); err != nil {
+					t.Fatal(err)
+				}
+				result, err := s.Sessions.ListSessions(other.ID, repository.SessionFilter{}, firstPage(repository.SessionListing, 10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Total != 1 {
+					t.Fatalf("another user has %d sessions, want 1", result.Total)
+				}
+				orphan := models.StudySession{StudyActivityID: d.activity.ID, UserID: 99, Status: models.SessionActive}
+				if err := s.Store.Sessions().Create(&orphan); err == nil {
+					t.Fatalf("created a session for a missing user")
+				}
+
+				for _, user := range []struct {
+					id      int64
+					correct int64
+					wrong   int64
+					reps    int
+				}{{d.user, 1, 0, 1}, {other.ID, 0, 1, 0}} {
+					word, err := s.Words.GetWordWithStats(user.id, d.words[0].ID)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if word.CorrectCount != user.correct || word.WrongCount != user.wrong {
+						t.Fatalf("user %d stats = %+v, want %d correct and %d wrong", user.id, word.WordStats, user.correct, user.wrong)
+					}
+					schedule, err := s.Reviews.GetSchedule(user.id, d.words[0].ID)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if schedule.Repetitions != user.reps {
+						t.Fatalf("user %d schedule = %+v, want %d repetitions", user.id, schedule, user.reps)
+					}
+				}
+			}),
+		},
+		{
+			Name: "tokens act for their user until revoked or expired",
+			Run: func(t *testing.T, s *StoreServices) {
+				teacher := models.User{Name: "sensei", Role: models.RoleTeacher}
+				if err := s.Users.CreateUser(&teacher); err != nil {
+					t.Fatal(err)
+				}
+
+				issued, err := s.Auth.IssueToken(teacher.ID, "laptop", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				user, err := s.Auth.Authenticate(issued.Token)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if user.ID != teacher.ID || user.Role != models.RoleTeacher {
+					t.Fatalf("token acts for %+v, want the teacher", user)
+				}
+				_, err = s.Auth.Authenticate(issued.Token + "x")
+				wantErr(t, err, service.ErrInvalidToken)
+
+				expiry := time.Now().Add(-time.Minute)
+				expired, err := s.Auth.IssueToken(teacher.ID, "old phone", &expiry)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = s.Auth.Authenticate(expired.Token)
+				wantErr(t, err, service.ErrInvalidToken)
+
+				tokens, err := s.Auth.ListTokens(teacher.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(tokens) != 2 || tokens[0].Name != "laptop" || tokens[1].ExpiresAt == nil {
+					t.Fatalf("tokens = %+v, want laptop and the expired phone", tokens)
+				}
+
+				wantErr(t, s.Auth.RevokeToken(models.DefaultUserID, issued.ID), service.ErrTokenNotFound)
+				if err := s.Auth.RevokeToken(teacher.ID, issued.ID); err != nil {
+					t.Fatal(err)
+				}
+				_, err = s.Auth.Authenticate(issued.Token)
+				wantErr(t, err, service.ErrInvalidToken)
+				_, err = s.Auth.IssueToken(99, "laptop", nil)
+				wantErr(t, err, service.ErrUserNotFound)
+
+				anonymous, err := s.Auth.Anonymous()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if anonymous.ID != models.DefaultUserID || anonymous.Role != models.RoleLearner {
+					t.Fatalf("anonymous requests act for %+v, want the default user as a learner", anonymous)
+				}
+			},
+		},
+		{
+			Name: "the last admin keeps the role",
+			Run: func(t *testing.T, s *StoreServices) {
+				_, err := s.Users.SetRole(models.DefaultUserID, models.RoleTeacher)
+				wantErr(t, err, service.ErrLastAdmin)
+
+				other := models.User{Name: "kenji"}
+				if err := s.Users.CreateUser(&other); err != nil {
+					t.Fatal(err)
+				}
+				if other.Role != models.RoleLearner {
+					t.Fatalf("new user has role %q, want learner", other.Role)
+				}
+				if _, err := s.Users.SetRole(other.ID, models.RoleAdmin); err != nil {
+					t.Fatal(err)
+				}
+				user, err := s.Users.SetRole(models.DefaultUserID, models.RoleTeacher)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if user.Role != models.RoleTeacher {
+					t.Fatalf("default user has role %q, want teacher", user.Role)
+				}
+				_, err = s.Users.SetRole(99, models.RoleTeacher)
+				wantErr(t, err, service.ErrUserNotFound)
+			},
+		},
+		{
+			Name: "imports match words on their japanese and english text",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				file := "japanese,romaji,english,parts.type\n" +
+					"こんにちは,konnichiwa,hello,greeting\n" +
+					"ねこ,neko,cat,animal\n" +
+					"いぬ,inu,dog,noun\n" +
+					"いぬ,inu,dog,noun\n"
+				report, err := s.Import.Import(strings.NewReader(file), importer.CSV, service.ImportOptions{GroupID: &d.animals.ID})
+				if err != nil {
+					t.Fatal(err)
+				}
+				var statuses []string
+				for _, row := range report.Rows {
+					statuses = append(statuses, row.Status)
+				}
+				want := []string{service.ImportSkipped, service.ImportUpdated, service.ImportCreated, service.ImportSkipped}
+				if !slices.Equal(statuses, want) || !report.Committed || report.Linked != 3 {
+					t.Fatalf("import = %v, committed %v, linked %d; want %v, committed, 3 linked",
+						statuses, report.Committed, report.Linked, want)
+				}
+				cat, err := s.Words.GetWord(d.words[2].ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cat.Parts["type"] != "animal" {
+					t.Fatalf("updated word has parts %v", cat.Parts)
+				}
+				wordCount(t, s, d.animals.ID, 3)
+			}),
+		},
+		{
+			Name: "imports with invalid rows or as a dry run leave no trace",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				file := "japanese,romaji,english\nとり,tori,bird\nうま,,horse\n"
+				_, err := s.Import.Import(strings.NewReader(file), importer.CSV, service.ImportOptions{GroupName: "Farm"})
+				var importErr *service.ImportError
+				if !errors.As(err, &importErr) || importErr.Report.Errored != 1 {
+					t.Fatalf("import err = %v, want one errored row", err)
+				}
+
+				file = "japanese,romaji,english\nとり,tori,bird\n"
+				report, err := s.Import.Import(strings.NewReader(file), importer.CSV,
+					service.ImportOptions{GroupName: "Farm", DryRun: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if report.Committed || report.Created != 1 || !report.GroupCreated {
+					t.Fatalf("dry run report = %+v", report)
+				}
+
+				_, err = s.Store.Words().FindByText("とり", "bird")
+				wantErr(t, err, repository.ErrNotFound)
+				_, err = s.Store.Groups().GetByName("Farm")
+				wantErr(t, err, repository.ErrNotFound)
+			}),
+		},
+		{
+			Name: "dashboard and system statistics",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				start := time.Now().AddDate(0, 0, -1)
+				end := start.Add(5 * time.Minute)
+				score := 80.0
+				yesterday := models.StudySession{
+					StartTime:       start,
+					EndTime:         &end,
+					Score:           &score,
+					Status:          "completed",
+					StudyActivityID: d.activity.ID,
+					UserID:          d.user,
+				}
+				if err := s.Store.Sessions().Create(&yesterday); err != nil {
+					t.Fatal(err)
+				}
+				for i, correct := range []bool{true, false} {
+					if _, err := s.Sessions.RecordReview(d.user, d.session.ID, d.words[i].ID, correct, ""); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				dashboard := s.Dashboard
+				last, err := dashboard.GetLastSession(d.user)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if last == nil || last.SessionID != d.session.ID || last.GroupName != "Greetings" ||
+					last.WordsReviewed != 2 || last.CorrectAnswers != 1 {
+					t.Fatalf("last session = %+v, want session %d of Greetings with 1 of 2 correct", last, d.session.ID)
+				}
+
+				stats, err := dashboard.GetStats(d.user)
+				if err != nil {
+					t.Fatal(err)
+				}
+				wantStats := models.StudyStats{
+					TotalStudyTime:     300,
+					SessionsCompleted:  1,
+					TotalWordsReviewed: 2,
+					SuccessRate:        50,
+					StudyStreakDays:    2,
+				}
+				if *stats != wantStats {
+					t.Fatalf("stats = %+v, want %+v", *stats, wantStats)
+				}
+
+				progress, err := dashboard.GetProgress(d.user)
+				if err != nil {
+					t.Fatal(err)
+				}
+				wantProgress := models.StudyProgress{OverallCompletion: 66.7, TotalWordsStudied: 2, TotalAvailableWords: 3}
+				if *progress != wantProgress {
+					t.Fatalf("progress = %+v, want %+v", *progress, wantProgress)
+				}
+
+				system, err := s.System.GetSystemStats()
+				if err != nil {
+					t.Fatal(err)
+				}
+				wantSystem := models.SystemStats{
+					TotalWords:            3,
+					TotalGroups:           2,
+					TotalSessions:         2,
+					AverageSessionScore:   80,
+					TotalStudyTimeMinutes: 5,
+				}
+				if *system != wantSystem {
+					t.Fatalf("system stats = %+v, want %+v", *system, wantSystem)
+				}
+
+				other := models.User{Name: "hana"}
+				if err := s.Users.CreateUser(&other); err != nil {
+					t.Fatal(err)
+				}
+				if last, err := dashboard.GetLastSession(other.ID); err != nil || last != nil {
+					t.Fatalf("another user's last session = %+v, %v, want none", last, err)
+				}
+				stats, err = dashboard.GetStats(other.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if *stats != (models.StudyStats{}) {
+					t.Fatalf("another user's stats = %+v, want zeros", *stats)
+				}
+				progress, err = dashboard.GetProgress(other.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if progress.TotalWordsStudied != 0 || progress.TotalAvailableWords != 3 {
+					t.Fatalf("another user's progress = %+v, want 0 of 3 words studied", *progress)
+				}
+			}),
+		},
+		{
+			Name: "the last session is the latest whatever the zone of its start time",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				// An hour ago in UTC+14 reads as a later date than now in UTC
+				ahead := time.FixedZone("LINT", 14*60*60)
+				earlier := models.StudySession{
+					StartTime:       time.Now().Add(-time.Hour).In(ahead),
+					Status:          "completed",
+					StudyActivityID: d.activity.ID,
+					UserID:          d.user,
+				}
+				if err := s.Store.Sessions().Create(&earlier); err != nil {
+					t.Fatal(err)
+				}
+
+				last, err := s.Dashboard.GetLastSession(d.user)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if last == nil || last.SessionID != d.session.ID {
+					t.Fatalf("last session = %+v, want session %d", last, d.session.ID)
+				}
+			}),
+		},
+		{
+			Name: "system health and pruning",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				system := s.System
+				health, err := system.GetSystemHealth()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if health.Status != "healthy" {
+					t.Fatalf("health = %+v, want healthy", health)
+				}
+				// The in-memory store has no file to measure
+				if size, err := system.GetDatabaseSize(); err != nil || (s.DB != nil && size <= 0) {
+					t.Fatalf("database size = %d, %v", size, err)
+				}
+
+				end := time.Now().AddDate(0, 0, -40)
+				old := models.StudySession{
+					StartTime:       end.Add(-10 * time.Minute),
+					EndTime:         &end,
+					Status:          "completed",
+					StudyActivityID: d.activity.ID,
+					UserID:          d.user,
+				}
+				if err := s.Store.Sessions().Create(&old); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := system.PruneOldData(30); err != nil {
+					t.Fatal(err)
+				}
+				_, err = s.Sessions.GetSession(d.user, old.ID)
+				wantErr(t, err, service.ErrSessionNotFound)
+				_, err = s.Sessions.GetSession(d.user, d.session.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}),
+		},
+		{
+			Name: "failed units of work leave no trace",
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				failure := errors.New("abort")
+				err := s.Store.Atomic(func(store repository.Store) error {
+					word := models.Word{Japanese: "とり", Romaji: "tori", English: "bird"}
+					if err := store.Words().Create(&word); err != nil {
+						return err
+					}
+					if err := store.Groups().AddWord(d.animals.ID, word.ID); err != nil {
+						return err
+					}
+					return failure
+				})
+				wantErr(t, err, failure)
+
+				result, err := s.Words.ListWords(models.DefaultUserID, repository.WordFilter{}, firstPage(repository.WordListing, 10))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Total != 3 {
+					t.Fatalf("found %d words after rollback, want 3", result.Total)
+				}
+				wordCount(t, s, d.animals.ID, 0)
+			}),
+		},
+	}, sqlCases()...)
+}
+
+// sqlCases run the tools that work on the database itself, such as the
+// seed importer, so each dialect's SQL is checked against its schema
+func sqlCases() []StoreCase {
+	return []StoreCase{
+		{
+			Name: "a group exported as JSON seeds back unchanged",
+			SQL:  true,
+			Run: seeded(func(t *testing.T, s *StoreServices, d *storeData) {
+				deck, err := s.Export.Deck(&d.greetings.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(deck.Words) != 2 || deck.Name() != d.greetings.Name {
+					t.Fatalf("deck = %+v, want the 2 greetings", deck)
+				}
+				missing := int64(99)
+				_, err = s.Export.Deck(&missing)
+				wantErr(t, err, service.ErrGroupNotFound)
+
+				dir := t.TempDir()
+				file, err := os.Create(filepath.Join(dir, deck.FileName(export.JSON)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = export.Write(file, export.JSON, deck)
+				if closeErr := file.Close(); err == nil {
+					err = closeErr
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				manifest := filepath.Join(dir, database.ManifestFile)
+				entry := fmt.Sprintf("seed %s into %q\n", deck.FileName(export.JSON), d.greetings.Name)
+				if err := os.WriteFile(manifest, []byte(entry), 0o644); err != nil {
+					t.Fatal(err)
+				}
+
+				report, err := database.SeedFromManifest(s.DB, manifest, database.SeedOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if f := report.Files[0]; f.GroupCreated || f.WordsInserted != 0 || f.WordsUpdated != 0 || f.WordsLinked != 0 || f.WordsUnchanged != 2 {
+					t.Fatalf("seeding the export = %+v, want 2 unchanged words", f)
+				}
+			}),
+		},
+		{
+			Name: "seeding twice changes nothing the second time",
+			SQL:  true,
+			Run: func(t *testing.T, s *StoreServices) {
+				root, err := ModuleRoot()
+				if err != nil {
+					t.Fatal(err)
+				}
+				manifest := filepath.Join(root, database.SeedsDir, database.ManifestFile)
+
+				first, err := database.SeedFromManifest(s.DB, manifest, database.SeedOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				second, err := database.SeedFromManifest(s.DB, manifest, database.SeedOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var total int64
+				for i, f := range second.Files {
+					inserted := first.Files[i].WordsInserted
+					total += int64(inserted)
+					if inserted == 0 || !first.Files[i].GroupCreated {
+						t.Fatalf("first run of %s = %+v, want a new group and words", f.File, first.Files[i])
+					}
+					if f.GroupCreated || f.WordsInserted != 0 || f.WordsUpdated != 0 || f.WordsLinked != 0 || f.WordsUnchanged != inserted {
+						t.Fatalf("second run of %s = %+v, want %d unchanged words", f.File, f, inserted)
+					}
+				}
+
+				result, err := s.Words.ListWords(models.DefaultUserID, repository.WordFilter{}, firstPage(repository.WordListing, 1))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Total != total {
+					t.Fatalf("found %d words, want %d", result.Total, total)
+				}
+			},
+		},
+		{
+			Name: "importing a seed file after seeding changes nothing",
+			SQL:  true,
+			Run: func(t *testing.T, s *StoreServices) {
+				root, err := ModuleRoot()
+				if err != nil {
+					t.Fatal(err)
+				}
+				seeds := filepath.Join(root, database.SeedsDir)
+				if _, err := database.SeedFromManifest(s.DB, filepath.Join(seeds, database.ManifestFile), database.SeedOptions{}); err != nil {
+					t.Fatal(err)
+				}
+
+				file, err := os.Open(filepath.Join(seeds, "basic_greetings.json"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer file.Close()
+				report, err := s.Import.Import(file, importer.JSON, service.ImportOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if report.GroupCreated || report.Created != 0 || report.Updated != 0 || report.Linked != 0 || report.Skipped != len(report.Rows) {
+					t.Fatalf("import after seeding = %+v, want every word skipped", report)
+				}
+			},
+		},
+	}
+}
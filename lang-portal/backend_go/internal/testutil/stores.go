@@ -0,0 +1,105 @@
+package testutil
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/erans/lang-portal/internal/repository"
+	"github.com/erans/lang-portal/internal/repository/memstore"
+	"github.com/erans/lang-portal/internal/repository/sqlstore"
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/erans/lang-portal/internal/validation"
+)
+
+// StoreServices are the repository-backed services sharing one store
+type StoreServices struct {
+	Store      repository.Store
+	Users      *service.UserService
+	Auth       *service.AuthService
+	Words      *service.WordService
+	Groups     *service.GroupService
+	Activities *service.StudyActivityService
+	Apps       *service.ActivityAppService
+	Export     *service.ExportService
+	Import     *service.ImportService
+	Sessions   *service.StudySessionService
+	Reviews    *service.ReviewService
+	Dashboard  *service.DashboardService
+	System     *service.SystemService
+	// DB is the database behind a SQL store, for the tools that query it
+	// directly; nil for other stores
+	DB *sql.DB
+}
+
+// NewStoreServices creates the repository-backed services on store
+func NewStoreServices(store repository.Store) *StoreServices {
+	return &StoreServices{
+		Store:      store,
+		Users:      service.NewUserService(store),
+		Auth:       service.NewAuthService(store),
+		Words:      service.NewWordService(store),
+		Groups:     service.NewGroupService(store),
+		Activities: service.NewStudyActivityService(store),
+		Apps:       service.NewActivityAppService(store),
+		Export:     service.NewExportService(store),
+		Import:     service.NewImportService(store, validation.New(store)),
+		Sessions:   service.NewStudySessionService(store),
+		Reviews:    service.NewReviewService(store),
+		Dashboard:  service.NewDashboardService(store),
+		System:     service.NewSystemService(store, ""),
+	}
+}
+
+// NewSQLServices creates the services on a sqlstore over db
+func NewSQLServices(db *sql.DB) *StoreServices {
+	s := NewStoreServices(sqlstore.New(db))
+	s.DB = db
+	return s
+}
+
+// StoreFactory creates the services on an empty store and returns the
+// function that releases it
+type StoreFactory func() (*StoreServices, func(), error)
+
+// SQLStore is a StoreFactory for a sqlstore on a migrated in-memory SQLite
+// database
+func SQLStore() (*StoreServices, func(), error) {
+	db, err := NewDB()
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewSQLServices(db), func() { db.Close() }, nil
+}
+
+// MemStore is a StoreFactory for the in-memory fake
+func MemStore() (*StoreServices, func(), error) {
+	return NewStoreServices(memstore.New()), func() {}, nil
+}
+
+// StoreCase checks the business logic of the services against a store
+type StoreCase struct {
+	Name string
+	// SQL cases need StoreServices.DB and are skipped on other stores
+	SQL bool
+	Run func(t *testing.T, s *StoreServices)
+}
+
+// RunStores runs each case as a subtest against its own empty store.
+// Running the same cases against every implementation keeps them
+// interchangeable.
+func RunStores(t *testing.T, newStore StoreFactory, cases []StoreCase) {
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			s, release, err := newStore()
+			if err != nil {
+				t.Fatalf("store: %v", err)
+			}
+			defer release()
+
+			if c.SQL && s.DB == nil {
+				t.Skip("needs a SQL database")
+			}
+			c.Run(t, s)
+		})
+	}
+}
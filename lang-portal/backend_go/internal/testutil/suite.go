@@ -0,0 +1,257 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/erans/lang-portal/internal/api"
+)
+
+// Case is a single request against a fresh harness and its expected outcome
+type Case struct {
+	Name     string
+	Fixtures []string
+	// Setup runs before the request, e.g. to prepare files
+	Setup      func(h *Harness) error
+	Method     string
+	Path       string
+	Body       any
+	WantStatus int
+	// Check inspects the response after the status matched
+	Check func(h *Harness, w *httptest.ResponseRecorder) error
+	// Header is sent with the request, e.g. to authenticate with a token
+	Header http.Header
+	// Skip documents why a case is not run yet
+	Skip string
+	// Options replaces the harness options, e.g. to run with the defaults
+	Options *api.Options
+}
+
+// Run runs each case as a subtest against its own harness
+func Run(t *testing.T, cases []Case) {
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			if c.Skip != "" {
+				t.Skip(c.Skip)
+			}
+			runCase(t, c)
+		})
+	}
+}
+
+// runCase executes a single case
+func runCase(t *testing.T, c Case) {
+	opts := Options()
+	if c.Options != nil {
+		opts = *c.Options
+	}
+	h := NewWithOptions(t, opts, c.Fixtures...)
+
+	if c.Setup != nil {
+		if err := c.Setup(h); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	w := h.DoWithHeader(c.Method, c.Path, c.Body, c.Header)
+	if w.Code != c.WantStatus {
+		t.Fatalf("status = %d, want %d: %s", w.Code, c.WantStatus, w.Body.String())
+	}
+
+	if c.Check != nil {
+		if err := c.Check(h, w); err != nil {
+			t.Fatalf("%v: %s", err, w.Body.String())
+		}
+	}
+}
+
+// JSONFields returns a check that decodes the response as a JSON object and
+// compares the given top-level fields. Values are compared by their
+// formatted representation, so 2 matches a decoded 2.0.
+func JSONFields(want map[string]any) func(*Harness, *httptest.ResponseRecorder) error {
+	return func(_ *Harness, w *httptest.ResponseRecorder) error {
+		var got map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			return fmt.Errorf("response is not a JSON object: %w", err)
+		}
+		for key, value := range want {
+			actual, ok := got[key]
+			if !ok {
+				return fmt.Errorf("missing field %q", key)
+			}
+			if fmt.Sprint(actual) != fmt.Sprint(value) {
+				return fmt.Errorf("%s = %v, want %v", key, actual, value)
+			}
+		}
+		return nil
+	}
+}
+
+// ItemCount returns a check that expects a response whose "items" array,
+// or top-level array, has n elements. An empty list must be [], not null.
+func ItemCount(n int) func(*Harness, *httptest.ResponseRecorder) error {
+	return func(_ *Harness, w *httptest.ResponseRecorder) error {
+		var items []any
+		var wrapped struct {
+			Items []any `json:"items"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &wrapped); err == nil {
+			items = wrapped.Items
+		} else if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			return fmt.Errorf("response has no items: %w", err)
+		}
+		if items == nil {
+			return fmt.Errorf("items are null, want an array")
+		}
+		if len(items) != n {
+			return fmt.Errorf("got %d items, want %d", len(items), n)
+		}
+		return nil
+	}
+}
+
+// PageIDs returns a check that follows the next_cursor of a listing from
+// the response to path and expects the items of every page to have ids,
+// in order
+func PageIDs(path string, ids ...int64) func(*Harness, *httptest.ResponseRecorder) error {
+	return PageIDsAs(nil, path, ids...)
+}
+
+// PageIDsAs is PageIDs for listings that follow cursors with header
+func PageIDsAs(header http.Header, path string, ids ...int64) func(*Harness, *httptest.ResponseRecorder) error {
+	return func(h *Harness, w *httptest.ResponseRecorder) error {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+
+		var got []int64
+		for range len(ids) + 1 {
+			var page struct {
+				Items []struct {
+					ID int64 `json:"id"`
+				} `json:"items"`
+				Pagination struct {
+					NextCursor string `json:"next_cursor"`
+				} `json:"pagination"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				return fmt.Errorf("response is not a page: %w", err)
+			}
+			for _, item := range page.Items {
+				got = append(got, item.ID)
+			}
+			if page.Pagination.NextCursor == "" {
+				break
+			}
+
+			w = h.DoWithHeader(http.MethodGet, path+sep+"cursor="+page.Pagination.NextCursor, nil, header)
+			if w.Code != http.StatusOK {
+				return fmt.Errorf("following cursor: status %d: %s", w.Code, w.Body.String())
+			}
+		}
+		if !slices.Equal(got, ids) {
+			return fmt.Errorf("listed ids %v, want %v", got, ids)
+		}
+		return nil
+	}
+}
+
+// RowCount returns a check that expects query to count n rows
+func RowCount(query string, n int) func(*Harness, *httptest.ResponseRecorder) error {
+	return func(h *Harness, _ *httptest.ResponseRecorder) error {
+		var count int
+		if err := h.DB.QueryRow(query).Scan(&count); err != nil {
+			return err
+		}
+		if count != n {
+			return fmt.Errorf("%s = %d, want %d", query, count, n)
+		}
+		return nil
+	}
+}
+
+// All combines checks, stopping at the first failure
+func All(checks ...func(*Harness, *httptest.ResponseRecorder) error) func(*Harness, *httptest.ResponseRecorder) error {
+	return func(h *Harness, w *httptest.ResponseRecorder) error {
+		for _, check := range checks {
+			if err := check(h, w); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ErrorCode returns a check that expects an error envelope with code
+func ErrorCode(code string) func(*Harness, *httptest.ResponseRecorder) error {
+	return func(_ *Harness, w *httptest.ResponseRecorder) error {
+		body, err := decodeError(w)
+		if err != nil {
+			return err
+		}
+		if body.Code != code {
+			return fmt.Errorf("error code = %q, want %q", body.Code, code)
+		}
+		return nil
+	}
+}
+
+// ErrorDetail returns a check that compares one field of the error details
+func ErrorDetail(key string, want any) func(*Harness, *httptest.ResponseRecorder) error {
+	return func(_ *Harness, w *httptest.ResponseRecorder) error {
+		body, err := decodeError(w)
+		if err != nil {
+			return err
+		}
+		got, ok := body.Details[key]
+		if !ok {
+			return fmt.Errorf("missing error detail %q", key)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			return fmt.Errorf("error detail %s = %v, want %v", key, got, want)
+		}
+		return nil
+	}
+}
+
+// decodeError decodes the error envelope of a response
+func decodeError(w *httptest.ResponseRecorder) (*api.ErrorBody, error) {
+	var envelope api.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		return nil, fmt.Errorf("response is not an error envelope: %w", err)
+	}
+	if envelope.Error.Code == "" {
+		return nil, fmt.Errorf("response has no error code")
+	}
+	return &envelope.Error, nil
+}
+
+// InvalidFields returns a check that expects a validation error naming
+// exactly the given fields
+func InvalidFields(fields ...string) func(*Harness, *httptest.ResponseRecorder) error {
+	return func(_ *Harness, w *httptest.ResponseRecorder) error {
+		body, err := decodeError(w)
+		if err != nil {
+			return err
+		}
+		if body.Code != "validation_failed" {
+			return fmt.Errorf("error code = %q, want %q", body.Code, "validation_failed")
+		}
+		got, _ := body.Details["fields"].(map[string]any)
+		if len(got) != len(fields) {
+			return fmt.Errorf("invalid fields = %v, want %v", got, fields)
+		}
+		for _, field := range fields {
+			if _, ok := got[field]; !ok {
+				return fmt.Errorf("invalid fields = %v, want %v", got, fields)
+			}
+		}
+		return nil
+	}
+}
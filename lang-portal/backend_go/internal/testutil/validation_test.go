@@ -0,0 +1,25 @@
+package testutil
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestRoutersValidateWithTheirOwnDatabase checks that two routers in one
+// process check references against their own databases
+func TestRoutersValidateWithTheirOwnDatabase(t *testing.T) {
+	withGroups := New(t, "groups")
+	empty := New(t)
+
+	activity := map[string]any{"group_id": 1, "activity_type": "quiz"}
+	if w := withGroups.DoWithHeader(http.MethodPost, "/api/activities", activity, admin); w.Code != http.StatusCreated {
+		t.Fatalf("router with groups: status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	w := empty.DoWithHeader(http.MethodPost, "/api/activities", activity, admin)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("router without groups: status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+	if err := InvalidFields("group_id")(empty, w); err != nil {
+		t.Fatal(err)
+	}
+}
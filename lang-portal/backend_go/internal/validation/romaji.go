@@ -0,0 +1,242 @@
+package validation
+
+import (
+	"strings"
+	"unicode"
+)
+
+// kanaRomaji maps each hiragana to its Hepburn reading. Katakana is folded
+// onto hiragana before lookup.
+var kanaRomaji = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n",
+	'ゔ': "vu",
+}
+
+// particleReadings lists kana that are also read differently when used
+// as particles, e.g. the は of こんにちは is read "wa"
+var particleReadings = map[rune]string{
+	'は': "wa",
+	'へ': "e",
+	'を': "wo",
+}
+
+// maxReadings caps the combinations of particle readings that are tried
+const maxReadings = 64
+
+// isKana reports whether r is hiragana, katakana or a kana mark
+func isKana(r rune) bool {
+	return unicode.In(r, unicode.Hiragana, unicode.Katakana) || r == 'ー'
+}
+
+// toHiragana folds katakana onto the matching hiragana
+func toHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - ('ァ' - 'ぁ')
+	}
+	return r
+}
+
+// kanaReadings returns the Hepburn readings of a kana-only text, one per
+// combination of particle readings. ok is false when the text contains
+// anything other than kana, e.g. kanji, since its reading is unknown.
+func kanaReadings(text string) (readings []string, ok bool) {
+	readings = []string{""}
+	geminate := false
+
+	for _, r := range text {
+		if unicode.IsSpace(r) || r == '・' {
+			continue
+		}
+		if !isKana(r) {
+			return nil, false
+		}
+		r = toHiragana(r)
+
+		switch r {
+		case 'っ':
+			geminate = true
+			continue
+		case 'ー':
+			// The long vowel mark repeats the previous vowel
+			for i, reading := range readings {
+				if reading != "" {
+					readings[i] = reading + reading[len(reading)-1:]
+				}
+			}
+			continue
+		case 'ゃ', 'ゅ', 'ょ':
+			vowel := map[rune]string{'ゃ': "a", 'ゅ': "u", 'ょ': "o"}[r]
+			for i, reading := range readings {
+				readings[i] = palatalize(reading, vowel)
+			}
+			continue
+		case 'ぁ', 'ぃ', 'ぅ', 'ぇ', 'ぉ':
+			vowel := map[rune]string{'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o"}[r]
+			for i, reading := range readings {
+				readings[i] = extendVowel(reading, vowel)
+			}
+			continue
+		}
+
+		syllable, known := kanaRomaji[r]
+		if !known {
+			return nil, false
+		}
+		alternatives := []string{syllable}
+		if particle, isParticle := particleReadings[r]; isParticle && len(readings)*2 <= maxReadings {
+			alternatives = append(alternatives, particle)
+		}
+
+		next := make([]string, 0, len(readings)*len(alternatives))
+		for _, reading := range readings {
+			for _, alt := range alternatives {
+				if geminate {
+					alt = geminateConsonant(alt) + alt
+				}
+				next = append(next, reading+alt)
+			}
+		}
+		readings = next
+		geminate = false
+	}
+
+	return readings, true
+}
+
+// palatalize combines a reading ending in an i-syllable with a small
+// ya/yu/yo, e.g. ki+ゃ becomes kya and shi+ゃ becomes sha
+func palatalize(reading, vowel string) string {
+	if !strings.HasSuffix(reading, "i") {
+		return reading + "y" + vowel
+	}
+	stem := strings.TrimSuffix(reading, "i")
+	if strings.HasSuffix(stem, "sh") || strings.HasSuffix(stem, "ch") || strings.HasSuffix(stem, "j") {
+		return stem + vowel
+	}
+	return stem + "y" + vowel
+}
+
+// extendVowel combines a reading with a small vowel as used in loanwords,
+// e.g. fu+ァ becomes fa and u+ィ becomes wi
+func extendVowel(reading, vowel string) string {
+	if reading == "" {
+		return vowel
+	}
+	if strings.HasSuffix(reading, "u") && (len(reading) == 1 || strings.ContainsRune("aeiou", rune(reading[len(reading)-2]))) {
+		return strings.TrimSuffix(reading, "u") + "w" + vowel
+	}
+	last := reading[len(reading)-1]
+	if strings.ContainsRune("aeiou", rune(last)) {
+		return reading[:len(reading)-1] + vowel
+	}
+	return reading + vowel
+}
+
+// geminateConsonant returns the consonant a small tsu doubles in front of
+// a syllable. Hepburn writes a doubled ch as tch.
+func geminateConsonant(syllable string) string {
+	if strings.HasPrefix(syllable, "ch") {
+		return "t"
+	}
+	if syllable == "" || strings.ContainsRune("aeiou", rune(syllable[0])) {
+		return ""
+	}
+	return syllable[:1]
+}
+
+// romajiFolds rewrites Hepburn spellings and long vowels into a single
+// canonical form so that Hepburn, Kunrei-shiki and macron spellings of a
+// reading compare equal. The order matters: multi-letter spellings are
+// folded before the letters they contain.
+var romajiFolds = strings.NewReplacer(
+	"tch", "tty",
+	"sh", "sy",
+	"ch", "ty",
+	"ts", "t",
+	"j", "zy",
+	"f", "h",
+	"du", "zu",
+	"di", "zi",
+	"mb", "nb",
+	"mp", "np",
+)
+
+// longVowels collapses doubled vowels and the "ou" spelling of a long o
+var longVowels = strings.NewReplacer(
+	"aa", "a",
+	"ii", "i",
+	"uu", "u",
+	"ee", "e",
+	"oo", "o",
+	"ou", "o",
+)
+
+// macrons maps vowels with a macron or circumflex to the plain vowel
+var macrons = strings.NewReplacer(
+	"ā", "a", "ī", "i", "ū", "u", "ē", "e", "ō", "o",
+	"â", "a", "î", "i", "û", "u", "ê", "e", "ô", "o",
+)
+
+// canonicalRomaji normalizes a romaji spelling for comparison
+func canonicalRomaji(romaji string) string {
+	s := macrons.Replace(strings.ToLower(romaji))
+	s = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '\'' || r == '.' {
+			return -1
+		}
+		return r
+	}, s)
+	s = romajiFolds.Replace(s)
+	s = strings.ReplaceAll(s, "syi", "si")
+	s = strings.ReplaceAll(s, "tyi", "ti")
+	s = strings.ReplaceAll(s, "zyi", "zi")
+	return longVowels.Replace(s)
+}
+
+// isRomaji reports whether text only contains Latin letters, including
+// vowels with a macron or circumflex, and word separators
+func isRomaji(text string) bool {
+	for _, r := range text {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case strings.ContainsRune("āīūēōâîûêôĀĪŪĒŌÂÎÛÊÔ", r):
+		case r == ' ' || r == '-' || r == '\'':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// KanaMatchesRomaji reports whether romaji is a plausible reading of
+// japanese. Hepburn, Kunrei-shiki and macron spellings are accepted, as
+// are particle readings. Text that is not written purely in kana cannot be
+// checked and always matches.
+func KanaMatchesRomaji(japanese, romaji string) bool {
+	readings, ok := kanaReadings(japanese)
+	if !ok {
+		return true
+	}
+
+	want := canonicalRomaji(romaji)
+	for _, reading := range readings {
+		if canonicalRomaji(reading) == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package validation
+
+import "testing"
+
+func TestKanaMatchesRomaji(t *testing.T) {
+	tests := []struct {
+		japanese string
+		romaji   string
+		want     bool
+	}{
+		{"ねこ", "neko", true},
+		{"ねこ", "Neko", true},
+		{"ねこ", "inu", false},
+		{"ネコ", "neko", true},
+		{"しんぶん", "shinbun", true},
+		{"きっぷ", "kippu", true},
+		{"まっちゃ", "matcha", true},
+		{"きょう", "kyou", true},
+		{"きょう", "kyō", true},
+		{"きょう", "kyo", true},
+		{"とうきょう", "Tôkyô", true},
+		{"しゃしん", "syasin", true},
+		{"ちず", "tizu", true},
+		{"つき", "tuki", true},
+		{"ふじ", "huzi", true},
+		{"コーヒー", "koohii", true},
+		{"コーヒー", "kōhī", true},
+		{"パーティー", "paatii", true},
+		{"こんにちは", "konnichiwa", true},
+		{"こんにちは", "konnichiha", true},
+		{"どこへ", "doko e", true},
+		{"ほんを", "hon wo", true},
+		{"ほんを", "hon o", true},
+		{"おはようございます", "ohayou gozaimasu", true},
+		{"おはようございます", "ohayo-gozaimasu", true},
+		{"おはようございます", "ohayou gozaimashita", false},
+		{"ありがとう", "thank you", false},
+		// Kanji cannot be read, so anything goes
+		{"猫", "inu", true},
+		{"日本語", "nihongo", true},
+	}
+	for _, tt := range tests {
+		if got := KanaMatchesRomaji(tt.japanese, tt.romaji); got != tt.want {
+			t.Errorf("KanaMatchesRomaji(%q, %q) = %v, want %v", tt.japanese, tt.romaji, got, tt.want)
+		}
+	}
+}
+
+func TestKanaMatchesRomajiCapsParticleReadings(t *testing.T) {
+	// Every は may be a particle, which would double the readings tried
+	// for each one
+	japanese := "はははははははははははははははははははは"
+	if !KanaMatchesRomaji(japanese, "hahahahahahahahahahahahahahahahahahahaha") {
+		t.Error("the plain reading of a long text did not match")
+	}
+}
+
+func TestIsRomaji(t *testing.T) {
+	tests := map[string]bool{
+		"neko":             true,
+		"ohayou gozaimasu": true,
+		"Tōkyō":            true,
+		"kon'ya":           true,
+		"o-cha":            true,
+		"ねこ":               false,
+		"neko1":            false,
+		"neko!":            false,
+		"":                 true,
+	}
+	for text, want := range tests {
+		if got := isRomaji(text); got != want {
+			t.Errorf("isRomaji(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
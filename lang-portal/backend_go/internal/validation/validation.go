@@ -0,0 +1,334 @@
+// Package validation implements the declarative rules in the `binding`
+// struct tags of the models and request payloads. The API validates request
+// bodies with it and the seed importer uses it directly, so data is checked
+// the same way whichever way it enters the database.
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+)
+
+// ActivityTypes are the study activity types that can be created
+var ActivityTypes = []string{"flashcard", "quiz", "typing", "matching"}
+
+// reference is a table an `exists` rule may name: the name of its rows in
+// error messages and the lookup that finds one by ID
+type reference struct {
+	noun   string
+	lookup func(store repository.Store, id int64) error
+}
+
+// existsTables are the tables an `exists` rule may reference. Lookups
+// return repository.ErrNotFound for a missing row.
+var existsTables = map[string]reference{
+	"words": {"word", func(store repository.Store, id int64) error {
+		missing, err := store.Words().Missing([]int64{id})
+		if err == nil && len(missing) > 0 {
+			err = repository.ErrNotFound
+		}
+		return err
+	}},
+	"groups": {"group", func(store repository.Store, id int64) error {
+		_, err := store.Groups().Get(id)
+		return err
+	}},
+	"activity_apps": {"activity app", func(store repository.Store, id int64) error {
+		_, err := store.Apps().Get(id)
+		return err
+	}},
+	"study_activities": {"study activity", func(store repository.Store, id int64) error {
+		_, err := store.Activities().Get(id)
+		return err
+	}},
+	"study_sessions": {"study session", func(store repository.Store, id int64) error {
+		_, err := store.Sessions().Get(id)
+		return err
+	}},
+}
+
+// ErrInvalid is the service error every validation failure unwraps to
+var ErrInvalid = service.NewValidationError("validation_failed", "validation failed")
+
+// FieldError describes why a single field failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors lists every field that failed validation
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, f := range e {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e Errors) Unwrap() error {
+	return ErrInvalid
+}
+
+// Details maps each invalid field to its message for the error response
+func (e Errors) Details() map[string]any {
+	fields := make(map[string]string, len(e))
+	for _, f := range e {
+		fields[f.Field] = f.Message
+	}
+	return map[string]any{"fields": fields}
+}
+
+// Validator checks structs against their `binding` tags. It implements
+// Gin's binding.StructValidator.
+type Validator struct {
+	// store answers the reference checks; nil skips them
+	store    repository.Store
+	validate *validator.Validate
+}
+
+// New creates a Validator. Foreign key rules (`exists=<table>`) look the
+// referenced row up in store; with a nil store they are skipped, for data
+// that carries no references.
+func New(store repository.Store) *Validator {
+	v := &Validator{store: store, validate: validator.New()}
+	v.validate.SetTagName("binding")
+	v.validate.RegisterTagNameFunc(jsonFieldName)
+
+	v.validate.RegisterValidation("notblank", validators.NotBlank)
+	v.validate.RegisterValidation("romaji", func(fl validator.FieldLevel) bool {
+		return isRomaji(fl.Field().String())
+	})
+	v.validate.RegisterValidation("activity_type", func(fl validator.FieldLevel) bool {
+		for _, t := range ActivityTypes {
+			if fl.Field().String() == t {
+				return true
+			}
+		}
+		return false
+	})
+	v.validate.RegisterValidation("launch_url", func(fl validator.FieldLevel) bool {
+		return isLaunchURL(fl.Field().String())
+	})
+	v.validate.RegisterValidationCtx("exists", v.exists)
+	v.validate.RegisterStructValidation(wordReading, models.Word{})
+
+	return v
+}
+
+// ValidateStruct validates a struct, a pointer to one, or each element of
+// a slice. Other values are accepted as is.
+func (v *Validator) ValidateStruct(obj any) error {
+	if obj == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(obj)
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return v.ValidateStruct(value.Elem().Interface())
+	case reflect.Struct:
+		return v.Struct(obj)
+	case reflect.Slice, reflect.Array:
+		var errs Errors
+		for i := 0; i < value.Len(); i++ {
+			err := v.ValidateStruct(value.Index(i).Interface())
+			var fieldErrs Errors
+			if errors.As(err, &fieldErrs) {
+				for _, f := range fieldErrs {
+					f.Field = fmt.Sprintf("[%d].%s", i, f.Field)
+					errs = append(errs, f)
+				}
+			} else if err != nil {
+				return err
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Engine returns the underlying go-playground validator
+func (v *Validator) Engine() any {
+	return v.validate
+}
+
+// lookups collects the first error of the reference checks of one Struct
+// call, which the rules themselves can only answer with true or false
+type lookups struct {
+	err error
+}
+
+type lookupsKey struct{}
+
+// fail records err unless an earlier check already failed
+func (l *lookups) fail(err error) {
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+// Struct validates a struct and returns Errors describing every invalid
+// field, or nil. A reference that could not be checked is returned as the
+// error instead.
+func (v *Validator) Struct(obj any) error {
+	checks := &lookups{}
+	err := v.validate.StructCtx(context.WithValue(context.Background(), lookupsKey{}, checks), obj)
+	if checks.err != nil {
+		return checks.err
+	}
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	errs := make(Errors, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		errs = append(errs, FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Message: message(fe),
+		})
+	}
+	return errs
+}
+
+// exists checks that an ID references a row of the table named by the
+// rule's parameter. A rule naming an unknown table, or a failed query, is
+// recorded in the lookups of ctx rather than reported as an invalid field.
+func (v *Validator) exists(ctx context.Context, fl validator.FieldLevel) bool {
+	checks := ctx.Value(lookupsKey{}).(*lookups)
+	table := fl.Param()
+	ref, ok := existsTables[table]
+	if !ok {
+		checks.fail(fmt.Errorf("validation: exists rule references unknown table %q", table))
+		return true
+	}
+	if v.store == nil {
+		return true
+	}
+
+	err := ref.lookup(v.store, fl.Field().Int())
+	if errors.Is(err, repository.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		checks.fail(fmt.Errorf("validation: checking %s reference: %w", table, err))
+	}
+	return true
+}
+
+// isLaunchURL reports whether a launch URL template only uses known
+// placeholders and expands to an absolute http or https URL
+func isLaunchURL(template string) bool {
+	sample := make(map[string]string, len(service.LaunchPlaceholders))
+	for _, name := range service.LaunchPlaceholders {
+		sample[name] = "1"
+	}
+	expanded, err := service.ExpandLaunchURL(template, sample)
+	if err != nil {
+		return false
+	}
+	u, err := url.Parse(expanded)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// wordReading checks that the romaji of a word written in kana is a
+// reading of its japanese text
+func wordReading(sl validator.StructLevel) {
+	word := sl.Current().Interface().(models.Word)
+	if strings.TrimSpace(word.Japanese) == "" || strings.TrimSpace(word.Romaji) == "" {
+		return
+	}
+	if !KanaMatchesRomaji(word.Japanese, word.Romaji) {
+		sl.ReportError(word.Romaji, "romaji", "Romaji", "kana_romaji", "")
+	}
+}
+
+// jsonFieldName names fields after their JSON key so errors match the
+// request payload
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// fieldPath returns the JSON path of a field relative to the validated
+// struct, e.g. "words[0].japanese"
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if _, rest, found := strings.Cut(ns, "."); found {
+		return rest
+	}
+	return ns
+}
+
+// message describes a failed rule in plain words
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "romaji":
+		return "must only contain romaji letters, spaces, hyphens and apostrophes"
+	case "kana_romaji":
+		return "does not match the reading of japanese"
+	case "activity_type":
+		return "must be one of " + strings.Join(ActivityTypes, ", ")
+	case "launch_url":
+		return "must be an http or https URL using only the placeholders {" + strings.Join(service.LaunchPlaceholders, "}, {") + "}"
+	case "url":
+		return "must be an absolute URL"
+	case "exists":
+		return "does not reference an existing " + existsTables[fe.Param()].noun
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min":
+		if fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map {
+			if fe.Param() == "1" {
+				return "must not be empty"
+			}
+			return "must contain at least " + fe.Param() + " items"
+		}
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be at most " + fe.Param()
+	default:
+		return "failed the " + fe.Tag() + " rule"
+	}
+}
@@ -0,0 +1,183 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository"
+	"github.com/erans/lang-portal/internal/repository/memstore"
+)
+
+func TestStruct(t *testing.T) {
+	type batch struct {
+		Words []models.Word `json:"words" binding:"min=1,dive"`
+	}
+	tests := []struct {
+		name string
+		obj  any
+		// want maps each invalid field to its rule
+		want map[string]string
+	}{
+		{
+			name: "valid word",
+			obj:  models.Word{Japanese: "ねこ", Romaji: "neko", English: "cat"},
+		},
+		{
+			name: "blank fields",
+			obj:  models.Word{Japanese: " ", Romaji: "neko", English: ""},
+			want: map[string]string{"japanese": "notblank", "english": "notblank"},
+		},
+		{
+			name: "romaji that is not a reading",
+			obj:  models.Word{Japanese: "ねこ", Romaji: "inu", English: "cat"},
+			want: map[string]string{"romaji": "kana_romaji"},
+		},
+		{
+			name: "romaji with other letters",
+			obj:  models.Word{Japanese: "猫", Romaji: "ねこ", English: "cat"},
+			want: map[string]string{"romaji": "romaji"},
+		},
+		{
+			name: "activity type",
+			obj:  models.StudyActivity{GroupID: 1, ActivityType: "essay"},
+			want: map[string]string{"activity_type": "activity_type"},
+		},
+		{
+			name: "launch url placeholder",
+			obj:  models.ActivityApp{Name: "Kana Quiz", ActivityType: "quiz", LaunchURL: "https://quiz.example/{lesson}"},
+			want: map[string]string{"launch_url": "launch_url"},
+		},
+		{
+			name: "launch url scheme",
+			obj:  models.ActivityApp{Name: "Kana Quiz", ActivityType: "quiz", LaunchURL: "ftp://quiz.example/{group_id}"},
+			want: map[string]string{"launch_url": "launch_url"},
+		},
+		{
+			name: "words of a batch",
+			obj:  batch{Words: []models.Word{{Japanese: "ねこ", Romaji: "neko", English: "cat"}, {Japanese: "いぬ", Romaji: "neko", English: "dog"}}},
+			want: map[string]string{"words[1].romaji": "kana_romaji"},
+		},
+		{
+			name: "empty batch",
+			obj:  batch{},
+			want: map[string]string{"words": "min"},
+		},
+	}
+	v := New(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Struct(tt.obj)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("error = %v, want none", err)
+				}
+				return
+			}
+
+			var fieldErrs Errors
+			if !errors.As(err, &fieldErrs) || !errors.Is(err, ErrInvalid) {
+				t.Fatalf("error = %v, want field errors", err)
+			}
+			got := make(map[string]string, len(fieldErrs))
+			for _, f := range fieldErrs {
+				got[f.Field] = f.Rule
+				if f.Message == "" {
+					t.Errorf("%s has no message", f.Field)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("invalid fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidatorUnknownTable checks that an exists rule naming a table the
+// validator does not know fails validation with an error, not a panic
+func TestValidatorUnknownTable(t *testing.T) {
+	payload := struct {
+		ID int64 `json:"id" binding:"exists=lessons"`
+	}{ID: 1}
+
+	err := New(nil).ValidateStruct(payload)
+	var fieldErrs Errors
+	if err == nil || errors.As(err, &fieldErrs) {
+		t.Fatalf("error = %v, want an error that is not a field error", err)
+	}
+}
+
+// failingGroups is a store whose group lookups fail
+type failingGroups struct {
+	repository.Store
+}
+
+func (s failingGroups) Groups() repository.GroupRepository {
+	return failingGroupRepo{s.Store.Groups()}
+}
+
+type failingGroupRepo struct {
+	repository.GroupRepository
+}
+
+func (failingGroupRepo) Get(int64) (*models.Group, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestExists(t *testing.T) {
+	store := memstore.New()
+	group := models.Group{Name: "Animals"}
+	if err := store.Groups().Create(&group); err != nil {
+		t.Fatal(err)
+	}
+	word := models.Word{Japanese: "ねこ", Romaji: "neko", English: "cat"}
+	if err := store.Words().Create(&word); err != nil {
+		t.Fatal(err)
+	}
+
+	type payload struct {
+		GroupID int64 `json:"group_id" binding:"required,exists=groups"`
+		WordID  int64 `json:"word_id" binding:"omitempty,exists=words"`
+	}
+	tests := []struct {
+		name    string
+		store   repository.Store
+		obj     payload
+		want    []string
+		wantErr bool
+	}{
+		{name: "existing rows", store: store, obj: payload{GroupID: group.ID, WordID: word.ID}},
+		{name: "missing rows", store: store, obj: payload{GroupID: 99, WordID: 98}, want: []string{"group_id", "word_id"}},
+		{name: "without a store", store: nil, obj: payload{GroupID: 99}},
+		{name: "failed lookup", store: failingGroups{store}, obj: payload{GroupID: group.ID}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(tt.store).Struct(tt.obj)
+			var fieldErrs Errors
+			isFieldErr := errors.As(err, &fieldErrs)
+			switch {
+			case tt.wantErr:
+				if err == nil || isFieldErr {
+					t.Fatalf("error = %v, want a lookup error", err)
+				}
+			case tt.want == nil:
+				if err != nil {
+					t.Fatalf("error = %v, want none", err)
+				}
+			default:
+				var fields []string
+				for _, f := range fieldErrs {
+					fields = append(fields, f.Field)
+					if f.Rule != "exists" {
+						t.Errorf("%s failed %s, want exists", f.Field, f.Rule)
+					}
+				}
+				if !isFieldErr || !reflect.DeepEqual(fields, tt.want) {
+					t.Fatalf("error = %v, want invalid %v", err, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -5,85 +5,301 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/erans/lang-portal/internal/config"
+	"github.com/erans/lang-portal/internal/database"
+	"github.com/erans/lang-portal/internal/dialect"
+	"github.com/erans/lang-portal/internal/importer"
+	"github.com/erans/lang-portal/internal/models"
+	"github.com/erans/lang-portal/internal/repository/sqlstore"
+	"github.com/erans/lang-portal/internal/service"
+	"github.com/erans/lang-portal/internal/validation"
 	"github.com/magefile/mage/mg"
 	"github.com/magefile/mage/sh"
-	_ "github.com/mattn/go-sqlite3"
 )
 
 // Default target to run when none is specified
 var Default = Build
 
+// buildTags enables the SQLite features the server depends on (FTS5 search)
+const buildTags = "sqlite_fts5"
+
 // Build builds the application
 func Build() error {
 	fmt.Println("Building...")
-	return sh.Run("go", "build", "-o", "bin/server", "./cmd/server")
+	return sh.Run("go", "build", "-tags", buildTags, "-o", "bin/server", "./cmd/server")
+}
+
+// dbConfig returns the database configuration from the config file and
+// environment. Mage targets always operate on the SQLite file or Postgres
+// database itself.
+func dbConfig() (database.Config, error) {
+	serverCfg, _, err := config.Load(nil)
+	if err != nil {
+		return database.Config{}, err
+	}
+	cfg := serverCfg.Database
+	if cfg.Mode == database.ModeMemory {
+		return cfg, fmt.Errorf("mage targets cannot operate on an in-memory database")
+	}
+	cfg.Mode = database.ModePersistent
+	return cfg, nil
 }
 
-// InitDB initializes the SQLite database
+// openDB opens the configured database
+func openDB() (*sql.DB, error) {
+	cfg, err := dbConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	return db, nil
+}
+
+// InitDB creates the SQLite database file, or checks that the Postgres
+// server is reachable
 func InitDB() error {
 	fmt.Println("Initializing database...")
 
-	dbPath := "words.db"
-	if _, err := os.Stat(dbPath); err == nil {
-		fmt.Println("Database already exists")
-		return nil
+	cfg, err := dbConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Dialect() == dialect.SQLite {
+		if _, err := os.Stat(cfg.Path); err == nil {
+			fmt.Println("Database already exists")
+			return nil
+		}
 	}
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := openDB()
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return err
 	}
 	defer db.Close()
 
 	return nil
 }
 
-// Migrate runs database migrations
+// Migrate applies pending database migrations
 func Migrate() error {
 	mg.Deps(InitDB)
 
 	fmt.Println("Running migrations...")
 
-	db, err := sql.Open("sqlite3", "words.db")
+	db, err := openDB()
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return err
 	}
 	defer db.Close()
 
-	migrations, err := filepath.Glob("db/migrations/*.sql")
+	applied, err := database.Migrate(db, database.MigrationsDir)
+	for _, m := range applied {
+		fmt.Printf("Applied migration: %s\n", m.Name)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to list migrations: %v", err)
+		return err
 	}
 
-	for _, migration := range migrations {
-		fmt.Printf("Applying migration: %s\n", migration)
+	if len(applied) == 0 {
+		fmt.Println("Database schema is up to date")
+	}
 
-		content, err := os.ReadFile(migration)
-		if err != nil {
-			return fmt.Errorf("failed to read migration %s: %v", migration, err)
-		}
+	return nil
+}
 
-		if _, err := db.Exec(string(content)); err != nil {
-			return fmt.Errorf("failed to apply migration %s: %v", migration, err)
+// Rollback reverts the most recently applied migration
+func Rollback() error {
+	mg.Deps(InitDB)
+
+	fmt.Println("Rolling back last migration...")
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	reverted, err := database.Rollback(db, database.MigrationsDir, 1)
+	for _, m := range reverted {
+		fmt.Printf("Reverted migration: %s\n", m.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(reverted) == 0 {
+		fmt.Println("No migrations to roll back")
+	}
+
+	return nil
+}
+
+// MigrateStatus lists migrations and whether they have been applied
+func MigrateStatus() error {
+	mg.Deps(InitDB)
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	states, err := database.MigrationStatus(db, database.MigrationsDir)
+	if err != nil {
+		return err
+	}
+
+	for _, state := range states {
+		status := "pending"
+		if state.Applied {
+			status = "applied " + state.AppliedAt
 		}
+		fmt.Printf("%-40s %s\n", state.Name, status)
 	}
 
 	return nil
 }
 
-// Seed adds sample data to the database
+// Seed imports the JSON seed files declared in db/seeds/seeds.manifest
 func Seed() error {
 	mg.Deps(Migrate)
 
 	fmt.Println("Seeding database...")
+	return runSeeds(database.SeedOptions{})
+}
+
+// SeedDryRun validates the JSON seeds and reports what Seed would change
+func SeedDryRun() error {
+	mg.Deps(Migrate)
 
-	db, err := sql.Open("sqlite3", "words.db")
+	fmt.Println("Validating seeds...")
+	return runSeeds(database.SeedOptions{DryRun: true})
+}
+
+// runSeeds applies the seed manifest and prints the resulting report
+func runSeeds(opts database.SeedOptions) error {
+	db, err := openDB()
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return err
+	}
+	defer db.Close()
+
+	report, err := database.SeedFromManifest(db, filepath.Join(database.SeedsDir, database.ManifestFile), opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(report)
+	return nil
+}
+
+// Import adds the words of a CSV, TSV, JSON seed or Anki .apkg file to a
+// group, creating the group when it does not exist. The format is taken
+// from the file's extension. Columns are mapped with
+// LANG_PORTAL_IMPORT_COLUMNS, e.g. "japanese=Kanji,english=Meaning".
+func Import(file, group string) error {
+	mg.Deps(Migrate)
+
+	fmt.Printf("Importing %s into %q...\n", file, group)
+	return runImport(file, group, false)
+}
+
+// ImportDryRun reports what Import would change without changing anything
+func ImportDryRun(file, group string) error {
+	mg.Deps(Migrate)
+
+	fmt.Printf("Checking the import of %s into %q...\n", file, group)
+	return runImport(file, group, true)
+}
+
+// runImport imports a file and prints the rows that were not skipped
+func runImport(path, group string, dryRun bool) error {
+	format, err := importer.ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return err
+	}
+	opts := service.ImportOptions{GroupName: group, DryRun: dryRun}
+	if spec := os.Getenv("LANG_PORTAL_IMPORT_COLUMNS"); spec != "" {
+		opts.Columns = make(map[string]string)
+		for _, pair := range strings.Split(spec, ",") {
+			field, column, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("LANG_PORTAL_IMPORT_COLUMNS: %q is not field=column", pair)
+			}
+			opts.Columns[strings.TrimSpace(field)] = strings.TrimSpace(column)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	store := sqlstore.New(db)
+	imports := service.NewImportService(store, validation.New(store))
+	report, err := imports.Import(file, format, opts)
+	var importErr *service.ImportError
+	if errors.As(err, &importErr) {
+		report = importErr.Report
+	} else if err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case service.ImportErrored:
+			fmt.Printf("row %d: %s\n", row.Row, row.Error)
+		case service.ImportCreated, service.ImportUpdated:
+			fmt.Printf("row %d: %s %s (%s)\n", row.Row, row.Status, row.Japanese, row.English)
+		}
+	}
+	fmt.Printf("%d created, %d updated, %d skipped, %d errors; %d words added to %q\n",
+		report.Created, report.Updated, report.Skipped, report.Errored, report.Linked, report.Group)
+	switch {
+	case importErr != nil:
+		return importErr
+	case dryRun:
+		fmt.Println("Dry run, no changes were committed")
+	}
+	return nil
+}
+
+// SeedTestData loads the SQL fixtures in db/seeds/*.sql, which are written
+// for SQLite
+func SeedTestData() error {
+	mg.Deps(Migrate)
+
+	fmt.Println("Loading test data...")
+
+	cfg, err := dbConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Dialect() != dialect.SQLite {
+		return fmt.Errorf("the SQL test data is written for sqlite; use mage seed for %s", cfg.Dialect())
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return err
 	}
 	defer db.Close()
 
@@ -108,10 +324,197 @@ func Seed() error {
 	return nil
 }
 
-// Clean removes generated files
+// CheckSchema runs every dashboard query against freshly migrated
+// in-memory databases, empty and loaded with db/seeds/test_data.sql, so
+// queries that drift from the schema fail here rather than in production
+func CheckSchema() error {
+	fmt.Println("Checking dashboard queries against the schema...")
+
+	empty, err := schemaCheckDB(false)
+	if err != nil {
+		return err
+	}
+	defer empty.Close()
+
+	if err := checkDashboard(service.NewDashboardService(sqlstore.New(empty)), nil); err != nil {
+		return fmt.Errorf("empty database: %v", err)
+	}
+
+	seeded, err := schemaCheckDB(true)
+	if err != nil {
+		return err
+	}
+	defer seeded.Close()
+
+	// Expected values for db/seeds/test_data.sql
+	want := &dashboardExpectations{
+		lastSessionID:      2,
+		lastWordsReviewed:  1,
+		lastCorrectAnswers: 0,
+		totalStudyTime:     540,
+		sessionsCompleted:  2,
+		totalWordsReviewed: 4,
+		successRate:        75,
+		streakDays:         2,
+		wordsStudied:       4,
+		availableWords:     5,
+	}
+	if err := checkDashboard(service.NewDashboardService(sqlstore.New(seeded)), want); err != nil {
+		return fmt.Errorf("seeded database: %v", err)
+	}
+
+	fmt.Println("All dashboard queries match the schema")
+	return nil
+}
+
+// schemaCheckDB opens a migrated in-memory database, optionally loaded
+// with the SQL test data
+func schemaCheckDB(withTestData bool) (*sql.DB, error) {
+	db, err := database.Open(database.Config{Mode: database.ModeMemory})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := database.Migrate(db, database.MigrationsDir); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if withTestData {
+		content, err := os.ReadFile("db/seeds/test_data.sql")
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		if _, err := db.Exec(string(content)); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to load test data: %v", err)
+		}
+	}
+
+	return db, nil
+}
+
+// dashboardExpectations are the dashboard values expected for a fixture
+type dashboardExpectations struct {
+	lastSessionID      int64
+	lastWordsReviewed  int
+	lastCorrectAnswers int
+	totalStudyTime     int
+	sessionsCompleted  int
+	totalWordsReviewed int
+	successRate        float64
+	streakDays         int
+	wordsStudied       int
+	availableWords     int
+}
+
+// checkDashboard runs each dashboard query for the default user, who owns
+// the seeded sessions, and compares the results with want. A nil want
+// expects an empty database.
+func checkDashboard(dashboard *service.DashboardService, want *dashboardExpectations) error {
+	if want == nil {
+		want = &dashboardExpectations{}
+	}
+
+	last, err := dashboard.GetLastSession(models.DefaultUserID)
+	if err != nil {
+		return fmt.Errorf("GetLastSession: %v", err)
+	}
+	switch {
+	case want.lastSessionID == 0 && last != nil:
+		return fmt.Errorf("GetLastSession: expected no session, got %d", last.SessionID)
+	case want.lastSessionID != 0 && last == nil:
+		return fmt.Errorf("GetLastSession: expected session %d, got none", want.lastSessionID)
+	case last != nil:
+		if err := expect("GetLastSession",
+			"session_id", last.SessionID, want.lastSessionID,
+			"words_reviewed", last.WordsReviewed, want.lastWordsReviewed,
+			"correct_answers", last.CorrectAnswers, want.lastCorrectAnswers,
+		); err != nil {
+			return err
+		}
+	}
+
+	stats, err := dashboard.GetStats(models.DefaultUserID)
+	if err != nil {
+		return fmt.Errorf("GetStats: %v", err)
+	}
+	if err := expect("GetStats",
+		"total_study_time", stats.TotalStudyTime, want.totalStudyTime,
+		"sessions_completed", stats.SessionsCompleted, want.sessionsCompleted,
+		"total_words_reviewed", stats.TotalWordsReviewed, want.totalWordsReviewed,
+		"success_rate", stats.SuccessRate, want.successRate,
+		"study_streak_days", stats.StudyStreakDays, want.streakDays,
+	); err != nil {
+		return err
+	}
+
+	progress, err := dashboard.GetProgress(models.DefaultUserID)
+	if err != nil {
+		return fmt.Errorf("GetProgress: %v", err)
+	}
+	return expect("GetProgress",
+		"total_words_studied", progress.TotalWordsStudied, want.wordsStudied,
+		"total_available_words", progress.TotalAvailableWords, want.availableWords,
+	)
+}
+
+// expect compares (name, got, want) triples and reports the first mismatch
+func expect(query string, triples ...any) error {
+	for i := 0; i+2 < len(triples); i += 3 {
+		if fmt.Sprint(triples[i+1]) != fmt.Sprint(triples[i+2]) {
+			return fmt.Errorf("%s: %s = %v, want %v", query, triples[i], triples[i+1], triples[i+2])
+		}
+	}
+	return nil
+}
+
+// goTest runs the tests of internal/testutil matching run
+func goTest(run string) error {
+	args := []string{"test", "-tags", buildTags, "-count=1", "-run", run}
+	if mg.Verbose() {
+		args = append(args, "-v")
+	}
+	return sh.RunV("go", append(args, "./internal/testutil")...)
+}
+
+// CheckAPI runs TestAPI, driving every handler through the router against
+// in-memory databases loaded with fixtures
+func CheckAPI() error {
+	fmt.Println("Running API tests...")
+	return goTest("^TestAPI$")
+}
+
+// CheckStores runs TestStores against SQLite, Postgres and the in-memory
+// fake, so all three behave the same. Postgres is skipped when no server
+// is available.
+func CheckStores() error {
+	fmt.Println("Running store tests...")
+	return goTest("^TestStores$")
+}
+
+// CheckShutdown runs TestShutdown, which shuts the server down while a
+// review is in flight and checks the review completes and is saved
+func CheckShutdown() error {
+	fmt.Println("Checking graceful shutdown...")
+	return goTest("^TestShutdown$")
+}
+
+// Clean removes generated files. A Postgres database is left alone.
 func Clean() error {
 	fmt.Println("Cleaning...")
-	os.Remove("words.db")
+
+	cfg, err := dbConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Dialect() != dialect.SQLite {
+		return nil
+	}
+	for _, file := range []string{cfg.Path, cfg.Path + "-wal", cfg.Path + "-shm"} {
+		os.Remove(file)
+	}
 	return nil
 }
 
@@ -119,5 +522,5 @@ func Clean() error {
 func Dev() error {
 	mg.Deps(Migrate)
 	fmt.Println("Starting server in development mode...")
-	return sh.Run("go", "run", "./cmd/server")
+	return sh.Run("go", "run", "-tags", buildTags, "./cmd/server")
 }